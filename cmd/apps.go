@@ -0,0 +1,18 @@
+package main
+
+import (
+	"lines/lines/app"
+	"lines/user"
+)
+
+// registrations lists every app the monolith can run, ENABLED_APPS picks the ones this process runs.
+var registrations = []app.Registration{
+	{
+		Name: "user",
+		New: func() app.App {
+			userApp := user.NewUserApp()
+			return &userApp
+		},
+		NewRemote: func() app.App { return user.NewRemoteUserApp() },
+	},
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"lines/lines/app"
+	"testing"
+)
+
+func TestRegistrations_RemoteStandInsMatchTheirApps(t *testing.T) {
+	for _, registration := range registrations {
+		if registration.NewRemote == nil {
+			continue
+		}
+		assert.Equal(t, registration.Name, app.Name(registration.NewRemote()))
+	}
+}
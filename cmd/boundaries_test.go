@@ -0,0 +1,10 @@
+package main
+
+import (
+	"lines/lines/app/apptest"
+	"testing"
+)
+
+func TestAppBoundaries(t *testing.T) {
+	apptest.CheckAppBoundaries(t, "..")
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"lines/internal"
+	"lines/lines/app"
+	"lines/lines/http"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Commands returns the built in subcommands followed by the ones the apps add.
+// serve runs the monolith, and work runs the job worker and scheduled tasks, until ctx is cancelled. migrateStores
+// migrates the monolith's own stores, before the apps' stores are migrated.
+func Commands(
+	apps []app.App,
+	config *internal.MainConfig,
+	serve func(ctx context.Context),
+	work func(ctx context.Context),
+	migrateStores func(out io.Writer) error,
+) ([]app.Command, error) {
+	commands := []app.Command{
+		{
+			Name:  "serve",
+			Usage: "Run the HTTP and gRPC servers until SIGTERM, the default command.",
+			Run: func(ctx context.Context, args []string, out io.Writer) error {
+				serve(ctx)
+				return nil
+			},
+		},
+		{
+			Name:  "worker",
+			Usage: "Run the background job worker and scheduled tasks until SIGTERM.",
+			Run: func(ctx context.Context, args []string, out io.Writer) error {
+				work(ctx)
+				return nil
+			},
+		},
+		{
+			Name:  "migrate",
+			Usage: "Migrate the job queue's, scheduler's and every app's stores.",
+			Run: func(ctx context.Context, args []string, out io.Writer) error {
+				err := migrateStores(out)
+				if err != nil {
+					return err
+				}
+				return migrateCommand(apps, out)
+			},
+		},
+		{
+			Name:  "routes",
+			Usage: "List the HTTP routes the apps register.",
+			Run: func(ctx context.Context, args []string, out io.Writer) error {
+				return withInitialisedApps(apps, config, func(initialised []app.App) error {
+					return routesCommand(initialised, config, out)
+				})
+			},
+		},
+		{
+			Name:  "check-config",
+			Usage: "Check the monolith's and every app's configuration.",
+			Run: func(ctx context.Context, args []string, out io.Writer) error {
+				return checkConfigCommand(apps, config, out)
+			},
+		},
+	}
+
+	names := map[string]string{}
+	for _, command := range commands {
+		names[command.Name] = "main"
+	}
+	for _, a := range apps {
+		for _, command := range a.Commands() {
+			if owner, ok := names[command.Name]; ok {
+				return nil, fmt.Errorf("app %s adds command %q, which %s already has", app.Name(a), command.Name, owner)
+			}
+			names[command.Name] = app.Name(a)
+			run := command.Run
+			command.Run = func(ctx context.Context, args []string, out io.Writer) error {
+				return withInitialisedApps(apps, config, func([]app.App) error {
+					return run(ctx, args, out)
+				})
+			}
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+// RunCommand runs the command named by args[0] with the rest of args, or serve if there are no args.
+func RunCommand(ctx context.Context, args []string, commands []app.Command, out io.Writer) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		return printUsage(commands, out)
+	}
+	for _, command := range commands {
+		if command.Name == name {
+			return command.Run(ctx, args, out)
+		}
+	}
+	err := printUsage(commands, out)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+func printUsage(commands []app.Command, out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Usage: main <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", command.Name, command.Usage)
+	}
+	return w.Flush()
+}
+
+// withInitialisedApps initialises the apps, runs fn and then shuts them down again.
+func withInitialisedApps(apps []app.App, config *internal.MainConfig, fn func(initialised []app.App) error) error {
+	initialised, err := initialiseApps(apps)
+	defer shutdownAfterCommand(initialised, config)
+	if err != nil {
+		return fmt.Errorf("failed to initialise apps: %w", err)
+	}
+	return fn(initialised)
+}
+
+// shutdownAfterCommand shuts the apps down once a command has finished with them, closing their stores.
+func shutdownAfterCommand(apps []app.App, config *internal.MainConfig) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(config.ShutdownTimeoutSeconds)*time.Second,
+	)
+	defer cancel()
+	shutdownApps(ctx, apps, config.Logger)
+}
+
+func migrateCommand(apps []app.App, out io.Writer) error {
+	layers, err := app.InitialisationOrder(apps)
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		for _, a := range layer {
+			migrating, ok := a.(app.MigratingApp)
+			if !ok {
+				continue
+			}
+			err = migrating.Migrate()
+			if err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", app.Name(a), err)
+			}
+			fmt.Fprintf(out, "Migrated %s\n", app.Name(a))
+		}
+	}
+	return nil
+}
+
+func routesCommand(apps []app.App, config *internal.MainConfig, out io.Writer) error {
+	// Release mode stops gin printing every route as it's registered.
+	gin.SetMode(gin.ReleaseMode)
+	engine := http.CreateEngine(config)
+	for _, a := range apps {
+		a.RegisterHTTPRoutes(engine)
+	}
+	routes := engine.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, route := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", route.Method, route.Path, route.Handler)
+	}
+	return w.Flush()
+}
+
+func checkConfigCommand(apps []app.App, config *internal.MainConfig, out io.Writer) error {
+	var errs []error
+	err := config.Validate()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("main: %w", err))
+	}
+	for _, a := range apps {
+		configurable, ok := a.(app.ConfigurableApp)
+		if !ok {
+			continue
+		}
+		err = configurable.CheckConfig()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", app.Name(a), err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	_, err = fmt.Fprintln(out, "Config OK")
+	return err
+}
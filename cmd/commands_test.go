@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"lines/internal"
+	"lines/lines/app"
+	"lines/lines/http"
+	"strings"
+	"testing"
+)
+
+func newCommandTestConfig() *internal.MainConfig {
+	config := newTestConfig()
+	config.LogLevel = "info"
+	config.CORSOrigins = []string{"http://localhost"}
+	config.HTTPPort = 8080
+	config.GRPCPort = 9090
+	config.MetricsPort = 9100
+	config.CookieSameSite = "lax"
+	return config
+}
+
+// noStores stands in for migrating the monolith's own stores.
+func noStores(io.Writer) error {
+	return nil
+}
+
+func runTestCommand(t *testing.T, apps []app.App, args ...string) (string, error) {
+	commands, err := Commands(apps, newCommandTestConfig(), func(ctx context.Context) {}, func(ctx context.Context) {}, noStores)
+	assert.Nil(t, err)
+	out := &bytes.Buffer{}
+	err = RunCommand(context.Background(), args, commands, out)
+	return out.String(), err
+}
+
+func TestRunCommand_DefaultsToServe(t *testing.T) {
+	served := 0
+	commands, err := Commands(
+		nil,
+		newCommandTestConfig(),
+		func(ctx context.Context) { served++ },
+		func(ctx context.Context) {},
+		noStores,
+	)
+	assert.Nil(t, err)
+
+	err = RunCommand(context.Background(), nil, commands, io.Discard)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, served)
+}
+
+func TestRunCommand_Worker(t *testing.T) {
+	worked := 0
+	commands, err := Commands(
+		nil,
+		newCommandTestConfig(),
+		func(ctx context.Context) {},
+		func(ctx context.Context) { worked++ },
+		noStores,
+	)
+	assert.Nil(t, err)
+
+	err = RunCommand(context.Background(), []string{"worker"}, commands, io.Discard)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, worked)
+}
+
+func TestRunCommand_Help(t *testing.T) {
+	out, err := runTestCommand(t, nil, "help")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Usage: main <command> [arguments]")
+	assert.Contains(t, out, "check-config")
+}
+
+func TestRunCommand_UnknownCommand(t *testing.T) {
+	out, err := runTestCommand(t, nil, "unknown")
+
+	assert.EqualError(t, err, `unknown command "unknown"`)
+	assert.Contains(t, out, "Commands:")
+}
+
+func TestCommands_AppCommandsRunWithInitialisedApps(t *testing.T) {
+	a := &mockApp{}
+	var initialiseCalls int
+	a.commands = []app.Command{{
+		Name: "greet",
+		Run: func(ctx context.Context, args []string, out io.Writer) error {
+			initialiseCalls = a.InitialiseCalls
+			_, err := io.WriteString(out, "hello "+args[0])
+			return err
+		},
+	}}
+
+	out, err := runTestCommand(t, []app.App{a}, "greet", "world")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", out)
+	assert.Equal(t, 1, initialiseCalls)
+	assert.Equal(t, 1, a.ShutdownCalls)
+}
+
+func TestCommands_DuplicateName(t *testing.T) {
+	apps := []app.App{&mockApp{commands: []app.Command{{Name: "migrate"}}}}
+
+	_, err := Commands(apps, newCommandTestConfig(), func(ctx context.Context) {}, func(ctx context.Context) {}, noStores)
+
+	assert.EqualError(t, err, `app *main.mockApp adds command "migrate", which main already has`)
+}
+
+type migratingMockApp struct {
+	*dependentMockApp
+	migrateErr error
+}
+
+func (m *migratingMockApp) Migrate() error {
+	if m.migrateErr != nil {
+		return m.migrateErr
+	}
+	*m.initialiseOrder = append(*m.initialiseOrder, m.name)
+	return nil
+}
+
+func TestMigrateCommand_MigratesInDependencyOrder(t *testing.T) {
+	var order []string
+	newApp := func(name string, dependencies ...string) *migratingMockApp {
+		return &migratingMockApp{dependentMockApp: &dependentMockApp{
+			mockApp:         &mockApp{},
+			name:            name,
+			dependencies:    dependencies,
+			initialiseOrder: &order,
+		}}
+	}
+	billing := newApp("billing", "user")
+	apps := []app.App{billing, newApp("user"), &mockApp{}}
+
+	out, err := runTestCommand(t, apps, "migrate")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user", "billing"}, order)
+	assert.Equal(t, "Migrated user\nMigrated billing\n", out)
+	assert.Equal(t, 0, billing.InitialiseCalls)
+	assert.Equal(t, 0, billing.ShutdownCalls, "Apps that weren't initialised aren't shut down")
+}
+
+func TestMigrateCommand_MigratesOwnStoresFirst(t *testing.T) {
+	apps := []app.App{&migratingMockApp{dependentMockApp: &dependentMockApp{
+		mockApp:         &mockApp{},
+		name:            "user",
+		initialiseOrder: &[]string{},
+	}}}
+	migrateStores := func(out io.Writer) error {
+		_, err := io.WriteString(out, "Migrated jobs\n")
+		return err
+	}
+	commands, err := Commands(
+		apps,
+		newCommandTestConfig(),
+		func(ctx context.Context) {},
+		func(ctx context.Context) {},
+		migrateStores,
+	)
+	assert.Nil(t, err)
+	out := &bytes.Buffer{}
+
+	err = RunCommand(context.Background(), []string{"migrate"}, commands, out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Migrated jobs\nMigrated user\n", out.String())
+}
+
+func TestMigrateCommand_OwnStoreError(t *testing.T) {
+	apps := []app.App{&migratingMockApp{dependentMockApp: &dependentMockApp{mockApp: &mockApp{}, name: "user"}}}
+	migrateStores := func(io.Writer) error {
+		return assert.AnError
+	}
+	commands, err := Commands(
+		apps,
+		newCommandTestConfig(),
+		func(ctx context.Context) {},
+		func(ctx context.Context) {},
+		migrateStores,
+	)
+	assert.Nil(t, err)
+
+	err = RunCommand(context.Background(), []string{"migrate"}, commands, io.Discard)
+
+	assert.Equal(t, assert.AnError, err)
+}
+
+func TestMigrateCommand_Error(t *testing.T) {
+	apps := []app.App{&migratingMockApp{
+		dependentMockApp: &dependentMockApp{mockApp: &mockApp{}, name: "user"},
+		migrateErr:       assert.AnError,
+	}}
+
+	_, err := runTestCommand(t, apps, "migrate")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Contains(t, err.Error(), "failed to migrate user")
+}
+
+type routingMockApp struct {
+	*mockApp
+}
+
+func (m *routingMockApp) RegisterHTTPRoutes(engine http.HttpEngine) {
+	engine.POST("/users", func(c *gin.Context) {})
+	engine.GET("/users", func(c *gin.Context) {})
+	engine.GET("/accounts", func(c *gin.Context) {})
+}
+
+func TestRoutesCommand(t *testing.T) {
+	a := &routingMockApp{mockApp: &mockApp{}}
+
+	out, err := runTestCommand(t, []app.App{a}, "routes")
+
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	// The engine's own docs routes are listed alongside the app's.
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "GET   /accounts      "))
+	assert.True(t, strings.HasPrefix(lines[1], "GET   /docs          "))
+	assert.True(t, strings.HasPrefix(lines[2], "GET   /openapi.json  "))
+	assert.True(t, strings.HasPrefix(lines[3], "GET   /users         "))
+	assert.True(t, strings.HasPrefix(lines[4], "POST  /users         "))
+	assert.Equal(t, 1, a.InitialiseCalls)
+	assert.Equal(t, 1, a.ShutdownCalls)
+}
+
+type configurableMockApp struct {
+	*mockApp
+	err error
+}
+
+func (m *configurableMockApp) Name() string { return "user" }
+
+func (m *configurableMockApp) CheckConfig() error { return m.err }
+
+func TestCheckConfigCommand(t *testing.T) {
+	apps := []app.App{&configurableMockApp{mockApp: &mockApp{}}, &mockApp{}}
+
+	out, err := runTestCommand(t, apps, "check-config")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Config OK\n", out)
+	assert.Equal(t, 0, apps[1].(*mockApp).ShutdownCalls)
+}
+
+func TestCheckConfigCommand_ReportsErrors(t *testing.T) {
+	apps := []app.App{&configurableMockApp{mockApp: &mockApp{}, err: assert.AnError}}
+	config := newCommandTestConfig()
+	config.LogLevel = "loud"
+	commands, err := Commands(apps, config, func(ctx context.Context) {}, func(ctx context.Context) {}, noStores)
+	assert.Nil(t, err)
+
+	err = RunCommand(context.Background(), []string{"check-config"}, commands, io.Discard)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.EqualError(t, err, "main: LOG_LEVEL \"loud\" is not a log level\nuser: "+assert.AnError.Error())
+}
@@ -0,0 +1,76 @@
+// Command lines is the developer tool for this repository, it scaffolds new apps.
+//
+//	go run ./cmd/lines new-app billing --model Invoice
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"lines/lines/generate"
+	"os"
+	"strings"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(out)
+		return nil
+	}
+	switch args[0] {
+	case "new-app":
+		return newAppCommand(args[1:], out)
+	}
+	printUsage(out)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: lines <command> [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	fmt.Fprintln(out, "  new-app <name> [-model Model] [-root dir]  generate an app and add it to cmd/apps.go")
+}
+
+// newAppCommand generates an app. Flags can come before or after the app's name.
+func newAppCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("new-app", flag.ContinueOnError)
+	flags.SetOutput(out)
+	model := flags.String("model", "", "the app's first model, defaults to the singular of the app's name")
+	root := flags.String("root", ".", "the repository root")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("new-app needs the app's name")
+	}
+	name := flags.Arg(0)
+	err = flags.Parse(flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+
+	written, err := generate.NewApp(generate.Options{Root: *root, App: name, Model: *model})
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Fprintln(out, "wrote", path)
+	}
+	prefix := strings.ToUpper(name)
+	fmt.Fprintf(out, "Set %s_POSTGRES_URL and %s_POSTGRES_URL_TEST, then run migrate to create its tables.\n", prefix, prefix)
+	return nil
+}
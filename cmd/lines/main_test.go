@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"help"}, {"--help"}} {
+		out := &bytes.Buffer{}
+		assert.Nil(t, run(args, out))
+		assert.Contains(t, out.String(), "new-app <name>")
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.EqualError(t, run([]string{"nope"}, out), `unknown command "nope"`)
+	assert.Contains(t, out.String(), "Usage: lines")
+}
+
+func TestRun_NewApp(t *testing.T) {
+	tests := []struct {
+		name string
+		args func(root string) []string
+	}{
+		{"flags after name", func(root string) []string { return []string{"new-app", "billing", "--model", "Invoice", "-root", root} }},
+		{"flags before name", func(root string) []string { return []string{"new-app", "-root", root, "-model", "Invoice", "billing"} }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			assert.Nil(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module lines\n"), 0o644))
+			apps, err := os.ReadFile(filepath.Join("..", "apps.go"))
+			assert.Nil(t, err)
+			assert.Nil(t, os.Mkdir(filepath.Join(root, "cmd"), 0o755))
+			assert.Nil(t, os.WriteFile(filepath.Join(root, "cmd", "apps.go"), apps, 0o644))
+			out := &bytes.Buffer{}
+
+			err = run(test.args(root), out)
+
+			assert.Nil(t, err)
+			assert.Contains(t, out.String(), "wrote "+filepath.Join("billing", "stores", "models_postgres.go"))
+			assert.Contains(t, out.String(), "Set BILLING_POSTGRES_URL and BILLING_POSTGRES_URL_TEST")
+			_, err = os.Stat(filepath.Join(root, "billing", "domain", "services.go"))
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestRun_NewAppErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"no name", []string{"new-app", "-model", "Invoice"}, "new-app needs the app's name"},
+		{"extra arguments", []string{"new-app", "billing", "shop"}, `unexpected arguments ["shop"]`},
+		{"unknown flag", []string{"new-app", "-force", "billing"}, "flag provided but not defined: -force"},
+		{"invalid name", []string{"new-app", "Billing"}, `app name "Billing" must be lower case letters and digits, starting with a letter`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualError(t, run(test.args, &bytes.Buffer{}), test.err)
+		})
+	}
+}
@@ -1,54 +1,351 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"google.golang.org/grpc"
+	"io"
 	"lines/internal"
 	"lines/lines/app"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
 	"lines/lines/http"
-	"lines/user"
-	"strconv"
+	"lines/lines/jobs"
+	"lines/lines/logging"
+	"lines/lines/schedule"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-// Main is the entry point for the application.
+// Main is the entry point for the application, it runs the subcommand named by the first argument, serve by default.
 func main() {
-	userApp := user.NewUserApp()
-	apps := []app.App{
-		&userApp,
-	}
 	config := internal.NewConfig()
-	httpEngine := http.CreateEngine(config)
+	apps, err := app.EnabledApps(registrations, config.EnabledApps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serve := func(ctx context.Context) {
+		httpEngine := http.CreateEngine(config)
+		httpServer := http.CreateServer(config, httpEngine)
+		grpcServer := linesGrpc.CreateServer(config)
+		grpcServer.UseAuthenticator(linesGrpc.NewServiceTokenAuthenticator(config.GRPCServiceToken))
+		bus := events.NewInMemoryBus(events.NewBusConfig(config.Logger))
+		jobQueue := jobs.NewPostgresQueue(jobs.NewConfig(config.Logger), jobs.NewPostgresJobStore())
+		defer jobQueue.Close()
+		MainHandler(ctx, apps, config, httpEngine, httpServer, grpcServer, bus, jobQueue)
+	}
+	work := func(ctx context.Context) {
+		bus := events.NewInMemoryBus(events.NewBusConfig(config.Logger))
+		jobQueue := jobs.NewPostgresQueue(jobs.NewConfig(config.Logger), jobs.NewPostgresJobStore())
+		defer jobQueue.Close()
+		scheduler := schedule.NewPostgresScheduler(schedule.NewConfig(config.Logger), schedule.NewPostgresScheduleStore())
+		defer scheduler.Close()
+		WorkerHandler(ctx, apps, config, jobQueue, scheduler, bus, http.CreateMetricsServer(config))
+	}
+	commands, err := Commands(apps, config, serve, work, migrateStores)
+	if err == nil {
+		err = RunCommand(ctx, os.Args[1:], commands, os.Stdout)
+	}
+	if err != nil {
+		// Commands are run by people, so errors go to stderr rather than the structured logs.
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
 
-	MainHandler(apps, config, httpEngine)
+// migratingStore is one of the monolith's own stores, which the migrate command migrates alongside the apps'.
+type migratingStore interface {
+	Migrate() error
+	Close() error
+}
+
+// migrateStores migrates the job queue's and scheduler's stores, and the rate limit store if RATE_LIMIT_STORE keeps
+// limits in Postgres, then closes them.
+func migrateStores(out io.Writer) error {
+	rateLimits, err := http.NewRateLimitStore()
+	if err != nil {
+		return err
+	}
+	defer rateLimits.Close()
+	jobStore := jobs.NewPostgresJobStore()
+	defer jobStore.Close()
+	scheduleStore := schedule.NewPostgresScheduleStore()
+	defer scheduleStore.Close()
+
+	names := []string{"jobs", "schedule"}
+	stores := []migratingStore{jobStore, scheduleStore}
+	if postgresRateLimits, ok := rateLimits.(migratingStore); ok {
+		names = append(names, "rate limits")
+		stores = append(stores, postgresRateLimits)
+	}
+	for i, s := range stores {
+		err = s.Migrate()
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", names[i], err)
+		}
+		fmt.Fprintf(out, "Migrated %s\n", names[i])
+	}
+	return nil
 }
 
 // MainHandler is the main handler for the application.
+// It runs until a server fails or ctx is cancelled, then drains the servers and shuts the apps down.
 func MainHandler(
+	ctx context.Context,
 	apps []app.App,
 	config *internal.MainConfig,
 	httpEngine http.HttpEngine,
+	httpServer http.HttpServer,
+	grpcServer linesGrpc.GrpcServer,
+	bus events.Bus,
+	jobRegistry jobs.Registry,
+) {
+	// First, we initialise each of our apps in dependency order. Each app then initialises its own dependencies.
+	initialised, err := initialiseApps(apps)
+	if err != nil {
+		err = fmt.Errorf("failed to initialise apps: %w", err)
+	} else {
+		err = registerBackgroundHandlers(initialised, bus, jobRegistry)
+	}
+	for i := 0; err == nil && i < len(initialised); i++ {
+		initialised[i].RegisterHTTPRoutes(httpEngine)
+		err = initialised[i].RegisterGRPCServices(grpcServer)
+		if err != nil {
+			err = fmt.Errorf("failed to register gRPC services for app %s: %w", app.Name(initialised[i]), err)
+		}
+	}
+	if err != nil {
+		failStartup(config, "main", initialised, err)
+		return
+	}
+
+	// Start the servers, and wait for either of them to stop or for a shutdown signal.
+	serverErrors := make(chan error, 2)
+	go func() {
+		serverErrors <- httpServer.ListenAndServe()
+	}()
+	go func() {
+		serverErrors <- grpcServer.ListenAndServe()
+	}()
+	running := 2
+	// serveErr is a server's failure, the process exits non-zero once everything is shut down.
+	var serveErr error
+	select {
+	case err := <-serverErrors:
+		running--
+		if err != nil && !errors.Is(err, nethttp.ErrServerClosed) && !errors.Is(err, grpc.ErrServerStopped) {
+			serveErr = err
+		}
+	case <-ctx.Done():
+		config.Logger.Info("main", "main", "Shutdown signal received, draining the servers.")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(config.ShutdownTimeoutSeconds)*time.Second,
+	)
+	defer cancel()
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "main", fmt.Sprintf("Failed to drain server: %s", err.Error()))
+	}
+	err = grpcServer.Shutdown(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "main", fmt.Sprintf("Failed to drain gRPC server: %s", err.Error()))
+	}
+	for ; running > 0; running-- {
+		<-serverErrors
+	}
+	// Let queued events finish while the apps' stores are still open.
+	err = bus.Close(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "main", fmt.Sprintf("Failed to drain event bus: %s", err.Error()))
+	}
+	shutdownApps(shutdownCtx, initialised, config.Logger)
+	// Send the reports of anything that went wrong while draining.
+	if config.ErrorReporter != nil {
+		err = config.ErrorReporter.Close(shutdownCtx)
+		if err != nil {
+			config.Logger.Error("main", "main", fmt.Sprintf("Failed to send error reports: %s", err.Error()))
+		}
+	}
+	if serveErr != nil {
+		config.Logger.Fatal("main", "main", fmt.Sprintf("Failed to start server: %s", serveErr.Error()))
+	}
+}
+
+// WorkerHandler runs the background job worker and the scheduled tasks, serving their metrics, until ctx is
+// cancelled, then stops them and shuts the apps down.
+func WorkerHandler(
+	ctx context.Context,
+	apps []app.App,
+	config *internal.MainConfig,
+	worker jobs.Worker,
+	scheduler schedule.Scheduler,
+	bus events.Bus,
+	metricsServer http.HttpServer,
 ) {
-	// TODO: Here we're going to initialise sentry, datadog and other app based stuff.
+	initialised, err := initialiseApps(apps)
+	if err != nil {
+		err = fmt.Errorf("failed to initialise apps: %w", err)
+	} else {
+		err = registerBackgroundHandlers(initialised, bus, worker)
+	}
+	for i := 0; err == nil && i < len(initialised); i++ {
+		err = initialised[i].RegisterSchedules(scheduler)
+		if err != nil {
+			err = fmt.Errorf("failed to register scheduled tasks for app %s: %w", app.Name(initialised[i]), err)
+		}
+	}
+	if err != nil {
+		failStartup(config, "WorkerHandler", initialised, err)
+		return
+	}
+
+	worker.Start()
+	scheduler.Start()
+	metricsDone := make(chan struct{})
+	go func() {
+		defer close(metricsDone)
+		// The worker carries on without its metrics rather than stopping.
+		err := metricsServer.ListenAndServe()
+		if err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+			config.Logger.Error("main", "WorkerHandler", fmt.Sprintf("Failed to serve metrics: %s", err.Error()))
+		}
+	}()
+	<-ctx.Done()
+	config.Logger.Info("main", "WorkerHandler", "Shutdown signal received, stopping the worker.")
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(config.ShutdownTimeoutSeconds)*time.Second,
+	)
+	defer cancel()
+	// Scheduled tasks may enqueue jobs, so they stop first.
+	err = scheduler.Stop(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "WorkerHandler", fmt.Sprintf("Failed to stop scheduler: %s", err.Error()))
+	}
+	err = worker.Stop(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "WorkerHandler", fmt.Sprintf("Failed to stop worker: %s", err.Error()))
+	}
+	err = bus.Close(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "WorkerHandler", fmt.Sprintf("Failed to drain event bus: %s", err.Error()))
+	}
+	err = metricsServer.Shutdown(shutdownCtx)
+	if err != nil {
+		config.Logger.Error("main", "WorkerHandler", fmt.Sprintf("Failed to stop metrics server: %s", err.Error()))
+	}
+	<-metricsDone
+	shutdownApps(shutdownCtx, initialised, config.Logger)
+}
 
-	// Next, we initialise each of our apps. Each app then initialises its own dependencies.
+// registerBackgroundHandlers registers the apps' event and job handlers.
+func registerBackgroundHandlers(apps []app.App, bus events.Bus, jobRegistry jobs.Registry) error {
 	for _, a := range apps {
-		err := a.Initialise()
+		err := a.RegisterEventHandlers(bus)
 		if err != nil {
-			config.Logger.Fatal(
-				"main",
-				"main",
-				fmt.Sprintf("Failed to initialise app: %s", err.Error()),
-			)
+			return fmt.Errorf("failed to register event handlers for app %s: %w", app.Name(a), err)
+		}
+		err = a.RegisterJobs(jobRegistry)
+		if err != nil {
+			return fmt.Errorf("failed to register jobs for app %s: %w", app.Name(a), err)
 		}
-		a.RegisterHTTPRoutes(httpEngine)
 	}
+	return nil
+}
 
-	// Start the server.
-	err := httpEngine.Run(":" + strconv.Itoa(config.HTTPPort))
+// failStartup shuts down the apps that were initialised before startup failed, in reverse order, then logs the
+// error and exits non-zero.
+func failStartup(config *internal.MainConfig, caller string, initialised []app.App, err error) {
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(config.ShutdownTimeoutSeconds)*time.Second,
+	)
+	shutdownApps(shutdownCtx, initialised, config.Logger)
+	cancel()
+	config.Logger.Fatal("main", caller, fmt.Sprintf("Failed to start: %s", err.Error()))
+}
+
+// initialiseApps initialises the apps in dependency order, sharing a use case registry between them.
+// It returns the apps that were initialised, in order, even if a later app failed, so they can be shut down.
+func initialiseApps(apps []app.App) ([]app.App, error) {
+	layers, err := app.InitialisationOrder(apps)
 	if err != nil {
-		config.Logger.Fatal(
-			"main",
-			"main",
-			fmt.Sprintf("Failed to start server: %s", err.Error()),
-		)
+		return nil, err
+	}
+	useCases := app.NewUseCaseRegistry()
+	var initialised []app.App
+	for _, layer := range layers {
+		started, err := initialiseLayer(layer, useCases)
+		initialised = append(initialised, started...)
+		if err != nil {
+			return initialised, err
+		}
+	}
+	return initialised, nil
+}
+
+// initialiseLayer initialises the apps in a layer concurrently, none of them depend on each other. It returns the
+// apps that were initialised, in the layer's order, along with the errors of the ones that failed.
+func initialiseLayer(layer []app.App, useCases *app.UseCaseRegistry) ([]app.App, error) {
+	errs := make([]error, len(layer))
+	var wg sync.WaitGroup
+	for i, a := range layer {
+		wg.Add(1)
+		go func(i int, a app.App) {
+			defer wg.Done()
+			err := a.Initialise(useCases)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", app.Name(a), err)
+			}
+		}(i, a)
+	}
+	wg.Wait()
+	var initialised []app.App
+	for i, a := range layer {
+		if errs[i] == nil {
+			initialised = append(initialised, a)
+		}
+	}
+	return initialised, errors.Join(errs...)
+}
+
+// shutdownApps shuts the apps down in reverse initialisation order, giving up once ctx expires.
+func shutdownApps(ctx context.Context, apps []app.App, logger logging.Logger) {
+	for i := len(apps) - 1; i >= 0; i-- {
+		done := make(chan error, 1)
+		go func(a app.App) {
+			done <- a.Shutdown(ctx)
+		}(apps[i])
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error(
+					"main",
+					"shutdownApps",
+					fmt.Sprintf("Failed to shut down app %s: %s", app.Name(apps[i]), err.Error()),
+				)
+			}
+		case <-ctx.Done():
+			logger.Error(
+				"main",
+				"shutdownApps",
+				fmt.Sprintf("Shutdown deadline exceeded, %d app(s) were not shut down.", i+1),
+			)
+			return
+		}
 	}
 }
@@ -1,23 +1,39 @@
 package main
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 	"lines/internal"
 	"lines/lines/app"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
 	"lines/lines/http"
+	"lines/lines/jobs"
 	"lines/lines/logging"
+	"lines/lines/schedule"
+	nethttp "net/http"
+	"sync"
 	"testing"
+	"time"
 )
 
 type mockApp struct {
 	InitialiseCalls           int
-	InitialiseArgs            []*internal.MainConfig
+	InitialiseArgs            []*app.UseCaseRegistry
 	RegisterHttpRoutesCalls   int
 	RegisterGRPCServicesCalls int
+	RegisterEventHandlersArgs []events.Bus
+	RegisterJobsArgs          []jobs.Registry
+	RegisterSchedulesArgs     []schedule.Registry
+	ShutdownCalls             int
+	shutdownOrder             *[]*mockApp
+	commands                  []app.Command
 }
 
-func (m *mockApp) Initialise() error {
+func (m *mockApp) Initialise(useCases *app.UseCaseRegistry) error {
 	m.InitialiseCalls++
+	m.InitialiseArgs = append(m.InitialiseArgs, useCases)
 	return nil
 }
 
@@ -25,11 +41,82 @@ func (m *mockApp) RegisterHTTPRoutes(engine http.HttpEngine) {
 	m.RegisterHttpRoutesCalls++
 }
 
-func (m *mockApp) RegisterGRPCServices() error {
+func (m *mockApp) RegisterGRPCServices(server linesGrpc.GrpcServer) error {
 	m.RegisterGRPCServicesCalls++
 	return nil
 }
 
+func (m *mockApp) RegisterEventHandlers(bus events.Bus) error {
+	m.RegisterEventHandlersArgs = append(m.RegisterEventHandlersArgs, bus)
+	return nil
+}
+
+func (m *mockApp) RegisterJobs(registry jobs.Registry) error {
+	m.RegisterJobsArgs = append(m.RegisterJobsArgs, registry)
+	return nil
+}
+
+func (m *mockApp) RegisterSchedules(registry schedule.Registry) error {
+	m.RegisterSchedulesArgs = append(m.RegisterSchedulesArgs, registry)
+	return nil
+}
+
+func (m *mockApp) Commands() []app.Command {
+	return m.commands
+}
+
+func (m *mockApp) Shutdown(ctx context.Context) error {
+	m.ShutdownCalls++
+	if m.shutdownOrder != nil {
+		*m.shutdownOrder = append(*m.shutdownOrder, m)
+	}
+	return nil
+}
+
+type mockBus struct {
+	events.Bus
+	CloseCalls int
+}
+
+func (m *mockBus) Close(ctx context.Context) error {
+	m.CloseCalls++
+	return nil
+}
+
+type mockJobRegistry struct {
+	jobs.Registry
+}
+
+type mockWorker struct {
+	jobs.Worker
+	StartCalls int
+	StopCalls  int
+}
+
+func (m *mockWorker) Start() {
+	m.StartCalls++
+}
+
+func (m *mockWorker) Stop(ctx context.Context) error {
+	m.StopCalls++
+	return nil
+}
+
+type mockScheduler struct {
+	schedule.Scheduler
+	StartCalls int
+	StopCalls  int
+}
+
+func (m *mockScheduler) Start() {
+	m.StartCalls++
+}
+
+func (m *mockScheduler) Stop(ctx context.Context) error {
+	m.StopCalls++
+	return nil
+}
+
 type mockHttpEngine struct {
 	RunCalls int
 	http.HttpEngine
@@ -40,61 +127,187 @@ func (m *mockHttpEngine) Run(addr ...string) error {
 	return nil
 }
 
+type mockHttpServer struct {
+	ListenAndServeCalls int
+	ShutdownCalls       int
+	ListenAndServeErr   error
+	// block makes ListenAndServe run until Shutdown is called.
+	block chan struct{}
+}
+
+func (m *mockHttpServer) ListenAndServe() error {
+	m.ListenAndServeCalls++
+	if m.block != nil {
+		<-m.block
+	}
+	return m.ListenAndServeErr
+}
+
+func (m *mockHttpServer) Shutdown(ctx context.Context) error {
+	m.ShutdownCalls++
+	if m.block != nil {
+		close(m.block)
+	}
+	return nil
+}
+
+type mockGrpcServer struct {
+	linesGrpc.GrpcServer
+	ListenAndServeCalls int
+	ShutdownCalls       int
+	ListenAndServeErr   error
+	// block makes ListenAndServe run until Shutdown is called.
+	block chan struct{}
+}
+
+func (m *mockGrpcServer) ListenAndServe() error {
+	m.ListenAndServeCalls++
+	if m.block != nil {
+		<-m.block
+	}
+	return m.ListenAndServeErr
+}
+
+func (m *mockGrpcServer) Shutdown(ctx context.Context) error {
+	m.ShutdownCalls++
+	if m.block != nil {
+		close(m.block)
+	}
+	return nil
+}
+
+func newTestConfig() *internal.MainConfig {
+	return &internal.MainConfig{
+		Logger:                 &MockLogger{},
+		ShutdownTimeoutSeconds: 1,
+	}
+}
+
 func TestMainHandler_InitialisesApps(t *testing.T) {
 	apps := []app.App{
 		&mockApp{},
 		&mockApp{},
 		&mockApp{},
 	}
-	config := &internal.MainConfig{}
+	config := newTestConfig()
 	httpEngine := &mockHttpEngine{}
 
-	MainHandler(apps, config, httpEngine)
+	MainHandler(context.Background(), apps, config, httpEngine, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
 
+	useCases := apps[0].(*mockApp).InitialiseArgs[0]
+	assert.NotNil(t, useCases)
 	for _, a := range apps {
 		mockApp := a.(*mockApp)
 		assert.Equal(t, 1, mockApp.InitialiseCalls)
+		assert.Same(t, useCases, mockApp.InitialiseArgs[0])
 		assert.Equal(t, 1, mockApp.RegisterHttpRoutesCalls)
-		assert.Equal(t, 0, mockApp.RegisterGRPCServicesCalls)
+		assert.Equal(t, 1, mockApp.RegisterGRPCServicesCalls)
 	}
 }
 
-func TestMainHandler_StartsHttpEngine(t *testing.T) {
+func TestMainHandler_StartsHttpServer(t *testing.T) {
 	apps := []app.App{
 		&mockApp{},
 	}
-	config := &internal.MainConfig{}
-	httpEngine := &mockHttpEngine{}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, httpServer.ListenAndServeCalls)
+}
+
+func TestMainHandler_StartsGrpcServer(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	config := newTestConfig()
+	grpcServer := &mockGrpcServer{}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, grpcServer, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, grpcServer.ListenAndServeCalls)
+	assert.Equal(t, 1, grpcServer.ShutdownCalls)
+}
+
+func TestMainHandler_GrpcServeError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{block: make(chan struct{})}
+	grpcServer := &mockGrpcServer{ListenAndServeErr: assert.AnError}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, grpcServer, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestMainHandler_GrpcServerStopped_DoesNotLogError(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{block: make(chan struct{})}
+	grpcServer := &mockGrpcServer{ListenAndServeErr: grpc.ErrServerStopped}
 
-	MainHandler(apps, config, httpEngine)
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, grpcServer, &mockBus{}, &mockJobRegistry{})
 
-	assert.Equal(t, 1, httpEngine.RunCalls)
+	assert.Equal(t, 0, config.Logger.(*MockLogger).FatalCalls)
+}
+
+type mockAppWithGRPCServicesError struct {
+	*mockApp
+}
+
+func (m *mockAppWithGRPCServicesError) RegisterGRPCServices(server linesGrpc.GrpcServer) error {
+	return assert.AnError
+}
+
+func TestMainHandler_RegisterGRPCServicesError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockAppWithGRPCServicesError{mockApp: &mockApp{}},
+	}
+	config := newTestConfig()
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
 }
 
 type mockAppWithInitialiseError struct {
 	*mockApp
 }
 
-func (m *mockAppWithInitialiseError) Initialise() error {
+func (m *mockAppWithInitialiseError) Initialise(useCases *app.UseCaseRegistry) error {
 	return assert.AnError
 }
 
 type MockLogger struct {
 	*logging.LogrusHandler
 	FatalCalls int
+	ErrorCalls int
+	InfoCalls  int
 }
 
 func (m *MockLogger) Fatal(appName string, caller string, message string) {
 	m.FatalCalls++
 }
 
+func (m *MockLogger) Error(appName string, caller string, message string) {
+	m.ErrorCalls++
+}
+
+func (m *MockLogger) Info(appName string, caller string, message string) {
+	m.InfoCalls++
+}
+
 func TestMainHandler_InitialiseError_LogsError(t *testing.T) {
 
 	apps := []app.App{
 		&mockAppWithInitialiseError{},
 	}
-	config := &internal.MainConfig{}
-	config.Logger = &MockLogger{}
+	config := newTestConfig()
 	httpEngine := &mockHttpEngine{}
 	defer func() {
 		if r := recover(); r != nil {
@@ -103,34 +316,436 @@ func TestMainHandler_InitialiseError_LogsError(t *testing.T) {
 		}
 	}()
 
-	MainHandler(apps, config, httpEngine)
+	MainHandler(context.Background(), apps, config, httpEngine, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestMainHandler_ListenAndServeError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{ListenAndServeErr: assert.AnError}
+	grpcServer := &mockGrpcServer{block: make(chan struct{})}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, grpcServer, &mockBus{}, &mockJobRegistry{})
 
 	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
 }
 
-type mockHttpEngineWithRunError struct {
-	*mockHttpEngine
+func TestMainHandler_ServerClosed_DoesNotLogError(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{ListenAndServeErr: nethttp.ErrServerClosed}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 0, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestMainHandler_Signal_DrainsServerAndShutsDownApps(t *testing.T) {
+	var order []*mockApp
+	first := &mockApp{shutdownOrder: &order}
+	second := &mockApp{shutdownOrder: &order}
+	apps := []app.App{first, second}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{block: make(chan struct{})}
+	grpcServer := &mockGrpcServer{block: make(chan struct{})}
+	bus := &mockBus{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	MainHandler(ctx, apps, config, &mockHttpEngine{}, httpServer, grpcServer, bus, &mockJobRegistry{})
+
+	assert.Equal(t, 1, httpServer.ShutdownCalls)
+	assert.Equal(t, 1, grpcServer.ShutdownCalls)
+	assert.Equal(t, 1, bus.CloseCalls)
+	assert.Equal(t, []*mockApp{second, first}, order)
+	assert.Equal(t, 1, config.Logger.(*MockLogger).InfoCalls)
 }
 
-func (m *mockHttpEngineWithRunError) Run(addr ...string) error {
+type mockAppWithShutdownError struct {
+	*mockApp
+}
+
+func (m *mockAppWithShutdownError) Shutdown(ctx context.Context) error {
 	return assert.AnError
 }
 
-func TestMainHandler_RunError_LogsError(t *testing.T) {
+func TestShutdownApps_LogsErrors(t *testing.T) {
+	logger := &MockLogger{}
 	apps := []app.App{
+		&mockAppWithShutdownError{mockApp: &mockApp{}},
 		&mockApp{},
 	}
-	config := &internal.MainConfig{}
-	config.Logger = &MockLogger{}
-	httpEngine := &mockHttpEngineWithRunError{}
-	defer func() {
-		if r := recover(); r != nil {
-			assert.True(t, true, "MainHandler did panic as expected")
-			assert.Equal(t, config.Logger.(*MockLogger).FatalCalls, 1, "Logger.Fatal was called")
+
+	shutdownApps(context.Background(), apps, logger)
+
+	assert.Equal(t, 1, logger.ErrorCalls)
+	assert.Equal(t, 1, apps[1].(*mockApp).ShutdownCalls)
+}
+
+type mockAppWithSlowShutdown struct {
+	*mockApp
+}
+
+func (m *mockAppWithSlowShutdown) Shutdown(ctx context.Context) error {
+	time.Sleep(time.Second)
+	return nil
+}
+
+func TestShutdownApps_DeadlineExceeded(t *testing.T) {
+	logger := &MockLogger{}
+	first := &mockApp{}
+	apps := []app.App{
+		first,
+		&mockAppWithSlowShutdown{mockApp: &mockApp{}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	shutdownApps(ctx, apps, logger)
+
+	assert.Equal(t, 1, logger.ErrorCalls)
+	assert.Equal(t, 0, first.ShutdownCalls)
+}
+
+type dependentMockApp struct {
+	*mockApp
+	name              string
+	dependencies      []string
+	initialiseOrder   *[]string
+	initialiseOrderMu *sync.Mutex
+}
+
+func (m *dependentMockApp) Name() string {
+	return m.name
+}
+
+func (m *dependentMockApp) Dependencies() []string {
+	return m.dependencies
+}
+
+func (m *dependentMockApp) Initialise(useCases *app.UseCaseRegistry) error {
+	m.initialiseOrderMu.Lock()
+	defer m.initialiseOrderMu.Unlock()
+	*m.initialiseOrder = append(*m.initialiseOrder, m.name)
+	return m.mockApp.Initialise(useCases)
+}
+
+func TestMainHandler_InitialisesAppsInDependencyOrder(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	newApp := func(name string, dependencies ...string) *dependentMockApp {
+		return &dependentMockApp{
+			mockApp:           &mockApp{},
+			name:              name,
+			dependencies:      dependencies,
+			initialiseOrder:   &order,
+			initialiseOrderMu: &mu,
 		}
-	}()
+	}
+	apps := []app.App{
+		newApp("billing", "user"),
+		newApp("user"),
+	}
+
+	MainHandler(context.Background(), apps, newTestConfig(), &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, []string{"user", "billing"}, order)
+}
+
+type greeter interface {
+	Greet() string
+}
+
+type greeterFunc func() string
+
+func (g greeterFunc) Greet() string { return g() }
+
+var greetV1 = app.UseCase[greeter]{App: "greeting", Name: "Greet", Version: 1}
+
+type providerMockApp struct {
+	*mockApp
+}
+
+func (m *providerMockApp) Name() string { return "greeting" }
+
+func (m *providerMockApp) Initialise(useCases *app.UseCaseRegistry) error {
+	return app.Provide[greeter](useCases, greetV1, greeterFunc(func() string { return "hello" }))
+}
+
+type consumerMockApp struct {
+	*mockApp
+	greeting string
+}
+
+func (m *consumerMockApp) Name() string { return "consumer" }
+
+func (m *consumerMockApp) Dependencies() []string { return []string{"greeting"} }
+
+func (m *consumerMockApp) Initialise(useCases *app.UseCaseRegistry) error {
+	g, err := app.Resolve(useCases, greetV1)
+	if err != nil {
+		return err
+	}
+	m.greeting = g.Greet()
+	return nil
+}
+
+func TestMainHandler_SharesUseCasesBetweenApps(t *testing.T) {
+	consumer := &consumerMockApp{mockApp: &mockApp{}}
+	apps := []app.App{
+		consumer,
+		&providerMockApp{mockApp: &mockApp{}},
+	}
+	config := newTestConfig()
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 0, config.Logger.(*MockLogger).FatalCalls)
+	assert.Equal(t, "hello", consumer.greeting)
+}
+
+func TestMainHandler_DependencyCycle_LogsError(t *testing.T) {
+	apps := []app.App{
+		&dependentMockApp{mockApp: &mockApp{}, name: "user", dependencies: []string{"billing"}},
+		&dependentMockApp{mockApp: &mockApp{}, name: "billing", dependencies: []string{"user"}},
+	}
+	config := newTestConfig()
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+	assert.Equal(t, 0, apps[0].(*dependentMockApp).InitialiseCalls)
+}
+
+type blockingMockApp struct {
+	*mockApp
+	started chan struct{}
+	other   chan struct{}
+}
+
+func (m *blockingMockApp) Initialise(useCases *app.UseCaseRegistry) error {
+	close(m.started)
+	select {
+	case <-m.other:
+		return nil
+	case <-time.After(time.Second):
+		return assert.AnError
+	}
+}
+
+func TestInitialiseLayer_InitialisesConcurrently(t *testing.T) {
+	first := make(chan struct{})
+	second := make(chan struct{})
+	layer := []app.App{
+		&blockingMockApp{mockApp: &mockApp{}, started: first, other: second},
+		&blockingMockApp{mockApp: &mockApp{}, started: second, other: first},
+	}
+
+	initialised, err := initialiseLayer(layer, app.NewUseCaseRegistry())
+
+	assert.Nil(t, err)
+	assert.Equal(t, layer, initialised)
+}
+
+func TestInitialiseLayer_ReturnsErrors(t *testing.T) {
+	layer := []app.App{
+		&mockApp{},
+		&mockAppWithInitialiseError{mockApp: &mockApp{}},
+	}
+
+	initialised, err := initialiseLayer(layer, app.NewUseCaseRegistry())
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Contains(t, err.Error(), "*main.mockAppWithInitialiseError")
+	assert.Equal(t, layer[:1], initialised)
+}
+
+func TestMainHandler_InitialiseError_ShutsDownInitialisedApps(t *testing.T) {
+	var order []*mockApp
+	var initialiseOrder []string
+	var mu sync.Mutex
+	newApp := func(name string, dependencies ...string) *dependentMockApp {
+		return &dependentMockApp{
+			mockApp:           &mockApp{shutdownOrder: &order},
+			name:              name,
+			dependencies:      dependencies,
+			initialiseOrder:   &initialiseOrder,
+			initialiseOrderMu: &mu,
+		}
+	}
+	user := newApp("user")
+	audit := newApp("audit", "user")
+	billing := &mockAppWithInitialiseError{mockApp: &mockApp{shutdownOrder: &order}}
+	apps := []app.App{user, audit, &dependentFailingMockApp{mockAppWithInitialiseError: billing}}
+	config := newTestConfig()
+	httpServer := &mockHttpServer{}
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, httpServer, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+	assert.Equal(t, []*mockApp{audit.mockApp, user.mockApp}, order)
+	assert.Equal(t, 0, httpServer.ListenAndServeCalls)
+}
+
+// dependentFailingMockApp fails to initialise after the user and audit apps have been.
+type dependentFailingMockApp struct {
+	*mockAppWithInitialiseError
+}
+
+func (m *dependentFailingMockApp) Name() string { return "billing" }
+
+func (m *dependentFailingMockApp) Dependencies() []string { return []string{"audit"} }
+
+func TestMainHandler_ServeError_ShutsDownBeforeExiting(t *testing.T) {
+	first := &mockApp{}
+	config := newTestConfig()
+	bus := &mockBus{}
+	httpServer := &mockHttpServer{ListenAndServeErr: assert.AnError}
+	grpcServer := &mockGrpcServer{block: make(chan struct{})}
+
+	MainHandler(context.Background(), []app.App{first}, config, &mockHttpEngine{}, httpServer, grpcServer, bus, &mockJobRegistry{})
+
+	assert.Equal(t, 1, grpcServer.ShutdownCalls)
+	assert.Equal(t, 1, bus.CloseCalls)
+	assert.Equal(t, 1, first.ShutdownCalls)
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestMainHandler_RegistersEventHandlers(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+		&mockApp{},
+	}
+	bus := &mockBus{}
+
+	MainHandler(context.Background(), apps, newTestConfig(), &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, bus, &mockJobRegistry{})
+
+	for _, a := range apps {
+		assert.Equal(t, []events.Bus{bus}, a.(*mockApp).RegisterEventHandlersArgs)
+	}
+}
+
+type mockAppWithEventHandlersError struct {
+	*mockApp
+}
+
+func (m *mockAppWithEventHandlersError) RegisterEventHandlers(bus events.Bus) error {
+	return assert.AnError
+}
+
+func TestMainHandler_RegisterEventHandlersError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockAppWithEventHandlersError{mockApp: &mockApp{}},
+	}
+	config := newTestConfig()
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestMainHandler_RegistersJobs(t *testing.T) {
+	apps := []app.App{
+		&mockApp{},
+	}
+	registry := &mockJobRegistry{}
+
+	MainHandler(context.Background(), apps, newTestConfig(), &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, registry)
+
+	assert.Equal(t, []jobs.Registry{registry}, apps[0].(*mockApp).RegisterJobsArgs)
+}
+
+type mockAppWithJobsError struct {
+	*mockApp
+}
+
+func (m *mockAppWithJobsError) RegisterJobs(registry jobs.Registry) error {
+	return assert.AnError
+}
+
+func TestMainHandler_RegisterJobsError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockAppWithJobsError{mockApp: &mockApp{}},
+	}
+	config := newTestConfig()
+
+	MainHandler(context.Background(), apps, config, &mockHttpEngine{}, &mockHttpServer{}, &mockGrpcServer{}, &mockBus{}, &mockJobRegistry{})
+
+	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+}
+
+func TestWorkerHandler_RunsWorkerUntilSignal(t *testing.T) {
+	var order []*mockApp
+	first := &mockApp{shutdownOrder: &order}
+	second := &mockApp{shutdownOrder: &order}
+	apps := []app.App{first, second}
+	config := newTestConfig()
+	worker := &mockWorker{}
+	scheduler := &mockScheduler{}
+	bus := &mockBus{}
+	metricsServer := &mockHttpServer{block: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	WorkerHandler(ctx, apps, config, worker, scheduler, bus, metricsServer)
+
+	assert.Equal(t, 1, first.InitialiseCalls)
+	assert.Equal(t, []events.Bus{bus}, first.RegisterEventHandlersArgs)
+	assert.Equal(t, []jobs.Registry{worker}, first.RegisterJobsArgs)
+	assert.Equal(t, 0, first.RegisterHttpRoutesCalls)
+	assert.Equal(t, 1, worker.StartCalls)
+	assert.Equal(t, 1, worker.StopCalls)
+	assert.Equal(t, []schedule.Registry{scheduler}, first.RegisterSchedulesArgs)
+	assert.Equal(t, 1, scheduler.StartCalls)
+	assert.Equal(t, 1, scheduler.StopCalls)
+	assert.Equal(t, 1, bus.CloseCalls)
+	assert.Equal(t, 1, metricsServer.ListenAndServeCalls)
+	assert.Equal(t, 1, metricsServer.ShutdownCalls)
+	assert.Equal(t, []*mockApp{second, first}, order)
+}
+
+func TestWorkerHandler_MetricsServerError_KeepsWorking(t *testing.T) {
+	config := newTestConfig()
+	worker := &mockWorker{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	WorkerHandler(ctx, []app.App{&mockApp{}}, config, worker, &mockScheduler{}, &mockBus{}, &mockHttpServer{
+		ListenAndServeErr: assert.AnError,
+	})
+
+	assert.Equal(t, 1, worker.StopCalls)
+	assert.Equal(t, 1, config.Logger.(*MockLogger).ErrorCalls)
+	assert.Equal(t, 0, config.Logger.(*MockLogger).FatalCalls)
+}
+
+type mockAppWithSchedulesError struct {
+	*mockApp
+}
+
+func (m *mockAppWithSchedulesError) RegisterSchedules(registry schedule.Registry) error {
+	return assert.AnError
+}
+
+func TestWorkerHandler_RegisterSchedulesError_LogsError(t *testing.T) {
+	apps := []app.App{
+		&mockAppWithSchedulesError{mockApp: &mockApp{}},
+	}
+	config := newTestConfig()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	worker := &mockWorker{}
 
-	MainHandler(apps, config, httpEngine)
+	WorkerHandler(ctx, apps, config, worker, &mockScheduler{}, &mockBus{}, &mockHttpServer{})
 
 	assert.Equal(t, 1, config.Logger.(*MockLogger).FatalCalls)
+	assert.Equal(t, 1, apps[0].(*mockAppWithSchedulesError).ShutdownCalls)
+	assert.Equal(t, 0, worker.StartCalls)
 }
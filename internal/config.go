@@ -1,8 +1,14 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
 	"lines/lines/logging"
+	"lines/lines/reporting"
 	"lines/lines/utils"
+	"net"
+	"strings"
 )
 
 // MainConfig is the main configuration struct for the whole monolith.
@@ -16,6 +22,23 @@ type MainConfig struct {
 	CORSOrigins []string
 	SentryDSN   string
 	HTTPPort    int
+	GRPCPort    int
+	// GRPCServiceToken is the token deployments share to call each other's gRPC services, without one only public
+	// methods can be called.
+	GRPCServiceToken string
+	// MetricsPort is the port the worker serves its metrics on.
+	MetricsPort int
+	// ErrorReporter reports errors to the SENTRY_DSN project, it drops them if there isn't one.
+	ErrorReporter reporting.Reporter
+	// TrustedProxies are the IPs and CIDRs of the proxies whose X-Forwarded-For headers are believed, requests from
+	// anywhere else are taken to come from their peer.
+	TrustedProxies []string
+	// CookieSameSite is the SameSite attribute of the auth and CSRF cookies: "lax", "strict" or "none".
+	CookieSameSite string
+	// ShutdownTimeoutSeconds is how long in-flight requests and apps get to finish once a shutdown signal arrives.
+	ShutdownTimeoutSeconds int
+	// EnabledApps names the apps this process runs, "*" runs all of them.
+	EnabledApps []string
 }
 
 // NewConfig creates a new MainConfig struct, reading from environment variables.
@@ -24,15 +47,76 @@ func NewConfig() *MainConfig {
 	testRunner := utils.GetEnvOrDefault("TEST_RUNNER", "false", "bool").(bool)
 	useSSL := utils.GetEnvOrDefault("USE_SSL", "false", "bool").(bool)
 	config := &MainConfig{
-		LocalDev:    localDev,
-		TestRunner:  testRunner,
-		UseSSL:      useSSL && !localDev,
-		SiteDomain:  utils.GetEnvOrDefault("SITE_DOMAIN", "localhost", "string").(string),
-		LogLevel:    utils.GetEnvOrDefault("LOG_LEVEL", "info", "string").(string),
-		CORSOrigins: utils.GetEnvOrDefault("CORS_ORIGINS", "http://localhost", "[]string").([]string),
-		SentryDSN:   utils.GetEnvOrDefault("SENTRY_DSN", "", "string").(string),
-		HTTPPort:    utils.GetEnvOrDefault("HTTP_PORT", "8080", "int").(int),
+		LocalDev:               localDev,
+		TestRunner:             testRunner,
+		UseSSL:                 useSSL && !localDev,
+		SiteDomain:             utils.GetEnvOrDefault("SITE_DOMAIN", "localhost", "string").(string),
+		LogLevel:               utils.GetEnvOrDefault("LOG_LEVEL", "info", "string").(string),
+		CORSOrigins:            utils.GetEnvOrDefault("CORS_ORIGINS", "http://localhost", "[]string").([]string),
+		TrustedProxies:         utils.GetEnvOrDefault("TRUSTED_PROXIES", "", "[]string").([]string),
+		CookieSameSite:         strings.ToLower(utils.GetEnvOrDefault("COOKIE_SAME_SITE", "lax", "string").(string)),
+		SentryDSN:              utils.GetEnvOrDefault("SENTRY_DSN", "", "string").(string),
+		HTTPPort:               utils.GetEnvOrDefault("HTTP_PORT", "8080", "int").(int),
+		GRPCPort:               utils.GetEnvOrDefault("GRPC_PORT", "9090", "int").(int),
+		GRPCServiceToken:       utils.GetEnvOrDefault("GRPC_SERVICE_TOKEN", "", "string").(string),
+		MetricsPort:            utils.GetEnvOrDefault("METRICS_PORT", "9100", "int").(int),
+		ShutdownTimeoutSeconds: utils.GetEnvOrDefault("SHUTDOWN_TIMEOUT_SECONDS", "30", "int").(int),
+		EnabledApps:            utils.GetEnvOrDefault("ENABLED_APPS", "*", "[]string").([]string),
 	}
 	config.Logger = logging.NewLogrusHandler(config.LogLevel)
+	reporter, err := reporting.NewReporter(config.SentryDSN, config.Logger)
+	if err != nil {
+		// Validate reports the DSN as invalid.
+		reporter = reporting.NoopReporter{}
+	}
+	config.ErrorReporter = reporter
 	return config
 }
+
+// Validate returns an error describing everything wrong with the config, it's nil if the config is usable.
+func (c *MainConfig) Validate() error {
+	var errs []error
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL %q is not a log level", c.LogLevel))
+	}
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ORIGINS must list at least one origin"))
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("TRUSTED_PROXIES %q is not an IP or CIDR", proxy))
+			}
+		}
+	}
+	if c.HTTPPort < 1 || c.HTTPPort > 65535 {
+		errs = append(errs, fmt.Errorf("HTTP_PORT %d is not a valid port", c.HTTPPort))
+	}
+	if c.GRPCPort < 1 || c.GRPCPort > 65535 {
+		errs = append(errs, fmt.Errorf("GRPC_PORT %d is not a valid port", c.GRPCPort))
+	}
+	if c.MetricsPort < 1 || c.MetricsPort > 65535 {
+		errs = append(errs, fmt.Errorf("METRICS_PORT %d is not a valid port", c.MetricsPort))
+	}
+	if c.HTTPPort == c.GRPCPort {
+		errs = append(errs, errors.New("HTTP_PORT and GRPC_PORT must be different"))
+	}
+	if c.ShutdownTimeoutSeconds <= 0 {
+		errs = append(errs, errors.New("SHUTDOWN_TIMEOUT_SECONDS must be positive"))
+	}
+	if c.SentryDSN != "" {
+		if _, err := reporting.ParseSentryDSN(c.SentryDSN); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch c.CookieSameSite {
+	case "lax", "strict":
+	case "none":
+		if !c.UseSSL {
+			errs = append(errs, errors.New("COOKIE_SAME_SITE none needs USE_SSL, browsers drop insecure SameSite=None cookies"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("COOKIE_SAME_SITE %q must be lax, strict or none", c.CookieSameSite))
+	}
+	return errors.Join(errs...)
+}
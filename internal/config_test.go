@@ -2,19 +2,27 @@ package internal
 
 import (
 	"github.com/stretchr/testify/assert"
+	"lines/lines/reporting"
 	"os"
 	"testing"
 )
 
 func TestNewConfig_SetsBasicValues(t *testing.T) {
 	envMap := map[string]string{
-		"LOCAL_DEV":    "false",
-		"TEST_RUNNER":  "false",
-		"USE_SSL":      "true",
-		"SITE_DOMAIN":  "test.com",
-		"CORS_ORIGINS": "http://test.com,http://test2.com",
-		"SENTRY_DSN":   "sentry",
-		"LOG_LEVEL":    "info",
+		"LOCAL_DEV":                "false",
+		"TEST_RUNNER":              "false",
+		"USE_SSL":                  "true",
+		"SITE_DOMAIN":              "test.com",
+		"CORS_ORIGINS":             "http://test.com,http://test2.com",
+		"SENTRY_DSN":               "sentry",
+		"LOG_LEVEL":                "info",
+		"SHUTDOWN_TIMEOUT_SECONDS": "10",
+		"GRPC_PORT":                "9191",
+		"GRPC_SERVICE_TOKEN":       "secret",
+		"METRICS_PORT":             "9200",
+		"TRUSTED_PROXIES":          "10.0.0.0/8",
+		"ENABLED_APPS":             "user,billing",
+		"COOKIE_SAME_SITE":         "Strict",
 	}
 	for k, v := range envMap {
 		err := os.Setenv(k, v)
@@ -28,8 +36,17 @@ func TestNewConfig_SetsBasicValues(t *testing.T) {
 	assert.True(t, config.UseSSL)
 	assert.Equal(t, "test.com", config.SiteDomain)
 	assert.Equal(t, "sentry", config.SentryDSN)
+	// The DSN is invalid, Validate reports it rather than NewConfig.
+	assert.Equal(t, reporting.NoopReporter{}, config.ErrorReporter)
 	assert.Equal(t, []string{"http://test.com", "http://test2.com"}, config.CORSOrigins)
 	assert.Equal(t, "info", config.LogLevel)
+	assert.Equal(t, 10, config.ShutdownTimeoutSeconds)
+	assert.Equal(t, 9191, config.GRPCPort)
+	assert.Equal(t, "secret", config.GRPCServiceToken)
+	assert.Equal(t, 9200, config.MetricsPort)
+	assert.Equal(t, []string{"10.0.0.0/8"}, config.TrustedProxies)
+	assert.Equal(t, []string{"user", "billing"}, config.EnabledApps)
+	assert.Equal(t, "strict", config.CookieSameSite)
 }
 
 func TestNewConfig_SetsHttpWhenOnLocalDev(t *testing.T) {
@@ -61,3 +78,68 @@ func TestNewConfig_InitialisesLogger(t *testing.T) {
 	config := NewConfig()
 	assert.NotNil(t, config.Logger)
 }
+
+func validConfig() *MainConfig {
+	return &MainConfig{
+		LogLevel:               "info",
+		CORSOrigins:            []string{"http://localhost"},
+		HTTPPort:               8080,
+		GRPCPort:               9090,
+		MetricsPort:            9100,
+		ShutdownTimeoutSeconds: 30,
+		CookieSameSite:         "lax",
+	}
+}
+
+func TestMainConfig_Validate(t *testing.T) {
+	assert.Nil(t, validConfig().Validate())
+}
+
+func TestMainConfig_Validate_ReportsEveryProblem(t *testing.T) {
+	config := validConfig()
+	config.LogLevel = "loud"
+	config.CORSOrigins = nil
+	config.HTTPPort = 0
+	config.GRPCPort = 0
+	config.MetricsPort = 0
+	config.ShutdownTimeoutSeconds = 0
+	config.CookieSameSite = "sometimes"
+
+	err := config.Validate()
+
+	assert.EqualError(t, err, `LOG_LEVEL "loud" is not a log level
+CORS_ORIGINS must list at least one origin
+HTTP_PORT 0 is not a valid port
+GRPC_PORT 0 is not a valid port
+METRICS_PORT 0 is not a valid port
+HTTP_PORT and GRPC_PORT must be different
+SHUTDOWN_TIMEOUT_SECONDS must be positive
+COOKIE_SAME_SITE "sometimes" must be lax, strict or none`)
+}
+
+func TestMainConfig_Validate_TrustedProxies(t *testing.T) {
+	config := validConfig()
+	config.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "::1", "proxy"}
+
+	assert.EqualError(t, config.Validate(), `TRUSTED_PROXIES "proxy" is not an IP or CIDR`)
+}
+
+func TestMainConfig_Validate_SameSiteNoneNeedsSSL(t *testing.T) {
+	config := validConfig()
+	config.CookieSameSite = "none"
+
+	assert.EqualError(t, config.Validate(), "COOKIE_SAME_SITE none needs USE_SSL, browsers drop insecure SameSite=None cookies")
+
+	config.UseSSL = true
+	assert.Nil(t, config.Validate())
+}
+
+func TestMainConfig_Validate_SentryDSN(t *testing.T) {
+	config := validConfig()
+	config.SentryDSN = "https://public@o1.ingest.sentry.io/"
+
+	assert.EqualError(t, config.Validate(), "SENTRY_DSN has no project ID")
+
+	config.SentryDSN = "https://public@o1.ingest.sentry.io/42"
+	assert.Nil(t, config.Validate())
+}
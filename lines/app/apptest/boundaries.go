@@ -0,0 +1,19 @@
+package apptest
+
+import (
+	"lines/lines/app"
+	"testing"
+)
+
+// CheckAppBoundaries fails the test if any package in the module rooted at root imports another app's domain or
+// stores packages.
+func CheckAppBoundaries(t testing.TB, root string) {
+	t.Helper()
+	violations, err := app.AppBoundaryViolations(root)
+	if err != nil {
+		t.Fatalf("Failed to check app boundaries: %s", err.Error())
+	}
+	for _, violation := range violations {
+		t.Error(violation)
+	}
+}
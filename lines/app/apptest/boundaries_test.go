@@ -0,0 +1,38 @@
+package apptest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type recordingT struct {
+	testing.TB
+	errors []any
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Error(args ...any) {
+	r.errors = append(r.errors, args...)
+}
+
+func TestCheckAppBoundaries(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                "module example\n\ngo 1.22\n",
+		"user/domain/domain.go": "package domain\n",
+		"billing/app.go":        "package billing\n\nimport _ \"example/user/domain\"\n",
+	}
+	for path, contents := range files {
+		full := filepath.Join(root, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.Nil(t, os.WriteFile(full, []byte(contents), 0o644))
+	}
+	recorder := &recordingT{}
+
+	CheckAppBoundaries(recorder, root)
+
+	assert.Len(t, recorder.errors, 1)
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// frameworkDir is where the shared lines packages live, it isn't an app even though it has a domain package.
+const frameworkDir = "lines"
+
+// privatePackages are the app packages other apps must not import, they go through the app's use cases instead.
+var privatePackages = map[string]bool{"domain": true, "stores": true}
+
+// AppBoundaryViolations describes every import in the module rooted at root of another app's domain or stores
+// packages. An app is a top level directory with a domain or stores package. apptest.CheckAppBoundaries fails a test
+// with them.
+func AppBoundaryViolations(root string) ([]string, error) {
+	module, err := ModulePath(root)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := appDirs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	fset := token.NewFileSet()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		importer := strings.Split(filepath.ToSlash(rel), "/")[0]
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range file.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(imported, module+"/") {
+				continue
+			}
+			parts := strings.Split(strings.TrimPrefix(imported, module+"/"), "/")
+			if len(parts) < 2 || !apps[parts[0]] || parts[0] == importer || !privatePackages[parts[1]] {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf(
+				"%s imports %s, use the %s app's public use cases instead",
+				filepath.ToSlash(rel),
+				imported,
+				parts[0],
+			))
+		}
+		return nil
+	})
+	return violations, err
+}
+
+// ModulePath reads the module path from root's go.mod.
+func ModulePath(root string) (string, error) {
+	file, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module directive in %s", filepath.Join(root, "go.mod"))
+}
+
+// appDirs returns the top level directories under root that are apps.
+func appDirs(root string) (map[string]bool, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	apps := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == frameworkDir || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		for private := range privatePackages {
+			info, err := os.Stat(filepath.Join(root, entry.Name(), private))
+			if err == nil && info.IsDir() {
+				apps[entry.Name()] = true
+			}
+		}
+	}
+	return apps, nil
+}
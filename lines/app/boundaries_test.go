@@ -0,0 +1,78 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeModule(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	files["go.mod"] = "module example\n\ngo 1.22\n"
+	for path, contents := range files {
+		full := filepath.Join(root, path)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		assert.Nil(t, os.WriteFile(full, []byte(contents), 0o644))
+	}
+	return root
+}
+
+func TestAppBoundaryViolations_AllowsPublicAndOwnPackages(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"user/domain/domain.go":  "package domain\n",
+		"user/stores/stores.go":  "package stores\n",
+		"user/public/public.go":  "package public\n",
+		"user/app.go":            "package user\n\nimport (\n\t_ \"example/user/domain\"\n\t_ \"example/user/stores\"\n)\n",
+		"billing/domain/a.go":    "package domain\n\nimport _ \"example/user/public\"\n",
+		"billing/app.go":         "package billing\n\nimport _ \"example/lines/domain\"\n",
+		"lines/domain/domain.go": "package domain\n",
+		"cmd/main.go":            "package main\n\nimport _ \"example/user\"\n",
+	})
+
+	violations, err := AppBoundaryViolations(root)
+
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestAppBoundaryViolations_ReportsOtherAppsPrivatePackages(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"user/domain/domain.go":      "package domain\n",
+		"user/stores/stores.go":      "package stores\n",
+		"billing/domain/a.go":        "package domain\n\nimport _ \"example/user/domain\"\n",
+		"billing/domain/a_test.go":   "package domain\n\nimport _ \"example/user/stores\"\n",
+		"cmd/main.go":                "package main\n\nimport _ \"example/user/domain\"\n",
+		"billing/.hidden/skipped.go": "package hidden\n\nimport _ \"example/user/domain\"\n",
+	})
+
+	violations, err := AppBoundaryViolations(root)
+
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{
+		"billing/domain/a.go imports example/user/domain, use the user app's public use cases instead",
+		"billing/domain/a_test.go imports example/user/stores, use the user app's public use cases instead",
+		"cmd/main.go imports example/user/domain, use the user app's public use cases instead",
+	}, violations)
+}
+
+func TestAppBoundaryViolations_NoGoMod(t *testing.T) {
+	_, err := AppBoundaryViolations(t.TempDir())
+
+	assert.NotNil(t, err)
+}
+
+func TestModulePath(t *testing.T) {
+	root := t.TempDir()
+	_, err := ModulePath(root)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("go 1.22\n"), 0o644))
+	_, err = ModulePath(root)
+	assert.EqualError(t, err, "no module directive in "+filepath.Join(root, "go.mod"))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/shop\n"), 0o644))
+	module, err := ModulePath(root)
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com/shop", module)
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"io"
+)
+
+// Command is a subcommand of the main binary, e.g. `./main create-user -email ...`.
+type Command struct {
+	Name string
+	// Usage is a one line description shown in the command list.
+	Usage string
+	// Run runs the command with the arguments that followed its name. It runs after the apps are initialised, and
+	// should write its output to out.
+	Run func(ctx context.Context, args []string, out io.Writer) error
+}
+
+// MigratingApp is an optional interface for apps with stores that need migrating.
+type MigratingApp interface {
+	// Migrate brings the app's stores up to date with its models.
+	Migrate() error
+}
+
+// ConfigurableApp is an optional interface for apps that can check their configuration up front.
+type ConfigurableApp interface {
+	// CheckConfig returns an error describing everything wrong with the app's configuration.
+	CheckConfig() error
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name returns the app's name, falling back to its type for apps that don't implement NamedApp.
+func Name(a App) string {
+	if named, ok := a.(NamedApp); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", a)
+}
+
+// InitialisationOrder sorts the apps into layers, where every app's dependencies are in an earlier layer.
+// The apps within a layer don't depend on each other, so they can be initialised concurrently.
+// Apps keep the order they were passed in within each layer.
+func InitialisationOrder(apps []App) ([][]App, error) {
+	dependencies, err := resolveDependencies(apps)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers [][]App
+	done := make([]bool, len(apps))
+	for ordered := 0; ordered < len(apps); {
+		var layer []int
+		for i := range apps {
+			if !done[i] && allDone(dependencies[i], done) {
+				layer = append(layer, i)
+			}
+		}
+		if len(layer) == 0 {
+			return nil, fmt.Errorf("app dependency cycle detected: %s", findCycle(apps, dependencies, done))
+		}
+		layerApps := make([]App, 0, len(layer))
+		for _, i := range layer {
+			done[i] = true
+			layerApps = append(layerApps, apps[i])
+		}
+		ordered += len(layer)
+		layers = append(layers, layerApps)
+	}
+	return layers, nil
+}
+
+// resolveDependencies maps each app's dependency names to the indexes of the apps they refer to.
+func resolveDependencies(apps []App) ([][]int, error) {
+	byName := make(map[string]int, len(apps))
+	for i, a := range apps {
+		named, ok := a.(NamedApp)
+		if !ok {
+			continue
+		}
+		if _, exists := byName[named.Name()]; exists {
+			return nil, fmt.Errorf("app %q is registered more than once", named.Name())
+		}
+		byName[named.Name()] = i
+	}
+
+	dependencies := make([][]int, len(apps))
+	for i, a := range apps {
+		dependent, ok := a.(DependentApp)
+		if !ok {
+			continue
+		}
+		for _, name := range dependent.Dependencies() {
+			index, exists := byName[name]
+			if !exists {
+				return nil, fmt.Errorf("app %q depends on %q, which is not registered", dependent.Name(), name)
+			}
+			dependencies[i] = append(dependencies[i], index)
+		}
+	}
+	return dependencies, nil
+}
+
+func allDone(indexes []int, done []bool) bool {
+	for _, i := range indexes {
+		if !done[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// findCycle returns the first dependency cycle between the apps that couldn't be ordered, formatted as "a -> b -> a".
+// Every one of those apps is waiting on another of them, so following unfinished dependencies must revisit an app.
+func findCycle(apps []App, dependencies [][]int, done []bool) string {
+	start := 0
+	for done[start] {
+		start++
+	}
+	var path []string
+	visited := map[int]int{}
+	for current := start; ; {
+		if index, seen := visited[current]; seen {
+			return strings.Join(append(path[index:], Name(apps[current])), " -> ")
+		}
+		visited[current] = len(path)
+		path = append(path, Name(apps[current]))
+		for _, dependency := range dependencies[current] {
+			if !done[dependency] {
+				current = dependency
+				break
+			}
+		}
+	}
+}
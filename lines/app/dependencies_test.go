@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
+	linesHttp "lines/lines/http"
+	"lines/lines/jobs"
+	"lines/lines/schedule"
+	"testing"
+)
+
+type mockApp struct {
+	name         string
+	dependencies []string
+}
+
+func (m *mockApp) Initialise(*UseCaseRegistry) error               { return nil }
+func (m *mockApp) RegisterHTTPRoutes(linesHttp.HttpEngine)         {}
+func (m *mockApp) RegisterGRPCServices(linesGrpc.GrpcServer) error { return nil }
+func (m *mockApp) RegisterEventHandlers(events.Bus) error          { return nil }
+func (m *mockApp) RegisterJobs(jobs.Registry) error                { return nil }
+func (m *mockApp) RegisterSchedules(schedule.Registry) error       { return nil }
+func (m *mockApp) Commands() []Command                             { return nil }
+func (m *mockApp) Shutdown(context.Context) error                  { return nil }
+func (m *mockApp) Name() string                                    { return m.name }
+func (m *mockApp) Dependencies() []string                          { return m.dependencies }
+
+type anonymousApp struct{}
+
+func (a *anonymousApp) Initialise(*UseCaseRegistry) error               { return nil }
+func (a *anonymousApp) RegisterHTTPRoutes(linesHttp.HttpEngine)         {}
+func (a *anonymousApp) RegisterGRPCServices(linesGrpc.GrpcServer) error { return nil }
+func (a *anonymousApp) RegisterEventHandlers(events.Bus) error          { return nil }
+func (a *anonymousApp) RegisterJobs(jobs.Registry) error                { return nil }
+func (a *anonymousApp) RegisterSchedules(schedule.Registry) error       { return nil }
+func (a *anonymousApp) Commands() []Command                             { return nil }
+func (a *anonymousApp) Shutdown(context.Context) error                  { return nil }
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "user", Name(&mockApp{name: "user"}))
+	assert.Equal(t, "*app.anonymousApp", Name(&anonymousApp{}))
+}
+
+func TestInitialisationOrder_NoDependencies(t *testing.T) {
+	apps := []App{&mockApp{name: "a"}, &mockApp{name: "b"}, &anonymousApp{}, &anonymousApp{}}
+
+	layers, err := InitialisationOrder(apps)
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]App{apps}, layers)
+}
+
+func TestInitialisationOrder_Layers(t *testing.T) {
+	billing := &mockApp{name: "billing", dependencies: []string{"user", "audit"}}
+	user := &mockApp{name: "user"}
+	audit := &mockApp{name: "audit", dependencies: []string{"user"}}
+	search := &mockApp{name: "search"}
+
+	layers, err := InitialisationOrder([]App{billing, user, audit, search})
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]App{{user, search}, {audit}, {billing}}, layers)
+}
+
+func TestInitialisationOrder_MissingDependency(t *testing.T) {
+	apps := []App{&mockApp{name: "billing", dependencies: []string{"user"}}}
+
+	layers, err := InitialisationOrder(apps)
+
+	assert.Nil(t, layers)
+	assert.EqualError(t, err, `app "billing" depends on "user", which is not registered`)
+}
+
+func TestInitialisationOrder_DuplicateName(t *testing.T) {
+	apps := []App{&mockApp{name: "user"}, &mockApp{name: "user"}}
+
+	layers, err := InitialisationOrder(apps)
+
+	assert.Nil(t, layers)
+	assert.EqualError(t, err, `app "user" is registered more than once`)
+}
+
+func TestInitialisationOrder_Cycle(t *testing.T) {
+	apps := []App{
+		&mockApp{name: "search"},
+		&mockApp{name: "user", dependencies: []string{"search", "billing"}},
+		&mockApp{name: "billing", dependencies: []string{"audit"}},
+		&mockApp{name: "audit", dependencies: []string{"user"}},
+	}
+
+	layers, err := InitialisationOrder(apps)
+
+	assert.Nil(t, layers)
+	assert.EqualError(t, err, "app dependency cycle detected: user -> billing -> audit -> user")
+}
+
+func TestInitialisationOrder_SelfDependency(t *testing.T) {
+	apps := []App{&mockApp{name: "user", dependencies: []string{"user"}}}
+
+	_, err := InitialisationOrder(apps)
+
+	assert.EqualError(t, err, "app dependency cycle detected: user -> user")
+}
@@ -1,15 +1,46 @@
 package app
 
 import (
+	"context"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
 	linesHttp "lines/lines/http"
+	"lines/lines/jobs"
+	"lines/lines/schedule"
 )
 
 // App is the interface that all apps must implement.
 type App interface {
-	// Initialise is called to initialise the app.
-	Initialise() error
+	// Initialise is called to initialise the app. The app provides its public use cases here, and resolves the use
+	// cases of the apps it depends on.
+	Initialise(useCases *UseCaseRegistry) error
 	// RegisterHTTPRoutes is called to register the app's HTTP routes.
 	RegisterHTTPRoutes(engine linesHttp.HttpEngine)
 	// RegisterGRPCServices is called to register the app's gRPC services.
-	RegisterGRPCServices() error
+	RegisterGRPCServices(server linesGrpc.GrpcServer) error
+	// RegisterEventHandlers is called to subscribe the app's event handlers, the app should keep the bus if it
+	// publishes events.
+	RegisterEventHandlers(bus events.Bus) error
+	// RegisterJobs is called to register the app's background job handlers, the app should keep the registry if
+	// it enqueues jobs.
+	RegisterJobs(registry jobs.Registry) error
+	// RegisterSchedules is called to register the app's periodic tasks, e.g. purging old rows.
+	RegisterSchedules(registry schedule.Registry) error
+	// Commands returns the subcommands the app adds to the main binary, e.g. admin tasks.
+	Commands() []Command
+	// Shutdown is called when the monolith is stopping, the app should release its resources before ctx expires.
+	Shutdown(ctx context.Context) error
+}
+
+// NamedApp is an optional interface for apps that other apps can depend on.
+type NamedApp interface {
+	// Name is the unique name other apps use to refer to this app.
+	Name() string
+}
+
+// DependentApp is an optional interface for apps that need other apps to be initialised before them.
+type DependentApp interface {
+	NamedApp
+	// Dependencies returns the names of the apps that must be initialised first.
+	Dependencies() []string
 }
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
+	linesHttp "lines/lines/http"
+	"lines/lines/jobs"
+	"lines/lines/schedule"
+	"slices"
+)
+
+// AllApps is the ENABLED_APPS value that enables every registered app.
+const AllApps = "*"
+
+// Registration is an app the main binary can run.
+type Registration struct {
+	// Name is the app's name, it must match the app's NamedApp.Name.
+	Name string
+	// New creates the app, it's only called if the app is enabled, so disabled apps don't connect to anything.
+	New func() App
+	// NewRemote creates a stand-in for the app when it's disabled. The stand-in provides the app's public use
+	// cases through clients of the deployment that does run it, so the enabled apps resolve them as usual. It's nil
+	// for apps that don't provide use cases.
+	NewRemote func() App
+}
+
+// EnabledApps creates the enabled apps, and the remote stand-ins of the disabled ones, in registration order.
+// Enabling AllApps runs every app in the one process.
+func EnabledApps(registrations []Registration, enabled []string) ([]App, error) {
+	known := make(map[string]bool, len(registrations))
+	for _, registration := range registrations {
+		if known[registration.Name] {
+			return nil, fmt.Errorf("app %q is registered more than once", registration.Name)
+		}
+		known[registration.Name] = true
+	}
+	for _, name := range enabled {
+		if name != AllApps && !known[name] {
+			return nil, fmt.Errorf("enabled app %q is not registered", name)
+		}
+	}
+
+	all := slices.Contains(enabled, AllApps)
+	var apps []App
+	for _, registration := range registrations {
+		var a App
+		switch {
+		case all || slices.Contains(enabled, registration.Name):
+			a = registration.New()
+		case registration.NewRemote != nil:
+			a = registration.NewRemote()
+		default:
+			continue
+		}
+		if Name(a) != registration.Name {
+			return nil, fmt.Errorf("app %q is registered as %q", Name(a), registration.Name)
+		}
+		apps = append(apps, a)
+	}
+	return apps, nil
+}
+
+// RemoteApp implements the App hooks as no-ops, remote stand-ins embed it and only implement Name, Initialise and
+// Shutdown. A stand-in never serves requests or runs background work, the deployment running the real app does.
+type RemoteApp struct{}
+
+func (RemoteApp) RegisterHTTPRoutes(linesHttp.HttpEngine)         {}
+func (RemoteApp) RegisterGRPCServices(linesGrpc.GrpcServer) error { return nil }
+func (RemoteApp) RegisterEventHandlers(events.Bus) error          { return nil }
+func (RemoteApp) RegisterJobs(jobs.Registry) error                { return nil }
+func (RemoteApp) RegisterSchedules(schedule.Registry) error       { return nil }
+func (RemoteApp) Commands() []Command                             { return nil }
+func (RemoteApp) Shutdown(context.Context) error                  { return nil }
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type remoteMockApp struct {
+	RemoteApp
+	name string
+}
+
+func (r *remoteMockApp) Name() string                      { return r.name }
+func (r *remoteMockApp) Initialise(*UseCaseRegistry) error { return nil }
+
+func testRegistrations() []Registration {
+	return []Registration{
+		{
+			Name:      "user",
+			New:       func() App { return &mockApp{name: "user"} },
+			NewRemote: func() App { return &remoteMockApp{name: "user"} },
+		},
+		{
+			Name: "billing",
+			New:  func() App { return &mockApp{name: "billing", dependencies: []string{"user"}} },
+		},
+	}
+}
+
+func TestEnabledApps_All(t *testing.T) {
+	apps, err := EnabledApps(testRegistrations(), []string{AllApps})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []App{&mockApp{name: "user"}, &mockApp{name: "billing", dependencies: []string{"user"}}}, apps)
+}
+
+func TestEnabledApps_Subset_UsesRemoteStandIns(t *testing.T) {
+	apps, err := EnabledApps(testRegistrations(), []string{"billing"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []App{&remoteMockApp{name: "user"}, &mockApp{name: "billing", dependencies: []string{"user"}}}, apps)
+	// The stand-in satisfies the enabled app's dependency.
+	_, err = InitialisationOrder(apps)
+	assert.Nil(t, err)
+}
+
+func TestEnabledApps_DisabledWithoutStandIn(t *testing.T) {
+	apps, err := EnabledApps(testRegistrations(), []string{"user"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []App{&mockApp{name: "user"}}, apps)
+}
+
+func TestEnabledApps_UnknownApp(t *testing.T) {
+	_, err := EnabledApps(testRegistrations(), []string{"user", "shop"})
+
+	assert.EqualError(t, err, `enabled app "shop" is not registered`)
+}
+
+func TestEnabledApps_DuplicateRegistration(t *testing.T) {
+	registrations := append(testRegistrations(), testRegistrations()[0])
+
+	_, err := EnabledApps(registrations, []string{AllApps})
+
+	assert.EqualError(t, err, `app "user" is registered more than once`)
+}
+
+func TestEnabledApps_NameMismatch(t *testing.T) {
+	registrations := []Registration{{Name: "users", New: func() App { return &mockApp{name: "user"} }}}
+
+	_, err := EnabledApps(registrations, []string{AllApps})
+
+	assert.EqualError(t, err, `app "user" is registered as "users"`)
+}
+
+func TestRemoteApp_NoOps(t *testing.T) {
+	remote := RemoteApp{}
+	remote.RegisterHTTPRoutes(nil)
+	assert.Nil(t, remote.RegisterGRPCServices(nil))
+	assert.Nil(t, remote.RegisterEventHandlers(nil))
+	assert.Nil(t, remote.RegisterJobs(nil))
+	assert.Nil(t, remote.RegisterSchedules(nil))
+	assert.Nil(t, remote.Commands())
+	assert.Nil(t, remote.Shutdown(context.Background()))
+}
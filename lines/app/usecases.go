@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// UseCase identifies a public interface, T, that an app offers to other apps in the monolith.
+// Once published, a version's interface shouldn't change, breaking changes get a new version alongside the old one.
+type UseCase[T any] struct {
+	App     string
+	Name    string
+	Version int
+}
+
+func (u UseCase[T]) String() string {
+	return fmt.Sprintf("%s.%s.v%d", u.App, u.Name, u.Version)
+}
+
+// UseCaseRegistry holds the use cases apps have provided. Apps provide theirs and resolve other apps' while
+// they initialise, so the consumer should list the provider in its Dependencies.
+type UseCaseRegistry struct {
+	mu       sync.RWMutex
+	useCases map[string]any
+}
+
+func NewUseCaseRegistry() *UseCaseRegistry {
+	return &UseCaseRegistry{useCases: map[string]any{}}
+}
+
+// Provide registers the implementation of a use case, a use case can only be provided once.
+func Provide[T any](registry *UseCaseRegistry, useCase UseCase[T], implementation T) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	key := useCase.String()
+	if _, ok := registry.useCases[key]; ok {
+		return fmt.Errorf("use case %s is provided more than once", key)
+	}
+	registry.useCases[key] = implementation
+	return nil
+}
+
+// Resolve returns the implementation of a use case, or an error if no app has provided it.
+func Resolve[T any](registry *UseCaseRegistry, useCase UseCase[T]) (T, error) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	key := useCase.String()
+	implementation, ok := registry.useCases[key]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf(
+			"use case %s has not been provided, is %q initialised before the app resolving it?",
+			key,
+			useCase.App,
+		)
+	}
+	typed, ok := implementation.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf(
+			"use case %s is provided as %T, not %s",
+			key,
+			implementation,
+			reflect.TypeOf((*T)(nil)).Elem(),
+		)
+	}
+	return typed, nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type resolver interface {
+	Resolve(id uint) string
+}
+
+type mockResolver struct{}
+
+func (m *mockResolver) Resolve(id uint) string { return "resolved" }
+
+var resolveV1 = UseCase[resolver]{App: "user", Name: "Resolve", Version: 1}
+
+func TestUseCase_String(t *testing.T) {
+	assert.Equal(t, "user.Resolve.v1", resolveV1.String())
+}
+
+func TestProvide_Resolve(t *testing.T) {
+	registry := NewUseCaseRegistry()
+
+	assert.Nil(t, Provide[resolver](registry, resolveV1, &mockResolver{}))
+	resolved, err := Resolve(registry, resolveV1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "resolved", resolved.Resolve(1))
+}
+
+func TestProvide_ProvidedTwice(t *testing.T) {
+	registry := NewUseCaseRegistry()
+	assert.Nil(t, Provide[resolver](registry, resolveV1, &mockResolver{}))
+
+	err := Provide[resolver](registry, resolveV1, &mockResolver{})
+
+	assert.EqualError(t, err, "use case user.Resolve.v1 is provided more than once")
+}
+
+func TestProvide_VersionsAreSeparate(t *testing.T) {
+	registry := NewUseCaseRegistry()
+	resolveV2 := UseCase[resolver]{App: "user", Name: "Resolve", Version: 2}
+	assert.Nil(t, Provide[resolver](registry, resolveV1, &mockResolver{}))
+
+	_, err := Resolve(registry, resolveV2)
+	assert.NotNil(t, err)
+	assert.Nil(t, Provide[resolver](registry, resolveV2, &mockResolver{}))
+}
+
+func TestResolve_NotProvided(t *testing.T) {
+	registry := NewUseCaseRegistry()
+
+	resolved, err := Resolve(registry, resolveV1)
+
+	assert.Nil(t, resolved)
+	assert.EqualError(
+		t,
+		err,
+		`use case user.Resolve.v1 has not been provided, is "user" initialised before the app resolving it?`,
+	)
+}
+
+func TestResolve_WrongType(t *testing.T) {
+	registry := NewUseCaseRegistry()
+	assert.Nil(t, Provide(registry, UseCase[string]{App: "user", Name: "Resolve", Version: 1}, "not a resolver"))
+
+	_, err := Resolve(registry, resolveV1)
+
+	assert.EqualError(t, err, "use case user.Resolve.v1 is provided as string, not app.resolver")
+}
@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"fmt"
+	"lines/lines/store"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationTag is the struct tag Validate reads a field's rules from, e.g.
+//
+//	Email string `json:"email" validate:"required,email,max=254"`
+//
+// Rules are separated by commas and take a parameter after "=". A regex rule takes the rest of the tag, so its
+// pattern can contain commas, and has to be the last rule.
+const ValidationTag = "validate"
+
+// Validator checks a field's value against a rule, returning the error if it's invalid or "" if it isn't. param is
+// the rule's parameter, e.g. "8" for min=8, and label is the field's name for messages, e.g. "Email". Validators other
+// than required are only called on fields that aren't empty, and pointers are dereferenced for them.
+type Validator func(value reflect.Value, param string, label string) string
+
+var validators = struct {
+	sync.RWMutex
+	byName map[string]Validator
+}{byName: map[string]Validator{
+	"required": requiredValidator,
+	"email":    emailValidator,
+	"min":      minValidator,
+	"max":      maxValidator,
+	"regex":    regexValidator,
+	"oneof":    oneOfValidator,
+}}
+
+// RegisterValidator makes a validator usable in validate tags under name, replacing the one already registered
+// with that name.
+func RegisterValidator(name string, validator Validator) {
+	validators.Lock()
+	defer validators.Unlock()
+	validators.byName[name] = validator
+}
+
+func validatorNamed(name string) (Validator, bool) {
+	validators.RLock()
+	defer validators.RUnlock()
+	validator, ok := validators.byName[name]
+	return validator, ok
+}
+
+// Validate checks a struct's fields against their validate tags, and the fields of its nested structs, pointers to
+// structs and slices of structs. Errors are by field, named like the field's JSON, e.g. "address.line" or
+// "items[0].name", and fields without a JSON name are named in snake case, e.g. "max_attempts". It panics on a rule
+// that isn't registered, like regexp.MustCompile, since a tag is fixed when the program's built.
+func Validate(value any) []DomainValidationErrors {
+	var errors []DomainValidationErrors
+	validateValue(reflect.ValueOf(value), "", &errors)
+	return errors
+}
+
+// ValidateModel is Validate for a model's Validate, which returns store errors.
+func ValidateModel(model any) []store.ModelValidationError {
+	var errors []store.ModelValidationError
+	for _, fieldErrors := range Validate(model) {
+		for _, message := range fieldErrors.Errors {
+			errors = append(errors, store.ModelValidationError{Field: fieldErrors.Field, Message: message})
+		}
+	}
+	return errors
+}
+
+func validateValue(value reflect.Value, path string, errors *[]DomainValidationErrors) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		for _, field := range fieldsOf(value.Type()) {
+			fieldValue := value.FieldByIndex(field.index)
+			fieldPath := field.name
+			if field.embedded {
+				fieldPath = path
+			} else if path != "" {
+				fieldPath = path + "." + field.name
+			}
+			for _, rule := range field.rules {
+				if rule.name != "required" && isEmpty(fieldValue) {
+					continue
+				}
+				if message := rule.validator(indirect(fieldValue), rule.param, field.label); message != "" {
+					*errors = AddValidationError(fieldPath, message, *errors)
+				}
+			}
+			validateValue(fieldValue, fieldPath, errors)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	}
+}
+
+type validationRule struct {
+	name      string
+	param     string
+	validator Validator
+}
+
+type validatedField struct {
+	index    []int
+	name     string
+	label    string
+	embedded bool
+	rules    []validationRule
+}
+
+// parsedFields caches the fields of the struct types Validate has seen, so tags are only parsed once per type.
+var parsedFields sync.Map
+
+func fieldsOf(structType reflect.Type) []validatedField {
+	if fields, ok := parsedFields.Load(structType); ok {
+		return fields.([]validatedField)
+	}
+	var fields []validatedField
+	for _, field := range reflect.VisibleFields(structType) {
+		tag := field.Tag.Get(ValidationTag)
+		if (!field.IsExported() && !field.Anonymous) || tag == "-" || len(field.Index) > 1 {
+			continue
+		}
+		name := jsonName(field)
+		fields = append(fields, validatedField{
+			index:    field.Index,
+			name:     name,
+			label:    label(name),
+			embedded: field.Anonymous,
+			rules:    parseRules(structType, field, tag),
+		})
+	}
+	parsedFields.Store(structType, fields)
+	return fields
+}
+
+func parseRules(structType reflect.Type, field reflect.StructField, tag string) []validationRule {
+	var rules []validationRule
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else {
+			rule, tag, _ = strings.Cut(tag, ",")
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		validator, ok := validatorNamed(name)
+		if !ok {
+			panic(fmt.Sprintf("domain: %s.%s has the unknown validation rule %q", structType, field.Name, name))
+		}
+		rules = append(rules, validationRule{name: name, param: param, validator: validator})
+	}
+	return rules
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name != "" && name != "-" {
+		return name
+	}
+	return snakeCase(field.Name)
+}
+
+func snakeCase(name string) string {
+	var builder strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (unicode.IsLower(runes[i-1]) || nextIsLower) {
+				builder.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// label turns a field's name into the start of a sentence, e.g. "max_attempts" into "Max attempts".
+func label(name string) string {
+	name = strings.ReplaceAll(name, "_", " ")
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	return value
+}
+
+func isEmpty(value reflect.Value) bool {
+	value = indirect(value)
+	switch value.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func requiredValidator(value reflect.Value, _ string, label string) string {
+	if isEmpty(value) {
+		return label + " is required."
+	}
+	return ""
+}
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+func emailValidator(value reflect.Value, _ string, label string) string {
+	if value.Kind() != reflect.String || !emailRegex.MatchString(value.String()) {
+		return label + " must be a valid email."
+	}
+	return ""
+}
+
+// size is what min and max compare: a string's characters, a collection's length or a number.
+func size(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	panic(fmt.Sprintf("domain: min and max can't validate a %s", value.Kind()))
+}
+
+func sizeParam(param string) float64 {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("domain: %q isn't a number for min or max", param))
+	}
+	return bound
+}
+
+func minValidator(value reflect.Value, param string, label string) string {
+	if size, unit := size(value); size < sizeParam(param) {
+		return fmt.Sprintf("%s must be at least %s%s.", label, param, unit)
+	}
+	return ""
+}
+
+func maxValidator(value reflect.Value, param string, label string) string {
+	if size, unit := size(value); size > sizeParam(param) {
+		return fmt.Sprintf("%s must be at most %s%s.", label, param, unit)
+	}
+	return ""
+}
+
+// regexes caches compiled regex rules by pattern.
+var regexes sync.Map
+
+func regexValidator(value reflect.Value, param string, label string) string {
+	regex, ok := regexes.Load(param)
+	if !ok {
+		regex, _ = regexes.LoadOrStore(param, regexp.MustCompile(param))
+	}
+	if !regex.(*regexp.Regexp).MatchString(fmt.Sprint(value)) {
+		return label + " is invalid."
+	}
+	return ""
+}
+
+func oneOfValidator(value reflect.Value, param string, label string) string {
+	options := strings.Fields(param)
+	if !slices.Contains(options, fmt.Sprint(value)) {
+		return fmt.Sprintf("%s must be one of %s.", label, strings.Join(options, ", "))
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"lines/lines/store"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	Line     string `json:"line" validate:"required"`
+	Postcode string `validate:"regex=^[A-Z]{2}[0-9]{1,2},? [0-9][A-Z]{2}$"`
+}
+
+type testTimestamps struct {
+	CreatedBy string `json:"created_by" validate:"required"`
+}
+
+type testSignUp struct {
+	testTimestamps
+	Name        string        `json:"name" validate:"required,min=2,max=5"`
+	Email       string        `json:"email" validate:"required,email"`
+	Nickname    *string       `json:"nickname" validate:"min=3"`
+	Plan        string        `json:"plan" validate:"oneof=free pro"`
+	Age         int           `json:"age" validate:"max=130"`
+	Tags        []string      `json:"tags" validate:"max=1"`
+	Address     testAddress   `json:"address"`
+	Previous    []testAddress `json:"previous"`
+	MaxAttempts int           `validate:"required"`
+	Ignored     string        `validate:"-"`
+}
+
+func validSignUp() testSignUp {
+	return testSignUp{
+		testTimestamps: testTimestamps{CreatedBy: "admin"},
+		Name:           "Ada",
+		Email:          "ada@example.com",
+		Address:        testAddress{Line: "1 Street"},
+		MaxAttempts:    1,
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	signUp := validSignUp()
+
+	assert.Nil(t, Validate(signUp))
+	assert.Nil(t, Validate(&signUp))
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	nickname := "Al"
+	signUp := testSignUp{
+		Name:     "Adelaide",
+		Email:    "ada",
+		Nickname: &nickname,
+		Plan:     "team",
+		Age:      200,
+		Tags:     []string{"a", "b"},
+		Address:  testAddress{Postcode: "nope"},
+		Previous: []testAddress{{Line: "2 Street", Postcode: "AB1, 2CD"}, {}},
+	}
+
+	assert.Equal(t, []DomainValidationErrors{
+		{Field: "created_by", Errors: []string{"Created by is required."}},
+		{Field: "name", Errors: []string{"Name must be at most 5 characters."}},
+		{Field: "email", Errors: []string{"Email must be a valid email."}},
+		{Field: "nickname", Errors: []string{"Nickname must be at least 3 characters."}},
+		{Field: "plan", Errors: []string{"Plan must be one of free, pro."}},
+		{Field: "age", Errors: []string{"Age must be at most 130."}},
+		{Field: "tags", Errors: []string{"Tags must be at most 1 items."}},
+		{Field: "address.line", Errors: []string{"Line is required."}},
+		{Field: "address.postcode", Errors: []string{"Postcode is invalid."}},
+		{Field: "previous[1].line", Errors: []string{"Line is required."}},
+		{Field: "max_attempts", Errors: []string{"Max attempts is required."}},
+	}, Validate(signUp))
+}
+
+func TestValidate_Required(t *testing.T) {
+	signUp := validSignUp()
+	signUp.Name = ""
+
+	// Only required is checked on an empty field.
+	assert.Equal(t, []DomainValidationErrors{{Field: "name", Errors: []string{"Name is required."}}}, Validate(signUp))
+}
+
+func TestValidate_UnknownRule(t *testing.T) {
+	type unknown struct {
+		Name string `validate:"shiny"`
+	}
+
+	assert.PanicsWithValue(t, `domain: domain.unknown.Name has the unknown validation rule "shiny"`, func() {
+		Validate(unknown{})
+	})
+}
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator("lowercase", func(value reflect.Value, _ string, label string) string {
+		if value.String() != strings.ToLower(value.String()) {
+			return label + " must be lower case."
+		}
+		return ""
+	})
+	type handle struct {
+		Handle string `json:"handle" validate:"required,lowercase"`
+	}
+
+	assert.Nil(t, Validate(handle{Handle: "ada"}))
+	assert.Equal(t, []DomainValidationErrors{{Field: "handle", Errors: []string{"Handle must be lower case."}}}, Validate(handle{Handle: "Ada"}))
+}
+
+func TestValidateModel(t *testing.T) {
+	type model struct {
+		Name  string `validate:"required"`
+		Email string `validate:"required,email"`
+	}
+
+	assert.Nil(t, ValidateModel(model{Name: "Ada", Email: "ada@example.com"}))
+	assert.Equal(t, []store.ModelValidationError{
+		{Field: "name", Message: "Name is required."},
+		{Field: "email", Message: "Email must be a valid email."},
+	}, ValidateModel(model{Email: "ada"}))
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "name", snakeCase("Name"))
+	assert.Equal(t, "max_attempts", snakeCase("MaxAttempts"))
+	assert.Equal(t, "user_id", snakeCase("UserID"))
+	assert.Equal(t, "http_status", snakeCase("HTTPStatus"))
+}
@@ -2,7 +2,6 @@ package domain
 
 import (
 	"lines/lines/store"
-	"regexp"
 )
 
 type GenericValidator func(value string, fieldName string, errors []DomainValidationErrors) []DomainValidationErrors
@@ -37,7 +36,6 @@ func EmailValidator(value string, fieldName string, errors []DomainValidationErr
 	if value == "" {
 		errors = AddValidationError(fieldName, fieldName+" is required", errors)
 	}
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(value) {
 		errors = AddValidationError(fieldName, "Invalid email", errors)
 	}
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// BusConfig is the configuration for an InMemoryBus.
+type BusConfig struct {
+	// Workers is the number of goroutines running handlers.
+	Workers int
+	// QueueSize is the number of deliveries that can be waiting for a worker before Publish blocks.
+	QueueSize int
+	// MaxAttempts is how many times a handler is tried before the delivery is dead-lettered.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry, it doubles with each attempt.
+	RetryBackoff time.Duration
+	Logger       logging.Logger
+}
+
+// NewBusConfig creates a new BusConfig, reading from environment variables.
+func NewBusConfig(logger logging.Logger) BusConfig {
+	return BusConfig{
+		Workers:      utils.GetEnvOrDefault("EVENT_BUS_WORKERS", "4", "int").(int),
+		QueueSize:    utils.GetEnvOrDefault("EVENT_BUS_QUEUE_SIZE", "1024", "int").(int),
+		MaxAttempts:  utils.GetEnvOrDefault("EVENT_BUS_MAX_ATTEMPTS", "5", "int").(int),
+		RetryBackoff: time.Duration(utils.GetEnvOrDefault("EVENT_BUS_RETRY_BACKOFF_MS", "100", "int").(int)) * time.Millisecond,
+		Logger:       logger,
+	}
+}
+
+// delivery is a single event on its way to a single handler.
+type delivery struct {
+	event   Event
+	handler Handler
+	attempt int
+	// requestID is the ID of the request the event was published for, if any, the handler's context carries it.
+	requestID string
+	// result gets the delivery's outcome if it was published with PublishAndWait.
+	result chan<- error
+}
+
+// acknowledge reports the delivery's outcome to the publisher if it's waiting for it.
+func (d delivery) acknowledge(err error) {
+	if d.result != nil {
+		d.result <- err
+	}
+}
+
+// InMemoryBus is an in-process Bus. Every subscribed handler gets its own delivery of each event, which is retried
+// with exponential backoff until it succeeds or runs out of attempts, so delivery is at-least-once while the
+// process is running. Events that have to survive the process, like an outbox's, are published with PublishAndWait,
+// so their publisher only lets go of them once they're handled.
+type InMemoryBus struct {
+	config      BusConfig
+	mu          sync.RWMutex
+	subscribers map[string][]Handler
+	closed      bool
+	deliveries  chan delivery
+	// pending counts deliveries that are queued, running or waiting to be retried.
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+	stop    chan struct{}
+}
+
+// NewInMemoryBus creates a new InMemoryBus and starts its workers.
+func NewInMemoryBus(config BusConfig) *InMemoryBus {
+	bus := &InMemoryBus{
+		config:      config,
+		subscribers: map[string][]Handler{},
+		deliveries:  make(chan delivery, config.QueueSize),
+		stop:        make(chan struct{}),
+	}
+	for i := 0; i < config.Workers; i++ {
+		bus.workers.Add(1)
+		go bus.work()
+	}
+	return bus
+}
+
+// Subscribe registers a handler for every event published with the given name.
+func (b *InMemoryBus) Subscribe(eventName string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventName] = append(b.subscribers[eventName], handler)
+}
+
+// Publish queues a delivery of the event for each subscribed handler.
+// It blocks while the queue is full, returning early if ctx is cancelled.
+func (b *InMemoryBus) Publish(ctx context.Context, event Event) error {
+	_, _, err := b.publish(ctx, event, false)
+	return err
+}
+
+// PublishAndWait queues a delivery of the event for each subscribed handler and waits for them to succeed or run out
+// of attempts. A failed delivery isn't dead-lettered, its error is returned for the caller to retry the event. It
+// returns ErrBusClosed if the bus closes first, and ctx's error if ctx is cancelled first.
+func (b *InMemoryBus) PublishAndWait(ctx context.Context, event Event) error {
+	queued, results, err := b.publish(ctx, event, true)
+	if err != nil {
+		return err
+	}
+	errs := make([]error, queued)
+	for i := range errs {
+		select {
+		case errs[i] = <-results:
+		case <-b.stop:
+			return ErrBusClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publish queues a delivery of the event for each subscribed handler, returning how many were queued. If acknowledge
+// is set, the deliveries' outcomes are sent to the returned channel.
+func (b *InMemoryBus) publish(ctx context.Context, event Event, acknowledge bool) (int, <-chan error, error) {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return 0, nil, ErrBusClosed
+	}
+	handlers := b.subscribers[event.EventName()]
+	b.pending.Add(len(handlers))
+	b.mu.RUnlock()
+	requestID := logging.RequestID(ctx)
+	var results chan error
+	if acknowledge {
+		// Buffered so workers never block acknowledging, even once the publisher has stopped waiting.
+		results = make(chan error, len(handlers))
+	}
+
+	for i, handler := range handlers {
+		select {
+		case b.deliveries <- delivery{event: event, handler: handler, attempt: 1, requestID: requestID, result: results}:
+		case <-ctx.Done():
+			b.pending.Add(-(len(handlers) - i))
+			return i, results, ctx.Err()
+		}
+	}
+	return len(handlers), results, nil
+}
+
+// Close stops accepting events, waits for pending deliveries and retries to finish and then stops the workers.
+// If ctx expires first, the remaining deliveries are dropped.
+func (b *InMemoryBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(drained)
+	}()
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	close(b.stop)
+	b.workers.Wait()
+	return err
+}
+
+func (b *InMemoryBus) work() {
+	defer b.workers.Done()
+	for {
+		select {
+		case d := <-b.deliveries:
+			b.deliver(d)
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// deliver runs the handler, scheduling a retry if it fails and dead-lettering the delivery once it's out of attempts.
+func (b *InMemoryBus) deliver(d delivery) {
+	err := b.runHandler(d)
+	if err == nil {
+		d.acknowledge(nil)
+		b.pending.Done()
+		return
+	}
+	if d.attempt >= b.config.MaxAttempts && d.result != nil {
+		// The publisher has the event, it retries it rather than losing it.
+		d.acknowledge(fmt.Errorf("handler for %s failed after %d attempts: %w", d.event.EventName(), d.attempt, err))
+		b.pending.Done()
+		return
+	}
+	if d.attempt >= b.config.MaxAttempts {
+		b.config.Logger.Error(
+			"events",
+			"InMemoryBus.deliver",
+			fmt.Sprintf("Dead-lettering %s after %d attempts: %s", d.event.EventName(), d.attempt, err.Error()),
+		)
+		b.pending.Done()
+		return
+	}
+	b.config.Logger.Warn(
+		"events",
+		"InMemoryBus.deliver",
+		fmt.Sprintf("Handler for %s failed on attempt %d, retrying: %s", d.event.EventName(), d.attempt, err.Error()),
+	)
+	backoff := b.config.RetryBackoff * time.Duration(1<<(d.attempt-1))
+	d.attempt++
+	time.AfterFunc(backoff, func() {
+		select {
+		case b.deliveries <- d:
+		case <-b.stop:
+			d.acknowledge(ErrBusClosed)
+		}
+	})
+}
+
+// runHandler runs the delivery's handler, turning a panic into an error so one bad handler can't take down a worker.
+// The handler runs in the context of the request the event was published for, so the request ID is on its logs, but
+// it isn't cancelled with the request.
+func (b *InMemoryBus) runHandler(d delivery) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+	ctx := context.Background()
+	if d.requestID != "" {
+		ctx = logging.WithRequestID(ctx, d.requestID)
+	}
+	return d.handler(ctx, d.event)
+}
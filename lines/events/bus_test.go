@@ -0,0 +1,327 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	ID int
+}
+
+func (e testEvent) EventName() string { return "test.event" }
+
+type otherEvent struct{}
+
+func (e otherEvent) EventName() string { return "test.event" }
+
+type mockLogger struct {
+	logging.Logger
+	mu         sync.Mutex
+	ErrorCalls int
+	WarnCalls  int
+}
+
+func (m *mockLogger) Error(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorCalls++
+}
+
+func (m *mockLogger) Warn(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.WarnCalls++
+}
+
+func newTestBus(logger *mockLogger) *InMemoryBus {
+	return NewInMemoryBus(BusConfig{
+		Workers:      2,
+		QueueSize:    10,
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+		Logger:       logger,
+	})
+}
+
+func TestNewBusConfig(t *testing.T) {
+	t.Setenv("EVENT_BUS_WORKERS", "8")
+	t.Setenv("EVENT_BUS_RETRY_BACKOFF_MS", "250")
+	logger := &mockLogger{}
+	config := NewBusConfig(logger)
+	assert.Equal(t, 8, config.Workers)
+	assert.Equal(t, 1024, config.QueueSize)
+	assert.Equal(t, 5, config.MaxAttempts)
+	assert.Equal(t, 250*time.Millisecond, config.RetryBackoff)
+	assert.Equal(t, logger, config.Logger)
+}
+
+func TestInMemoryBus_DeliversToEverySubscriber(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	var calls atomic.Int32
+	for i := 0; i < 3; i++ {
+		bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+			calls.Add(1)
+			return nil
+		})
+	}
+	bus.Subscribe("other.event", func(ctx context.Context, event Event) error {
+		t.Error("Handler for another event was called")
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{ID: 1}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestInMemoryBus_HandlersCarryRequestID(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	requestIDs := make(chan string, 2)
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		requestIDs <- logging.RequestID(ctx)
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(logging.WithRequestID(context.Background(), "abc"))
+
+	assert.Nil(t, bus.Publish(ctx, testEvent{ID: 1}))
+	// The handler outlives the request it was published for.
+	cancel()
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{ID: 2}))
+	assert.Nil(t, bus.Close(context.Background()))
+	close(requestIDs)
+
+	var received []string
+	for requestID := range requestIDs {
+		received = append(received, requestID)
+	}
+	assert.ElementsMatch(t, []string{"abc", ""}, received)
+}
+
+func TestInMemoryBus_NoSubscribers(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{}))
+	assert.Nil(t, bus.Close(context.Background()))
+}
+
+func TestSubscribe_Typed(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	var received testEvent
+	Subscribe(bus, func(ctx context.Context, event testEvent) error {
+		received = event
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{ID: 42}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, 42, received.ID)
+}
+
+func TestSubscribe_Typed_RawEvent(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	var received testEvent
+	Subscribe(bus, func(ctx context.Context, event testEvent) error {
+		received = event
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), RawEvent{Name: "test.event", Payload: []byte(`{"ID":7}`)}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, 7, received.ID)
+}
+
+func TestSubscribe_Typed_InvalidRawEvent(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	Subscribe(bus, func(ctx context.Context, event testEvent) error {
+		t.Error("Handler was called with an undecodable event")
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), RawEvent{Name: "test.event", Payload: []byte(`nope`)}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+func TestSubscribe_Typed_UnexpectedEvent(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	Subscribe(bus, func(ctx context.Context, event testEvent) error {
+		t.Error("Handler was called with the wrong event type")
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), otherEvent{}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+func TestInMemoryBus_RetriesFailedHandler(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	var calls atomic.Int32
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		if calls.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 2, logger.WarnCalls)
+	assert.Equal(t, 0, logger.ErrorCalls)
+}
+
+func TestInMemoryBus_DeadLettersAfterMaxAttempts(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	var calls atomic.Int32
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls.Add(1)
+		return assert.AnError
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+func TestInMemoryBus_IsolatesPanics(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	var panics, calls atomic.Int32
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		if panics.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls.Add(1)
+		return nil
+	})
+
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{}))
+	assert.Nil(t, bus.Close(context.Background()))
+
+	assert.Equal(t, int32(2), panics.Load())
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 1, logger.WarnCalls)
+}
+
+func TestInMemoryBus_PublishAfterClose(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	assert.Nil(t, bus.Close(context.Background()))
+
+	err := bus.Publish(context.Background(), testEvent{})
+	assert.ErrorIs(t, err, ErrBusClosed)
+}
+
+func TestInMemoryBus_PublishCancelledWhileQueueFull(t *testing.T) {
+	bus := NewInMemoryBus(BusConfig{QueueSize: 1, MaxAttempts: 1, Logger: &mockLogger{}})
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := bus.Publish(ctx, testEvent{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer closeCancel()
+	assert.ErrorIs(t, bus.Close(closeCtx), context.DeadlineExceeded)
+}
+
+func TestInMemoryBus_CloseDeadlineExceeded(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	release := make(chan struct{})
+	defer close(release)
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	})
+	assert.Nil(t, bus.Publish(context.Background(), testEvent{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- bus.Close(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	release <- struct{}{}
+
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+}
+
+func TestInMemoryBus_PublishAndWait_WaitsForHandlers(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	var handled atomic.Int32
+	for i := 0; i < 2; i++ {
+		bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+			time.Sleep(10 * time.Millisecond)
+			handled.Add(1)
+			return nil
+		})
+	}
+
+	assert.Nil(t, bus.PublishAndWait(context.Background(), testEvent{}))
+	assert.Equal(t, int32(2), handled.Load())
+	assert.Nil(t, bus.Close(context.Background()))
+}
+
+func TestInMemoryBus_PublishAndWait_ReturnsHandlerErrors(t *testing.T) {
+	logger := &mockLogger{}
+	bus := newTestBus(logger)
+	var calls atomic.Int32
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls.Add(1)
+		return assert.AnError
+	})
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+
+	err := bus.PublishAndWait(context.Background(), testEvent{})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, int32(3), calls.Load())
+	// The publisher retries the event, it isn't dead-lettered.
+	assert.Equal(t, 0, logger.ErrorCalls)
+	assert.Nil(t, bus.Close(context.Background()))
+}
+
+func TestInMemoryBus_PublishAndWait_Closed(t *testing.T) {
+	bus := newTestBus(&mockLogger{})
+	release := make(chan struct{})
+	bus.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- bus.PublishAndWait(context.Background(), testEvent{}) }()
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	closed := make(chan error)
+	go func() { closed <- bus.Close(ctx) }()
+
+	// The handler didn't finish before the bus closed, so the event isn't acknowledged.
+	assert.ErrorIs(t, <-done, ErrBusClosed)
+	close(release)
+	assert.ErrorIs(t, <-closed, context.DeadlineExceeded)
+	assert.ErrorIs(t, bus.PublishAndWait(context.Background(), testEvent{}), ErrBusClosed)
+}
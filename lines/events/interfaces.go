@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrBusClosed is returned when publishing to a bus that is shutting down.
+var ErrBusClosed = errors.New("event bus is closed")
+
+// Event is something that happened in an app which other apps may want to react to.
+type Event interface {
+	// EventName identifies the type of event, e.g. "user.created". Subscribers are matched on it.
+	EventName() string
+}
+
+// Handler handles a single delivery of an event. Returning an error, or panicking, causes the delivery to be retried.
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher is the part of a Bus that apps use to emit events.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// AcknowledgingPublisher is a Publisher that can wait for an event to be handled, for callers that hold on to the
+// event until it is, like an outbox relay.
+type AcknowledgingPublisher interface {
+	Publisher
+	// PublishAndWait publishes the event and waits for every subscribed handler to finish with it, returning the
+	// errors of the handlers that failed. Publishing a failed event again runs every handler again, so delivery is
+	// at-least-once and handlers must be idempotent.
+	PublishAndWait(ctx context.Context, event Event) error
+}
+
+// Bus delivers published events to every handler subscribed to the event's name.
+type Bus interface {
+	AcknowledgingPublisher
+	Subscribe(eventName string, handler Handler)
+	// Close stops accepting events and waits for queued deliveries to finish, or for ctx to expire.
+	Close(ctx context.Context) error
+}
+
+// Subscribe registers a handler for events of type T, which should be a struct value type.
+// RawEvents with a matching name are decoded into T before the handler is called.
+func Subscribe[T Event](bus Bus, handler func(ctx context.Context, event T) error) {
+	var zero T
+	bus.Subscribe(zero.EventName(), func(ctx context.Context, event Event) error {
+		switch e := event.(type) {
+		case T:
+			return handler(ctx, e)
+		case RawEvent:
+			var typed T
+			err := json.Unmarshal(e.Payload, &typed)
+			if err != nil {
+				return err
+			}
+			return handler(ctx, typed)
+		default:
+			return &UnexpectedEventError{EventName: event.EventName()}
+		}
+	})
+}
+
+// UnexpectedEventError is returned when an event can't be converted to the type a handler subscribed with.
+type UnexpectedEventError struct {
+	EventName string
+}
+
+func (e *UnexpectedEventError) Error() string {
+	return "unexpected type for event " + e.EventName
+}
+
+// RawEvent is an event that has been serialised to JSON, e.g. by an outbox. Typed subscribers decode its payload.
+type RawEvent struct {
+	Name    string
+	Payload json.RawMessage
+}
+
+func (e RawEvent) EventName() string { return e.Name }
@@ -0,0 +1,298 @@
+package generate
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/inflection"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"lines/lines/app"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed all:templates
+var templates embed.FS
+
+// AppListPath is where the app list lives, relative to the repository root.
+var AppListPath = filepath.Join("cmd", "apps.go")
+
+var (
+	appNamePattern   = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	modelNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	// reservedAppNames are the top level directories that aren't apps.
+	reservedAppNames = []string{"lines", "cmd", "internal"}
+	// reservedModelVars are the packages the templates import, a model variable named after one would shadow it.
+	reservedModelVars = []string{
+		"app", "assert", "context", "domain", "events", "gin", "http", "jobs", "logging", "schedule", "store",
+		"stores", "strconv", "strings", "testing", "utils",
+	}
+)
+
+// Options describes the app to generate.
+type Options struct {
+	// Root is the repository root, the directory holding go.mod.
+	Root string
+	// App is the app's name, it's the app's directory, package and route prefix.
+	App string
+	// Model is the app's first model, it defaults to the singular of the app's name.
+	Model string
+}
+
+// Validate returns an error describing the first thing wrong with the options.
+func (o Options) Validate() error {
+	if !appNamePattern.MatchString(o.App) {
+		return fmt.Errorf("app name %q must be lower case letters and digits, starting with a letter", o.App)
+	}
+	if slices.Contains(reservedAppNames, o.App) || token.IsKeyword(o.App) {
+		return fmt.Errorf("app name %q is reserved", o.App)
+	}
+	if !modelNamePattern.MatchString(o.Model) {
+		return fmt.Errorf("model name %q must be an exported Go identifier, e.g. Invoice", o.Model)
+	}
+	modelVar := lowerFirst(o.Model)
+	if token.IsKeyword(modelVar) || slices.Contains(reservedModelVars, modelVar) {
+		return fmt.Errorf("model name %q clashes with a Go keyword or package", o.Model)
+	}
+	return nil
+}
+
+// templateData is what the templates are rendered with.
+type templateData struct {
+	Module string
+	// App is the app's name, e.g. "billing".
+	App string
+	// AppType prefixes the app's types, e.g. "Billing".
+	AppType string
+	// AppUpper prefixes the app's environment variables, e.g. "BILLING".
+	AppUpper string
+	// Model is the model's type, e.g. "LineItem".
+	Model string
+	// ModelVar is a variable holding a model, e.g. "lineItem".
+	ModelVar string
+	// ModelPluralType is the model's plural as an exported identifier, e.g. "LineItems".
+	ModelPluralType string
+	// ModelPath is the model's route segment, e.g. "line-items".
+	ModelPath string
+	// ModelWords is the model in a sentence, e.g. "line item".
+	ModelWords string
+	// ModelSentence starts a sentence with the model, e.g. "Line item".
+	ModelSentence string
+}
+
+func newTemplateData(module string, options Options) templateData {
+	words := splitWords(options.Model)
+	plural := inflection.Plural(options.Model)
+	modelWords := strings.ToLower(strings.Join(words, " "))
+	return templateData{
+		Module:          module,
+		App:             options.App,
+		AppType:         upperFirst(options.App),
+		AppUpper:        strings.ToUpper(options.App),
+		Model:           options.Model,
+		ModelVar:        lowerFirst(options.Model),
+		ModelPluralType: plural,
+		ModelPath:       strings.ToLower(strings.Join(splitWords(plural), "-")),
+		ModelWords:      modelWords,
+		ModelSentence:   upperFirst(modelWords),
+	}
+}
+
+// NewApp generates an app mirroring the user app's layout under Root and adds it to the app list. It returns the
+// paths it wrote, relative to Root. Nothing is written if the app's directory already exists.
+func NewApp(options Options) ([]string, error) {
+	if options.Model == "" {
+		options.Model = upperFirst(inflection.Singular(options.App))
+	}
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	module, err := app.ModulePath(options.Root)
+	if err != nil {
+		return nil, err
+	}
+	appDir := filepath.Join(options.Root, options.App)
+	if _, err := os.Stat(appDir); err == nil {
+		return nil, fmt.Errorf("%s already exists", appDir)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	data := newTemplateData(module, options)
+	files, err := render(data)
+	if err != nil {
+		return nil, err
+	}
+	appList, err := os.ReadFile(filepath.Join(options.Root, AppListPath))
+	if err != nil {
+		return nil, err
+	}
+	appList, err = addRegistration(appList, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AppListPath, err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var written []string
+	for _, path := range paths {
+		target := filepath.Join(options.App, path)
+		err = os.MkdirAll(filepath.Join(options.Root, filepath.Dir(target)), 0o755)
+		if err != nil {
+			return written, err
+		}
+		err = os.WriteFile(filepath.Join(options.Root, target), files[path], 0o644)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, target)
+	}
+	err = os.WriteFile(filepath.Join(options.Root, AppListPath), appList, 0o644)
+	if err != nil {
+		return written, err
+	}
+	return append(written, AppListPath), nil
+}
+
+// render renders every app template, keyed by its path in the app's directory.
+func render(data templateData) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := fs.WalkDir(templates, "templates/app", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		source, err := fs.ReadFile(templates, path)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(path).Delims("[[", "]]").Option("missingkey=error").Parse(string(source))
+		if err != nil {
+			return err
+		}
+		var rendered bytes.Buffer
+		err = tmpl.Execute(&rendered, data)
+		if err != nil {
+			return err
+		}
+		formatted, err := format.Source(rendered.Bytes())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		relative := strings.TrimSuffix(strings.TrimPrefix(path, "templates/app/"), ".tmpl")
+		files[filepath.FromSlash(relative)] = formatted
+		return nil
+	})
+	return files, err
+}
+
+// addRegistration adds the app to the registrations in the app list source, and imports it.
+func addRegistration(source []byte, data templateData) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Imports) == 0 {
+		return nil, errors.New("expected a parenthesised import block")
+	}
+	imports := file.Decls[0].(*ast.GenDecl)
+	if !imports.Lparen.IsValid() {
+		return nil, errors.New("expected a parenthesised import block")
+	}
+	importPath := strconv.Quote(data.Module + "/" + data.App)
+	for _, spec := range file.Imports {
+		if spec.Path.Value == importPath {
+			return nil, fmt.Errorf("%s is already imported", importPath)
+		}
+	}
+	importEnd := fset.Position(imports.Rparen).Offset
+
+	file, err = parser.ParseFile(fset, "", source, 0)
+	if err != nil {
+		return nil, err
+	}
+	list := registrationsLiteral(file)
+	if list == nil {
+		return nil, errors.New("registrations is not a composite literal")
+	}
+	listEnd := fset.Position(list.Rbrace).Offset
+
+	registration := fmt.Sprintf(
+		"{\nName: %q,\nNew: func() app.App {\n%sApp := %s.New%sApp()\nreturn &%sApp\n},\n},\n",
+		data.App, data.App, data.App, data.AppType, data.App,
+	)
+	var updated bytes.Buffer
+	updated.Write(source[:importEnd])
+	updated.WriteString("\t" + importPath + "\n")
+	updated.Write(source[importEnd:listEnd])
+	updated.WriteString(registration)
+	updated.Write(source[listEnd:])
+	return format.Source(updated.Bytes())
+}
+
+func registrationsLiteral(file *ast.File) *ast.CompositeLit {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				if name.Name != "registrations" || i >= len(value.Values) {
+					continue
+				}
+				list, _ := value.Values[i].(*ast.CompositeLit)
+				return list
+			}
+		}
+	}
+	return nil
+}
+
+// splitWords splits an identifier into its words, keeping acronyms together, e.g. "HTTPRoute" is "HTTP", "Route".
+func splitWords(identifier string) []string {
+	runes := []rune(identifier)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1])
+		acronymEnd := unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) &&
+			unicode.IsUpper(runes[i-1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,201 @@
+package generate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const appList = `package main
+
+import (
+	"lines/lines/app"
+	"lines/user"
+)
+
+var registrations = []app.Registration{
+	{
+		Name: "user",
+		New: func() app.App {
+			userApp := user.NewUserApp()
+			return &userApp
+		},
+	},
+}
+`
+
+// testRoot creates a repository root holding a go.mod and the app list.
+func testRoot(t *testing.T) string {
+	root := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module lines\n\ngo 1.22\n"), 0o644))
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "cmd"), 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(root, AppListPath), []byte(appList), 0o644))
+	return root
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		err     string
+	}{
+		{"valid", Options{App: "billing", Model: "Invoice"}, ""},
+		{"digits", Options{App: "billing2", Model: "LineItem2"}, ""},
+		{"upper case app", Options{App: "Billing", Model: "Invoice"}, `app name "Billing" must be lower case letters and digits, starting with a letter`},
+		{"dashed app", Options{App: "bill-ing", Model: "Invoice"}, `app name "bill-ing" must be lower case letters and digits, starting with a letter`},
+		{"reserved app", Options{App: "lines", Model: "Invoice"}, `app name "lines" is reserved`},
+		{"keyword app", Options{App: "func", Model: "Invoice"}, `app name "func" is reserved`},
+		{"unexported model", Options{App: "billing", Model: "invoice"}, `model name "invoice" must be an exported Go identifier, e.g. Invoice`},
+		{"package model", Options{App: "billing", Model: "Domain"}, `model name "Domain" clashes with a Go keyword or package`},
+		{"keyword model", Options{App: "billing", Model: "Type"}, `model name "Type" clashes with a Go keyword or package`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.options.Validate()
+			if test.err == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewTemplateData(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected templateData
+	}{
+		{"Invoice", templateData{
+			Module: "lines", App: "billing", AppType: "Billing", AppUpper: "BILLING", Model: "Invoice",
+			ModelVar: "invoice", ModelPluralType: "Invoices", ModelPath: "invoices", ModelWords: "invoice",
+			ModelSentence: "Invoice",
+		}},
+		{"LineItem", templateData{
+			Module: "lines", App: "billing", AppType: "Billing", AppUpper: "BILLING", Model: "LineItem",
+			ModelVar: "lineItem", ModelPluralType: "LineItems", ModelPath: "line-items", ModelWords: "line item",
+			ModelSentence: "Line item",
+		}},
+		{"VATRate", templateData{
+			Module: "lines", App: "billing", AppType: "Billing", AppUpper: "BILLING", Model: "VATRate",
+			ModelVar: "vATRate", ModelPluralType: "VATRates", ModelPath: "vat-rates", ModelWords: "vat rate",
+			ModelSentence: "Vat rate",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.model, func(t *testing.T) {
+			data := newTemplateData("lines", Options{App: "billing", Model: test.model})
+			assert.Equal(t, test.expected, data)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	files, err := render(newTemplateData("lines", Options{App: "billing", Model: "Invoice"}))
+
+	assert.Nil(t, err)
+	assert.Contains(t, files, "app.go")
+	assert.Contains(t, files, filepath.Join("domain", "interfaces.go"))
+	assert.Contains(t, files, filepath.Join("ingress", "http", "endpoints_test.go"))
+	assert.Contains(t, files, filepath.Join("stores", "services_postgres.go"))
+	assert.Contains(t, string(files["app.go"]), "func NewBillingApp() BillingApp {")
+	assert.Contains(t, string(files[filepath.Join("ingress", "http", "http.go")]), `v1 := e.Group("/v1/billing/invoices")`)
+	for path, source := range files {
+		assert.NotContains(t, string(source), "[[", path)
+	}
+}
+
+func TestAddRegistration(t *testing.T) {
+	updated, err := addRegistration([]byte(appList), newTemplateData("lines", Options{App: "billing", Model: "Invoice"}))
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(updated), "\t\"lines/billing\"\n\t\"lines/lines/app\"\n")
+	assert.True(t, strings.HasSuffix(string(updated), `	{
+		Name: "billing",
+		New: func() app.App {
+			billingApp := billing.NewBillingApp()
+			return &billingApp
+		},
+	},
+}
+`))
+}
+
+func TestAddRegistration_Errors(t *testing.T) {
+	data := newTemplateData("lines", Options{App: "user", Model: "User"})
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{"already imported", appList, `"lines/user" is already imported`},
+		{"no import block", "package main\n\nimport \"lines/lines/app\"\n\nvar registrations = []app.Registration{}\n", "expected a parenthesised import block"},
+		{"no registrations", "package main\n\nimport (\n\t\"lines/lines/app\"\n)\n\nvar apps []app.Registration\n", "registrations is not a composite literal"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := addRegistration([]byte(test.source), data)
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestNewApp(t *testing.T) {
+	root := testRoot(t)
+
+	written, err := NewApp(Options{Root: root, App: "billing"})
+
+	assert.Nil(t, err)
+	assert.Contains(t, written, filepath.Join("billing", "app.go"))
+	assert.Equal(t, AppListPath, written[len(written)-1])
+	model, err := os.ReadFile(filepath.Join(root, "billing", "stores", "models_postgres.go"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(model), "type Billing struct {")
+	list, err := os.ReadFile(filepath.Join(root, AppListPath))
+	assert.Nil(t, err)
+	assert.Contains(t, string(list), `Name: "billing"`)
+}
+
+func TestNewApp_RefusesToOverwrite(t *testing.T) {
+	root := testRoot(t)
+	assert.Nil(t, os.Mkdir(filepath.Join(root, "billing"), 0o755))
+
+	_, err := NewApp(Options{Root: root, App: "billing", Model: "Invoice"})
+
+	assert.EqualError(t, err, filepath.Join(root, "billing")+" already exists")
+	list, err := os.ReadFile(filepath.Join(root, AppListPath))
+	assert.Nil(t, err)
+	assert.Equal(t, appList, string(list))
+}
+
+// TestNewApp_Compiles generates an app next to a copy of this repository and builds and vets the result.
+func TestNewApp_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a generated app")
+	}
+	repo, err := filepath.Abs(filepath.Join("..", ".."))
+	assert.Nil(t, err)
+	root := t.TempDir()
+	for _, file := range []string{"go.mod", "go.sum", AppListPath} {
+		source, err := os.ReadFile(filepath.Join(repo, file))
+		assert.Nil(t, err)
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, filepath.Dir(file)), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(root, file), source, 0o644))
+	}
+	for _, dir := range []string{"lines", "user", "internal"} {
+		assert.Nil(t, os.Symlink(filepath.Join(repo, dir), filepath.Join(root, dir)))
+	}
+
+	_, err = NewApp(Options{Root: root, App: "billing", Model: "LineItem"})
+	assert.Nil(t, err)
+
+	for _, args := range [][]string{{"build", "./billing/..."}, {"vet", "./billing/...", "./cmd"}} {
+		command := exec.Command("go", args...)
+		command.Dir = root
+		output, err := command.CombinedOutput()
+		assert.Nil(t, err, string(output))
+	}
+}
@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"lines/internal"
+	"testing"
+)
+
+func TestServiceTokenAuthenticator(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expected      codes.Code
+	}{
+		{"accepted", "secret", "Bearer secret", codes.OK},
+		{"wrong token", "secret", "Bearer other", codes.Unauthenticated},
+		{"not bearer", "secret", "secret", codes.Unauthenticated},
+		{"missing", "secret", "", codes.Unauthenticated},
+		{"no token configured", "", "Bearer ", codes.Unauthenticated},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationMetadata, test.authorization))
+			}
+
+			_, err := NewServiceTokenAuthenticator(test.token).Authenticate(ctx, "/test.Ping/Ping")
+
+			assert.Equal(t, test.expected, status.Code(err))
+		})
+	}
+}
+
+func TestServiceTokenCredentials(t *testing.T) {
+	credentials := NewServiceTokenCredentials("secret", true)
+
+	md, err := credentials.GetRequestMetadata(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{AuthorizationMetadata: "Bearer secret"}, md)
+	assert.True(t, credentials.RequireTransportSecurity())
+}
+
+func TestServer_ServiceToken(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.UseAuthenticator(NewServiceTokenAuthenticator("secret"))
+	})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(ping(conn, context.Background())))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), AuthorizationMetadata, "Bearer secret")
+	assert.Nil(t, ping(conn, ctx))
+}
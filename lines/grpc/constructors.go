@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"lines/internal"
+	"strconv"
+)
+
+// CreateServer creates a new gRPC server listening on the configured gRPC port.
+// It installs the shared request ID, recovery, logging and auth interceptors, registers the standard health service
+// and, in local dev, server reflection. The request ID comes first, so a recovered panic is logged with it.
+func CreateServer(config *internal.MainConfig) *Server {
+	s := &Server{
+		addr:   ":" + strconv.Itoa(config.GRPCPort),
+		logger: config.Logger,
+		health: health.NewServer(),
+		public: map[string]bool{},
+	}
+	s.Server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			RequestIDUnaryInterceptor(),
+			RecoveryUnaryInterceptor(config.Logger),
+			LoggingUnaryInterceptor(config.Logger),
+			s.authUnaryInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			RequestIDStreamInterceptor(),
+			RecoveryStreamInterceptor(config.Logger),
+			LoggingStreamInterceptor(config.Logger),
+			s.authStreamInterceptor,
+		),
+	)
+	grpc_health_v1.RegisterHealthServer(s.Server, s.health)
+	s.AllowUnauthenticated(
+		grpc_health_v1.Health_Check_FullMethodName,
+		grpc_health_v1.Health_Watch_FullMethodName,
+	)
+	if config.LocalDev {
+		reflection.Register(s.Server)
+		s.AllowUnauthenticated(
+			"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo",
+			"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo",
+		)
+	}
+	return s
+}
@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"lines/lines/logging"
+	"runtime/debug"
+	"time"
+)
+
+// RecoveryUnaryInterceptor turns a panicking handler into an Internal error, so it doesn't take the server down. The
+// panic is logged with the call's request ID, if an earlier interceptor put one in its context.
+func RecoveryUnaryInterceptor(logger logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logging.FromContext(ctx, logger), info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor turns a panicking stream handler into an Internal error.
+func RecoveryStreamInterceptor(logger logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(logging.FromContext(ss.Context(), logger), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(logger logging.Logger, fullMethod string, r any) error {
+	logger.Error("grpc", fullMethod, fmt.Sprintf("Recovered from panic: %v\n%s", r, debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+// RequestIDMetadata is the metadata key calls carry their request ID in, it's echoed in the response headers.
+const RequestIDMetadata = "x-request-id"
+
+// RequestIDUnaryInterceptor puts the call's request ID in its context, it's taken from the call's metadata so a
+// request's logs can be followed across deployments, or generated.
+func RequestIDUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := incomingRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+		return handler(logging.WithRequestID(ctx, requestID), req)
+	}
+}
+
+// RequestIDStreamInterceptor puts the stream's request ID in its context.
+func RequestIDStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		requestID := incomingRequestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDMetadata, requestID))
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: logging.WithRequestID(ss.Context(), requestID)})
+	}
+}
+
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(RequestIDMetadata); len(ids) > 0 && logging.ValidRequestID(ids[0]) {
+		return ids[0]
+	}
+	return logging.NewRequestID()
+}
+
+// RequestIDClientInterceptor sends the request ID of the call's context with it, clients of other deployments use it
+// so the calls are logged under the request they were made for.
+func RequestIDClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if requestID := logging.RequestID(ctx); requestID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, requestID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// LoggingUnaryInterceptor logs the method, status code and duration of every call.
+func LoggingUnaryInterceptor(logger logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(logging.FromContext(ctx, logger), info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor logs the method, status code and duration of every stream.
+func LoggingStreamInterceptor(logger logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(logging.FromContext(ss.Context(), logger), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(logger logging.Logger, fullMethod string, start time.Time, err error) {
+	code := status.Code(err)
+	message := fmt.Sprintf("%s %s %s", fullMethod, code.String(), time.Since(start))
+	switch code {
+	case codes.OK, codes.NotFound, codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied:
+		logger.Info("grpc", "logCall", message)
+	default:
+		logger.Error("grpc", "logCall", message)
+	}
+}
+
+// authUnaryInterceptor authenticates calls with the server's Authenticator, unless the method is public. Without an
+// Authenticator only public methods can be called.
+func (s *Server) authUnaryInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authStreamInterceptor authenticates streams with the server's Authenticator, unless the method is public.
+func (s *Server) authStreamInterceptor(
+	srv any,
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (s *Server) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	if s.public[fullMethod] {
+		return ctx, nil
+	}
+	if s.authenticator == nil {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorised")
+	}
+	return s.authenticator.Authenticate(ctx, fullMethod)
+}
+
+// wrappedStream is a ServerStream carrying the context returned by an interceptor.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// GrpcServer is the gRPC server the monolith runs alongside the HTTP engine. Apps register their services on it.
+type GrpcServer interface {
+	grpc.ServiceRegistrar
+	// UseAuthenticator sets how incoming calls are authenticated. Without one, only public methods can be
+	// called.
+	UseAuthenticator(authenticator Authenticator)
+	// AllowUnauthenticated lets calls to the given full method names, e.g. "/user.v1.UserService/ValidateToken",
+	// skip authentication.
+	AllowUnauthenticated(fullMethods ...string)
+	ListenAndServe() error
+	// Shutdown stops accepting calls and waits for in-flight calls to finish, cancelling them if ctx expires.
+	Shutdown(ctx context.Context) error
+}
+
+// Authenticator checks the credentials in an incoming call's metadata.
+// It returns the context the handler should run with, e.g. carrying the authenticated user, or an error
+// with an Unauthenticated status.
+type Authenticator interface {
+	Authenticate(ctx context.Context, fullMethod string) (context.Context, error)
+}
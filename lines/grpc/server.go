@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"lines/lines/logging"
+	"net"
+)
+
+// Server is the GrpcServer implementation, wrapping a grpc.Server.
+type Server struct {
+	*grpc.Server
+	addr          string
+	logger        logging.Logger
+	health        *health.Server
+	authenticator Authenticator
+	public        map[string]bool
+}
+
+func (s *Server) UseAuthenticator(authenticator Authenticator) {
+	s.authenticator = authenticator
+}
+
+func (s *Server) AllowUnauthenticated(fullMethods ...string) {
+	for _, method := range fullMethods {
+		s.public[method] = true
+	}
+}
+
+// ListenAndServe listens on the server's address and serves calls until the server is shut down.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves calls on the listener, reporting the server as healthy while it does.
+func (s *Server) Serve(listener net.Listener) error {
+	s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	return s.Server.Serve(listener)
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,440 @@
+package grpc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"lines/internal"
+	"lines/lines/logging"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockLogger struct {
+	logging.Logger
+	mu         sync.Mutex
+	InfoCalls  int
+	ErrorCalls int
+	Fields     map[string]string
+}
+
+func (m *mockLogger) Info(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InfoCalls++
+}
+
+func (m *mockLogger) Error(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorCalls++
+}
+
+func (m *mockLogger) With(key string, value string) logging.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Fields == nil {
+		m.Fields = map[string]string{}
+	}
+	m.Fields[key] = value
+	return m
+}
+
+// pingServer is a hand-written service, reusing the health messages, for exercising the interceptors.
+type pingServer struct {
+	panics bool
+}
+
+func (p *pingServer) Ping(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if p.panics {
+		panic("boom")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if user, ok := ctx.Value(userKey{}).(string); ok {
+		return &grpc_health_v1.HealthCheckResponse{}, status.Error(codes.AlreadyExists, user+md.Get("authorization")[0])
+	}
+	return &grpc_health_v1.HealthCheckResponse{}, nil
+}
+
+var pingServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Ping",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Ping",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := &grpc_health_v1.HealthCheckRequest{}
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Ping/Ping"}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return srv.(*pingServer).Ping(ctx, req.(*grpc_health_v1.HealthCheckRequest))
+			})
+		},
+	}},
+}
+
+type userKey struct{}
+
+type mockAuthenticator struct{}
+
+func (m *mockAuthenticator) Authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("authorization")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorised")
+	}
+	return context.WithValue(ctx, userKey{}, "user:"), nil
+}
+
+func startTestServer(t *testing.T, config *internal.MainConfig, setup func(s *Server)) *grpc.ClientConn {
+	server := CreateServer(config)
+	if setup != nil {
+		setup(server)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown(context.Background())
+	})
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func ping(conn *grpc.ClientConn, ctx context.Context) error {
+	return conn.Invoke(ctx, "/test.Ping/Ping", &grpc_health_v1.HealthCheckRequest{}, &grpc_health_v1.HealthCheckResponse{})
+}
+
+func TestCreateServer(t *testing.T) {
+	server := CreateServer(&internal.MainConfig{GRPCPort: 9090, Logger: &mockLogger{}})
+	assert.Equal(t, ":9090", server.addr)
+	assert.NotNil(t, server.Server)
+	_, registered := server.GetServiceInfo()["grpc.health.v1.Health"]
+	assert.True(t, registered)
+	_, reflection := server.GetServiceInfo()["grpc.reflection.v1.ServerReflection"]
+	assert.False(t, reflection)
+}
+
+func TestCreateServer_LocalDevRegistersReflection(t *testing.T) {
+	server := CreateServer(&internal.MainConfig{LocalDev: true, Logger: &mockLogger{}})
+	_, reflection := server.GetServiceInfo()["grpc.reflection.v1.ServerReflection"]
+	assert.True(t, reflection)
+}
+
+func TestServer_HealthCheck(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.UseAuthenticator(&mockAuthenticator{})
+	})
+
+	response, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+}
+
+func TestServer_Reflection_LocalDev(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{LocalDev: true, Logger: &mockLogger{}}, func(s *Server) {
+		s.UseAuthenticator(&mockAuthenticator{})
+	})
+
+	stream, err := grpc_reflection_v1.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	assert.Nil(t, err)
+	err = stream.Send(&grpc_reflection_v1.ServerReflectionRequest{
+		MessageRequest: &grpc_reflection_v1.ServerReflectionRequest_ListServices{},
+	})
+	assert.Nil(t, err)
+	response, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.GetListServicesResponse().Service)
+}
+
+func TestServer_NoAuthenticator_RejectsCalls(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+	})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "token")
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(ping(conn, ctx)))
+}
+
+func TestServer_NoAuthenticator_AllowsPublicCalls(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.AllowUnauthenticated("/test.Ping/Ping")
+	})
+
+	assert.Nil(t, ping(conn, context.Background()))
+}
+
+func TestServer_Authenticator_RejectsCalls(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.UseAuthenticator(&mockAuthenticator{})
+	})
+
+	err := ping(conn, context.Background())
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_Authenticator_PassesContextToHandler(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.UseAuthenticator(&mockAuthenticator{})
+	})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "token")
+
+	err := ping(conn, ctx)
+
+	assert.Equal(t, "user:token", status.Convert(err).Message())
+}
+
+func TestServer_AllowUnauthenticated(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.UseAuthenticator(&mockAuthenticator{})
+		s.AllowUnauthenticated("/test.Ping/Ping")
+	})
+
+	assert.Nil(t, ping(conn, context.Background()))
+}
+
+func TestServer_RecoversFromPanics(t *testing.T) {
+	logger := &mockLogger{}
+	conn := startTestServer(t, &internal.MainConfig{Logger: logger}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{panics: true})
+		s.AllowUnauthenticated("/test.Ping/Ping")
+	})
+
+	err := ping(conn, context.Background())
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, codes.Internal, status.Code(ping(conn, context.Background())))
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	assert.GreaterOrEqual(t, logger.ErrorCalls, 2)
+}
+
+func TestRecoveryInterceptors_LogRequestID(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "abc")
+	logger := &mockLogger{}
+
+	_, err := RecoveryUnaryInterceptor(logger)(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "abc", logger.Fields[logging.RequestIDField])
+
+	logger = &mockLogger{}
+	stream := &contextStream{ctx: ctx}
+	err = RecoveryStreamInterceptor(logger)(nil, stream, &grpc.StreamServerInfo{}, func(srv any, ss grpc.ServerStream) error {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "abc", logger.Fields[logging.RequestIDField])
+}
+
+func TestServer_ShutdownDeadline(t *testing.T) {
+	server := CreateServer(&internal.MainConfig{Logger: &mockLogger{}})
+	listener := bufconn.Listen(1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.Nil(t, err)
+	defer conn.Close()
+	// An open watch stream keeps GracefulStop waiting.
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestServer_ListenAndServe_InvalidAddress(t *testing.T) {
+	server := CreateServer(&internal.MainConfig{GRPCPort: -1, Logger: &mockLogger{}})
+	assert.NotNil(t, server.ListenAndServe())
+}
+
+func TestLoggingUnaryInterceptor(t *testing.T) {
+	logger := &mockLogger{}
+	interceptor := LoggingUnaryInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Ping/Ping"}
+
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	})
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.Unavailable, "unavailable")
+	})
+
+	assert.Equal(t, 1, logger.InfoCalls)
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+}
+
+func (m *mockServerStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	logger := &mockLogger{}
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Ping/Stream"}
+
+	err := RecoveryStreamInterceptor(logger)(nil, &mockServerStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		panic("boom")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	err = LoggingStreamInterceptor(logger)(nil, &mockServerStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, logger.InfoCalls)
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+func TestServer_AuthStreamInterceptor(t *testing.T) {
+	server := CreateServer(&internal.MainConfig{Logger: &mockLogger{}})
+	server.UseAuthenticator(&mockAuthenticator{})
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Ping/Stream"}
+
+	err := server.authStreamInterceptor(nil, &mockServerStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		t.Error("Handler was called without authentication")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "token"))
+	err = server.authStreamInterceptor(nil, &contextStream{ctx: ctx}, info, func(srv any, stream grpc.ServerStream) error {
+		assert.Equal(t, "user:", stream.Context().Value(userKey{}))
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (c *contextStream) Context() context.Context {
+	return c.ctx
+}
+
+func TestServer_RequestID(t *testing.T) {
+	conn := startTestServer(t, &internal.MainConfig{Logger: &mockLogger{}}, func(s *Server) {
+		s.RegisterService(&pingServiceDesc, &pingServer{})
+		s.AllowUnauthenticated("/test.Ping/Ping")
+	})
+	tests := []struct {
+		name     string
+		sent     string
+		expected string
+	}{
+		{"accepted", "abc-123", "abc-123"},
+		{"generated", "", ""},
+		{"malformed", "abc def", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.sent != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, test.sent)
+			}
+			var header metadata.MD
+			err := conn.Invoke(ctx, "/test.Ping/Ping", &grpc_health_v1.HealthCheckRequest{}, &grpc_health_v1.HealthCheckResponse{}, grpc.Header(&header))
+
+			assert.Nil(t, err)
+			echoed := header.Get(RequestIDMetadata)
+			assert.Len(t, echoed, 1)
+			if test.expected != "" {
+				assert.Equal(t, test.expected, echoed[0])
+			} else {
+				assert.Len(t, echoed[0], 32)
+			}
+		})
+	}
+}
+
+func TestRequestIDStreamInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDMetadata, "abc"))
+	stream := &headerStream{contextStream: contextStream{ctx: ctx}}
+
+	err := RequestIDStreamInterceptor()(nil, stream, &grpc.StreamServerInfo{}, func(srv any, ss grpc.ServerStream) error {
+		assert.Equal(t, "abc", logging.RequestID(ss.Context()))
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"abc"}, stream.header.Get(RequestIDMetadata))
+}
+
+type headerStream struct {
+	contextStream
+	header metadata.MD
+}
+
+func (h *headerStream) SetHeader(md metadata.MD) error {
+	h.header = metadata.Join(h.header, md)
+	return nil
+}
+
+func TestRequestIDClientInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected []string
+	}{
+		{"propagated", logging.WithRequestID(context.Background(), "abc"), []string{"abc"}},
+		{"outside a request", context.Background(), nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sent metadata.MD
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				sent, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			}
+
+			err := RequestIDClientInterceptor()(test.ctx, "/test.Ping/Ping", nil, nil, nil, invoker)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, sent.Get(RequestIDMetadata))
+		})
+	}
+}
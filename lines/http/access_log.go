@@ -0,0 +1,84 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// AccessLogConfig configures the access log.
+type AccessLogConfig struct {
+	// ExcludedPaths aren't logged unless they fail or are slow, e.g. health checks. They're matched against the
+	// request's path and its route.
+	ExcludedPaths []string
+	// SamplePercent is the percentage of requests logged. Failed and slow requests are always logged.
+	SamplePercent int
+	// SlowThreshold is how long a request can take before it's logged as a warning, it's off if it's 0.
+	SlowThreshold time.Duration
+}
+
+// NewAccessLogConfig reads the access log's settings from the environment.
+func NewAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{
+		ExcludedPaths: utils.GetEnvOrDefault("ACCESS_LOG_EXCLUDED_PATHS", "", "[]string").([]string),
+		SamplePercent: utils.GetEnvOrDefault("ACCESS_LOG_SAMPLE_PERCENT", "100", "int").(int),
+		SlowThreshold: time.Duration(utils.GetEnvOrDefault("ACCESS_LOG_SLOW_MS", "1000", "int").(int)) * time.Millisecond,
+	}
+}
+
+// AccessLog is middleware that logs every request once it's handled, with its method, route, status, latency,
+// response size, client IP, user ID and request ID. Server errors are logged as errors and slow requests as warnings.
+// It has to come after RequestID for the request ID to be logged, and sees the user ID RequireAuth and OptionalAuth
+// set further down the chain.
+func AccessLog(logger logging.Logger, config AccessLogConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+		latency := time.Since(start)
+
+		status := c.Writer.Status()
+		failed := status >= http.StatusInternalServerError
+		slow := config.SlowThreshold > 0 && latency >= config.SlowThreshold
+		if !failed && !slow {
+			if slices.Contains(config.ExcludedPaths, path) || slices.Contains(config.ExcludedPaths, c.FullPath()) {
+				return
+			}
+			if config.SamplePercent < 100 && rand.IntN(100) >= config.SamplePercent {
+				return
+			}
+		}
+
+		route := c.FullPath()
+		entry := RequestLogger(c, logger).
+			With("method", c.Request.Method).
+			With("route", route).
+			With("path", path).
+			With("status", strconv.Itoa(status)).
+			With("latency_ms", strconv.FormatInt(latency.Milliseconds(), 10)).
+			With("bytes", strconv.Itoa(max(c.Writer.Size(), 0))).
+			With("client_ip", c.ClientIP())
+		if userID := CurrentUserID(c); userID != "" {
+			entry = entry.With("user_id", userID)
+		}
+		if route == "" {
+			// No route matched, the path is all there is to go on.
+			route = path
+		}
+		message := fmt.Sprintf("%s %s %d in %s", c.Request.Method, route, status, latency)
+		switch {
+		case failed:
+			entry.Error("http", "AccessLog", message)
+		case slow:
+			entry.Warn("http", "AccessLog", message)
+		default:
+			entry.Info("http", "AccessLog", message)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// accessLogEngine serves routes through the access log, returning the entries it logs.
+func accessLogEngine(t *testing.T, config AccessLogConfig) (*gin.Engine, func() []map[string]any) {
+	var buf bytes.Buffer
+	logger := logging.NewLogrusHandler("info")
+	logger.Logrus.Out = &buf
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestID(), AccessLog(logger, config))
+	authenticated := &mockAuthenticator{
+		authentication: &Authentication[testClaims, *testUser]{User: &testUser{ID: 7}, UserID: "7"},
+	}
+	engine.GET("/access/items/:id", RequireAuth[testClaims, *testUser](authenticated), func(c *gin.Context) {
+		c.String(http.StatusOK, "item")
+	})
+	engine.GET("/access/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/access/failing", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+	engine.GET("/access/slow", func(c *gin.Context) {
+		time.Sleep(20 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	return engine, func() []map[string]any {
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := map[string]any{}
+			assert.Nil(t, json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestNewAccessLogConfig(t *testing.T) {
+	t.Setenv("ACCESS_LOG_EXCLUDED_PATHS", "/healthz,/readyz")
+	t.Setenv("ACCESS_LOG_SAMPLE_PERCENT", "10")
+
+	config := NewAccessLogConfig()
+
+	assert.Equal(t, []string{"/healthz", "/readyz"}, config.ExcludedPaths)
+	assert.Equal(t, 10, config.SamplePercent)
+	assert.Equal(t, time.Second, config.SlowThreshold)
+}
+
+func TestAccessLog_LogsRequests(t *testing.T) {
+	engine, entries := accessLogEngine(t, AccessLogConfig{SamplePercent: 100})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/access/items/42", nil)
+	req.Header.Set(RequestIDHeader, "request-1")
+	req.RemoteAddr = "192.0.2.1:1234"
+	engine.ServeHTTP(rr, req)
+
+	logged := entries()
+	assert.Len(t, logged, 1)
+	entry := logged[0]
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "AccessLog", entry["caller"])
+	assert.Regexp(t, `^GET /access/items/:id 200 in .+`, entry["message"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/access/items/:id", entry["route"])
+	assert.Equal(t, "/access/items/42", entry["path"])
+	assert.Equal(t, "200", entry["status"])
+	assert.Equal(t, "4", entry["bytes"])
+	assert.Equal(t, "192.0.2.1", entry["client_ip"])
+	assert.Equal(t, "7", entry["user_id"])
+	assert.Equal(t, "request-1", entry[logging.RequestIDField])
+	assert.Contains(t, entry, "latency_ms")
+}
+
+func TestAccessLog_UnmatchedRoute(t *testing.T) {
+	engine, entries := accessLogEngine(t, AccessLogConfig{SamplePercent: 100})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/missing", nil))
+
+	entry := entries()[0]
+	assert.Regexp(t, `^GET /access/missing 404 in .+`, entry["message"])
+	assert.Equal(t, "", entry["route"])
+	assert.NotContains(t, entry, "user_id")
+}
+
+func TestAccessLog_ExcludesPathsUnlessFailing(t *testing.T) {
+	engine, entries := accessLogEngine(t, AccessLogConfig{
+		ExcludedPaths: []string{"/access/healthz", "/access/failing"},
+		SamplePercent: 100,
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/healthz", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/failing", nil))
+
+	logged := entries()
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "error", logged[0]["level"])
+	assert.Equal(t, "503", logged[0]["status"])
+}
+
+func TestAccessLog_Samples(t *testing.T) {
+	engine, entries := accessLogEngine(t, AccessLogConfig{SamplePercent: 0})
+
+	for i := 0; i < 10; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/healthz", nil))
+	}
+	// Failed requests are logged whatever the sample.
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/failing", nil))
+
+	logged := entries()
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "/access/failing", logged[0]["route"])
+}
+
+func TestAccessLog_WarnsOfSlowRequests(t *testing.T) {
+	engine, entries := accessLogEngine(t, AccessLogConfig{SamplePercent: 0, SlowThreshold: 10 * time.Millisecond})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/slow", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/access/healthz", nil))
+
+	logged := entries()
+	assert.Len(t, logged, 1)
+	assert.Equal(t, "warning", logged[0]["level"])
+	assert.Equal(t, "/access/slow", logged[0]["route"])
+}
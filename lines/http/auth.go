@@ -0,0 +1,98 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	claimsKey = "lines.auth.claims"
+	userKey   = "lines.auth.user"
+	userIDKey = "lines.auth.user_id"
+)
+
+// Authentication is who a request was made by. C is the type of the credentials' claims, e.g. the claims of a JWT,
+// and U the type of the user they resolve to.
+type Authentication[C any, U any] struct {
+	Claims C
+	User   U
+	// UserID identifies the user in logs, like the access log, where the user's type isn't known.
+	UserID string
+}
+
+// Authenticator authenticates requests, the user app provides the JWT one.
+type Authenticator[C any, U any] interface {
+	// Authenticate returns a problem, usually a 401, if the request isn't authenticated, and an error if it couldn't
+	// tell.
+	Authenticate(r *http.Request) (*Problem, *Authentication[C, U], error)
+}
+
+// RequireAuth is middleware that only lets authenticated requests through. The request's claims and user are
+// available to the handlers after it from CurrentClaims and CurrentUser.
+func RequireAuth[C any, U any](authenticator Authenticator[C, U]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		problem, authentication, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			AbortWithProblem(c, Internal("Could not authenticate request."))
+			return
+		}
+		if problem != nil {
+			AbortWithProblem(c, problem)
+			return
+		}
+		setAuthentication(c, authentication)
+		c.Next()
+	}
+}
+
+// OptionalAuth is RequireAuth for public endpoints, requests that aren't authenticated are let through anonymously.
+// Handlers tell them apart with CurrentUser's ok.
+func OptionalAuth[C any, U any](authenticator Authenticator[C, U]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		problem, authentication, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			AbortWithProblem(c, Internal("Could not authenticate request."))
+			return
+		}
+		if problem == nil {
+			setAuthentication(c, authentication)
+		}
+		c.Next()
+	}
+}
+
+func setAuthentication[C any, U any](c *gin.Context, authentication *Authentication[C, U]) {
+	c.Set(claimsKey, authentication.Claims)
+	c.Set(userKey, authentication.User)
+	if authentication.UserID != "" {
+		c.Set(userIDKey, authentication.UserID)
+	}
+}
+
+// CurrentClaims returns the claims RequireAuth or OptionalAuth authenticated the request with. ok is false if the
+// request wasn't authenticated, or was authenticated with claims of another type.
+func CurrentClaims[C any](c *gin.Context) (claims C, ok bool) {
+	value, exists := c.Get(claimsKey)
+	if !exists {
+		return claims, false
+	}
+	claims, ok = value.(C)
+	return claims, ok
+}
+
+// CurrentUser returns the user RequireAuth or OptionalAuth authenticated the request as. ok is false if the request
+// wasn't authenticated, or was authenticated as a user of another type.
+func CurrentUser[U any](c *gin.Context) (user U, ok bool) {
+	value, exists := c.Get(userKey)
+	if !exists {
+		return user, false
+	}
+	user, ok = value.(U)
+	return user, ok
+}
+
+// CurrentUserID returns the ID of the user RequireAuth or OptionalAuth authenticated the request as, or "" if the
+// request wasn't authenticated or its authenticator didn't set a UserID.
+func CurrentUserID(c *gin.Context) string {
+	return c.GetString(userIDKey)
+}
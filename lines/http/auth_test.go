@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testClaims struct {
+	Subject string
+}
+
+type testUser struct {
+	ID uint
+}
+
+type mockAuthenticator struct {
+	problem        *Problem
+	authentication *Authentication[testClaims, *testUser]
+	err            error
+}
+
+func (m *mockAuthenticator) Authenticate(r *http.Request) (*Problem, *Authentication[testClaims, *testUser], error) {
+	return m.problem, m.authentication, m.err
+}
+
+type currentAuth struct {
+	Claims     *testClaims `json:"claims"`
+	User       *testUser   `json:"user"`
+	WrongClaim bool        `json:"wrong_claim"`
+	UserID     string      `json:"user_id,omitempty"`
+}
+
+// serveAuth serves a request through the middleware to a handler that responds with what it was authenticated as.
+func serveAuth(middleware gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", middleware, func(c *gin.Context) {
+		response := currentAuth{}
+		if claims, ok := CurrentClaims[testClaims](c); ok {
+			response.Claims = &claims
+		}
+		if user, ok := CurrentUser[*testUser](c); ok {
+			response.User = user
+		}
+		_, response.WrongClaim = CurrentClaims[string](c)
+		response.UserID = CurrentUserID(c)
+		c.JSON(http.StatusOK, response)
+	})
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	return rr
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticated := &mockAuthenticator{
+		authentication: &Authentication[testClaims, *testUser]{
+			Claims: testClaims{Subject: "1"},
+			User:   &testUser{ID: 1},
+			UserID: "1",
+		},
+	}
+	unauthenticated := &mockAuthenticator{problem: Unauthorised("Bearer token invalid")}
+	failing := &mockAuthenticator{err: assert.AnError}
+
+	tests := []struct {
+		name       string
+		middleware gin.HandlerFunc
+		status     int
+		body       any
+	}{
+		{
+			"required, authenticated",
+			RequireAuth[testClaims, *testUser](authenticated),
+			http.StatusOK,
+			currentAuth{Claims: &testClaims{Subject: "1"}, User: &testUser{ID: 1}, UserID: "1"},
+		},
+		{
+			"required, unauthenticated",
+			RequireAuth[testClaims, *testUser](unauthenticated),
+			http.StatusUnauthorized,
+			Unauthorised("Bearer token invalid"),
+		},
+		{
+			"required, failing",
+			RequireAuth[testClaims, *testUser](failing),
+			http.StatusInternalServerError,
+			Internal("Could not authenticate request."),
+		},
+		{
+			"optional, authenticated",
+			OptionalAuth[testClaims, *testUser](authenticated),
+			http.StatusOK,
+			currentAuth{Claims: &testClaims{Subject: "1"}, User: &testUser{ID: 1}, UserID: "1"},
+		},
+		{
+			"optional, unauthenticated",
+			OptionalAuth[testClaims, *testUser](unauthenticated),
+			http.StatusOK,
+			currentAuth{},
+		},
+		{
+			"optional, failing",
+			OptionalAuth[testClaims, *testUser](failing),
+			http.StatusInternalServerError,
+			Internal("Could not authenticate request."),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := serveAuth(test.middleware)
+
+			assert.Equal(t, test.status, rr.Code)
+			expected, err := json.Marshal(test.body)
+			assert.Nil(t, err)
+			assert.JSONEq(t, string(expected), rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// CodeRequestTooLarge is the problem code of requests with bodies over their route's size limit.
+const CodeRequestTooLarge = "request_too_large"
+
+const bodySizeLimitKey = "lines.bodySizeLimit"
+
+// MaxBodySize is middleware that limits request bodies to limit bytes, or the limit LimitBodySize set for their
+// route's group. There's no limit if it's 0. The limit is applied when the body is first read, reading a body that
+// says it's larger fails straight away, and reading past the limit of a body that doesn't say fails. BindProblem
+// turns the failure into a 413 problem.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(bodySizeLimitKey, limit)
+		if c.Request.Body != nil {
+			c.Request.Body = &limitedBody{c: c, body: c.Request.Body}
+		}
+		c.Next()
+	}
+}
+
+// LimitBodySize is middleware for a route group that changes the body size limit MaxBodySize set, e.g. to accept
+// larger bodies on a group of upload routes. A limit of 0 takes the limit off. The most specific group's limit wins.
+//
+//	uploads := engine.Group("/uploads", LimitBodySize(100<<20))
+func LimitBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(bodySizeLimitKey, limit)
+		c.Next()
+	}
+}
+
+// BindProblem is the problem for an error binding a request's body, a 413 if the body is over its limit and a 400
+// otherwise.
+func BindProblem(err error) *Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return NewProblem(
+			http.StatusRequestEntityTooLarge,
+			CodeRequestTooLarge,
+			fmt.Sprintf("The request body can't be larger than %d bytes.", tooLarge.Limit),
+		)
+	}
+	return BadRequest(err.Error())
+}
+
+// limitedBody reads a request body with the limit of the request's route, which is only known once every group's
+// middleware has run.
+type limitedBody struct {
+	c      *gin.Context
+	body   io.ReadCloser
+	reader io.Reader
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		limit := b.c.GetInt64(bodySizeLimitKey)
+		switch {
+		case limit <= 0:
+			b.reader = b.body
+		case b.c.Request.ContentLength > limit:
+			return 0, &http.MaxBytesError{Limit: limit}
+		default:
+			b.reader = http.MaxBytesReader(b.c.Writer, b.body, limit)
+		}
+	}
+	return b.reader.Read(p)
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
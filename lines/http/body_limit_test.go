@@ -0,0 +1,77 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newBodyLimitEngine(limit int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(MaxBodySize(limit))
+	return engine
+}
+
+func echoBody(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		AbortWithProblem(c, BindProblem(err))
+		return
+	}
+	c.String(http.StatusOK, string(body))
+}
+
+func postBody(engine *gin.Engine, path string, body string, chunked bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if chunked {
+		req.ContentLength = -1
+	}
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMaxBodySize(t *testing.T) {
+	engine := newBodyLimitEngine(4)
+	engine.POST("/small", echoBody)
+	engine.POST("/body/uploadsmall", echoBody)
+	uploads := engine.Group("/body/uploads", LimitBodySize(16))
+	uploads.POST("/:name", echoBody)
+	unlimited := uploads.Group("/unlimited", LimitBodySize(0))
+	unlimited.POST("", echoBody)
+
+	assert.Equal(t, "1234", postBody(engine, "/small", "1234", false).Body.String())
+	rr := postBody(engine, "/small", "12345", false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), CodeRequestTooLarge)
+	// A body that doesn't say how large it is fails once it's read past the limit.
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postBody(engine, "/small", "12345", true).Code)
+
+	assert.Equal(t, "0123456789", postBody(engine, "/body/uploads/a", "0123456789", false).Body.String())
+	assert.Equal(t, "0123456789", postBody(engine, "/body/uploads/a", "0123456789", true).Body.String())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postBody(engine, "/body/uploads/a", strings.Repeat("1", 17), false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postBody(engine, "/body/uploadsmall", "12345", false).Code)
+	assert.Equal(t, strings.Repeat("1", 17), postBody(engine, "/body/uploads/unlimited", strings.Repeat("1", 17), false).Body.String())
+}
+
+func TestLimitBodySize_OnlyAppliesToItsEngine(t *testing.T) {
+	uploads := newBodyLimitEngine(4)
+	uploads.Group("/uploads", LimitBodySize(16)).POST("", echoBody)
+	other := newBodyLimitEngine(4)
+	other.POST("/uploads", echoBody)
+
+	assert.Equal(t, http.StatusOK, postBody(uploads, "/uploads", "0123456789", false).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, postBody(other, "/uploads", "0123456789", false).Code)
+}
+
+func TestBindProblem(t *testing.T) {
+	assert.Equal(t, BadRequest(assert.AnError.Error()), BindProblem(assert.AnError))
+	problem := BindProblem(&http.MaxBytesError{Limit: 4})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, problem.Status)
+	assert.Equal(t, CodeRequestTooLarge, problem.Code)
+}
@@ -4,14 +4,47 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"lines/internal"
+	"lines/lines/reporting"
+	"net/http"
 )
 
-// CreateEngine creates a new gin engine and sorts CORS out.
+// CreateEngine creates a new gin engine and sorts CORS out. Every request gets an ID and is logged to the config's
+// Logger by AccessLog, panics are recovered and reported to the config's ErrorReporter, responses carry the config's
+// security headers, request bodies are limited to HTTP_MAX_BODY_BYTES, cookie-authenticated requests are protected
+// from CSRF, and requests no route matches get a problem response. The engine serves its OpenAPI document and docs.
+// Client IPs are only taken from X-Forwarded-For on requests from the config's TrustedProxies.
 func CreateEngine(config *internal.MainConfig) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	err := r.SetTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		// Validate reports the proxies as invalid, until then no proxy is trusted.
+		_ = r.SetTrustedProxies(nil)
+	}
+	reporter := config.ErrorReporter
+	if reporter == nil {
+		reporter = reporting.NoopReporter{}
+	}
+	r.Use(RequestID(), AccessLog(config.Logger, NewAccessLogConfig()), Recovery(config.Logger, reporter))
+	r.Use(SecurityHeaders(NewSecurityHeadersConfig(config)), MaxBodySize(NewServerConfig(config).MaxBodyBytes))
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = config.CORSOrigins
 	corsConfig.AllowCredentials = true
-	r.Use(cors.New(corsConfig))
+	corsConfig.AddAllowHeaders(RequestIDHeader, CSRFHeader)
+	corsConfig.AddExposeHeaders(RequestIDHeader, CSRFHeader)
+	r.Use(cors.New(corsConfig), CSRF(NewCSRFConfig(config)))
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(func(c *gin.Context) {
+		AbortWithProblem(c, NotFound("No route matches the request."))
+	})
+	r.NoMethod(func(c *gin.Context) {
+		AbortWithProblem(c, NewProblem(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "The route doesn't allow the request's method."))
+	})
+	ServeOpenAPI(r, OpenAPIInfo{Title: "Lines API", Version: "1.0.0"})
 	return r
 }
+
+// CreateServer creates a new server that serves the engine on the configured HTTP port, with the timeouts, limits
+// and TLS of NewServerConfig.
+func CreateServer(config *internal.MainConfig, engine HttpEngine) *Server {
+	return NewServer(NewServerConfig(config), engine, config.Logger)
+}
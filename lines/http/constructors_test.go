@@ -1,15 +1,63 @@
 package http
 
 import (
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"lines/internal"
+	"lines/lines/logging"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
 func TestCreateEngine(t *testing.T) {
 	config := &internal.MainConfig{
 		CORSOrigins: []string{"http://localhost:3000"},
+		Logger:      logging.NewLogrusHandler("fatal"),
 	}
 	engine := CreateEngine(config)
 	assert.NotNil(t, engine)
+	assert.Empty(t, UndocumentedRoutes(engine))
+}
+
+func TestCreateEngine_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxies  []string
+		expected string
+	}{
+		{"none by default", []string{}, "192.0.2.1"},
+		{"trusted", []string{"192.0.2.0/24"}, "203.0.113.7"},
+		{"another proxy", []string{"198.51.100.1"}, "192.0.2.1"},
+		{"invalid", []string{"proxy"}, "192.0.2.1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := CreateEngine(&internal.MainConfig{
+				CORSOrigins:    []string{"http://localhost:3000"},
+				TrustedProxies: test.proxies,
+				Logger:         logging.NewLogrusHandler("fatal"),
+			})
+			engine.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, KeyByIP(c)) })
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			rr := httptest.NewRecorder()
+
+			engine.ServeHTTP(rr, req)
+
+			assert.Equal(t, "ip:"+test.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestCreateServer(t *testing.T) {
+	config := &internal.MainConfig{
+		CORSOrigins: []string{"http://localhost:3000"},
+		HTTPPort:    8080,
+	}
+	engine := CreateEngine(config)
+	server := CreateServer(config, engine)
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, engine, server.Handler)
 }
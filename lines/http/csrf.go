@@ -0,0 +1,120 @@
+package http
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/gin-gonic/gin"
+	"lines/internal"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+)
+
+// CodeCSRFFailed is the problem code of requests refused by the CSRF middleware.
+const CodeCSRFFailed = "csrf_failed"
+
+// AuthCookieName is the cookie requests are authenticated with, the user app sets it to a JWT on sign in. Requests
+// carrying it are the ones another site can forge, since browsers send it along with cross-site requests.
+const AuthCookieName = "Bearer"
+
+const (
+	// CSRFCookieName is the cookie the CSRF token is issued in. It isn't HttpOnly, so the site's JavaScript can read
+	// it and send it back in the X-CSRF-Token header.
+	CSRFCookieName = "csrf_token"
+	// CSRFHeader carries the CSRF token, on unsafe requests from clients and on every response.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CSRFConfig configures the CSRF token cookie.
+type CSRFConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// NewCSRFConfig issues the token cookie for the site's domain, with the SameSite attribute of the config's
+// COOKIE_SAME_SITE.
+func NewCSRFConfig(config *internal.MainConfig) CSRFConfig {
+	return CSRFConfig{
+		Domain:   config.SiteDomain,
+		Secure:   config.UseSSL,
+		SameSite: SameSite(config.CookieSameSite),
+	}
+}
+
+// SameSite turns a COOKIE_SAME_SITE value, "lax", "strict" or "none" as NewConfig normalises and Validate checks it,
+// into the cookie attribute. An unset value is lax, the attribute browsers default to.
+func SameSite(name string) http.SameSite {
+	switch name {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// ExemptFromCSRF is middleware for a route or group that exempts it from CSRF checks, e.g. a webhook that's
+// authenticated by a signature rather than a cookie:
+//
+//	webhooks := engine.Group("/webhooks", ExemptFromCSRF())
+func ExemptFromCSRF() gin.HandlerFunc {
+	return csrfExempt
+}
+
+func csrfExempt(c *gin.Context) {
+	c.Next()
+}
+
+// csrfExemptName is how csrfExempt appears among a route's handler names.
+var csrfExemptName = runtime.FuncForPC(reflect.ValueOf(csrfExempt).Pointer()).Name()
+
+// CSRF is middleware that protects cookie-authenticated requests from cross-site request forgery with a double-submit
+// token. Every response carries the token, in the csrf_token cookie and the X-CSRF-Token header, and unsafe requests
+// carrying the auth cookie have to send it back in the X-CSRF-Token header, which another site can't read or set.
+// Requests without the auth cookie can't be forged by another site, so they aren't checked. Requests with it are,
+// even if they also have an Authorization header, since another site can send one with any value.
+func CSRF(config CSRFConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := c.Cookie(CSRFCookieName)
+		if err != nil || token == "" {
+			token = newCSRFToken()
+			c.SetSameSite(config.SameSite)
+			c.SetCookie(CSRFCookieName, token, 0, "/", config.Domain, config.Secure, false)
+		}
+		c.Header(CSRFHeader, token)
+
+		if csrfSafe(c) {
+			c.Next()
+			return
+		}
+		sent := c.GetHeader(CSRFHeader)
+		if sent == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			AbortWithProblem(c, NewProblem(http.StatusForbidden, CodeCSRFFailed, "The request's CSRF token is missing or doesn't match."))
+			return
+		}
+		c.Next()
+	}
+}
+
+// csrfSafe is whether a request doesn't need a CSRF token: it can't change anything, it isn't authenticated by the
+// auth cookie, or its route is exempt. CSRF runs before the route's own middleware, so it looks for ExemptFromCSRF
+// among the route's handlers rather than waiting for it to run.
+func csrfSafe(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	if _, err := c.Cookie(AuthCookieName); err != nil {
+		return true
+	}
+	return slices.Contains(c.HandlerNames(), csrfExemptName)
+}
+
+func newCSRFToken() string {
+	token := make([]byte, 32)
+	_, _ = rand.Read(token)
+	return base64.RawURLEncoding.EncodeToString(token)
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func csrfEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(CSRF(CSRFConfig{Domain: "example.com", Secure: true, SameSite: http.SameSiteStrictMode}))
+	engine.GET("/csrf/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.POST("/csrf/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	webhooks := engine.Group("/csrf/webhooks", ExemptFromCSRF())
+	webhooks.POST("/:provider", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	engine.POST("/csrf/hook", ExemptFromCSRF(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return engine
+}
+
+func csrfRequest(method string, path string, authCookie bool, token string, sentToken string) *http.Request {
+	req := httptest.NewRequest(method, path, nil)
+	if authCookie {
+		req.AddCookie(&http.Cookie{Name: AuthCookieName, Value: "jwt"})
+	}
+	if token != "" {
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: token})
+	}
+	if sentToken != "" {
+		req.Header.Set(CSRFHeader, sentToken)
+	}
+	return req
+}
+
+func TestCSRF_IssuesToken(t *testing.T) {
+	engine := csrfEngine()
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodGet, "/csrf/items", true, "", ""))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	token := rr.Header().Get(CSRFHeader)
+	assert.Len(t, token, 43)
+	assert.Equal(t, CSRFCookieName+"="+token+"; Path=/; Domain=example.com; Secure; SameSite=Strict", rr.Header().Get("Set-Cookie"))
+
+	// A request with a token keeps it.
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodGet, "/csrf/items", true, token, ""))
+	assert.Equal(t, token, rr.Header().Get(CSRFHeader))
+	assert.Empty(t, rr.Header().Get("Set-Cookie"))
+}
+
+func TestCSRF_ChecksCookieAuthenticatedRequests(t *testing.T) {
+	engine := csrfEngine()
+
+	for name, req := range map[string]*http.Request{
+		"no token":    csrfRequest(http.MethodPost, "/csrf/items", true, "token", ""),
+		"wrong token": csrfRequest(http.MethodPost, "/csrf/items", true, "token", "forged"),
+		"no cookie":   csrfRequest(http.MethodPost, "/csrf/items", true, "", "token"),
+		"authorization header": func() *http.Request {
+			// Another site can send the header with any value, the cookie still authenticates the request.
+			req := csrfRequest(http.MethodPost, "/csrf/items", true, "token", "")
+			req.Header.Set("Authorization", "Bearer forged")
+			return req
+		}(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+			problem := Problem{}
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, CodeCSRFFailed, problem.Code)
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodPost, "/csrf/items", true, "token", "token"))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+}
+
+func TestCSRF_SkipsRequestsThatCantBeForged(t *testing.T) {
+	engine := csrfEngine()
+
+	// Requests without the auth cookie.
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodPost, "/csrf/items", false, "", ""))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// Requests authenticated by header alone.
+	req := csrfRequest(http.MethodPost, "/csrf/items", false, "", "")
+	req.Header.Set("Authorization", "Bearer jwt")
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// Exempt routes.
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodPost, "/csrf/webhooks/stripe", true, "", ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodPost, "/csrf/hook", true, "", ""))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestExemptFromCSRF_OnlyAppliesToItsEngine(t *testing.T) {
+	csrfEngine()
+	engine := gin.New()
+	engine.Use(CSRF(CSRFConfig{}))
+	engine.POST("/csrf/webhooks/:provider", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, csrfRequest(http.MethodPost, "/csrf/webhooks/stripe", true, "", ""))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestSameSite(t *testing.T) {
+	assert.Equal(t, http.SameSiteLaxMode, SameSite("lax"))
+	assert.Equal(t, http.SameSiteStrictMode, SameSite("strict"))
+	assert.Equal(t, http.SameSiteNoneMode, SameSite("none"))
+	assert.Equal(t, http.SameSiteLaxMode, SameSite(""))
+}
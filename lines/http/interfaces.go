@@ -1,18 +1,43 @@
 package http
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
 
 type Route func(ctx *gin.Context)
 
-type HttpEngine interface {
-	Run(addr ...string) error
+// HttpRouter registers routes and the middleware that runs before them. The engine is a router, and so are the
+// groups it creates, a *gin.RouterGroup satisfies HttpRouter, so ingresses can pass groups around like the engine.
+type HttpRouter interface {
+	// Use adds middleware to the router, it runs before the handlers of every route registered afterwards.
+	Use(middleware ...gin.HandlerFunc) gin.IRoutes
+	// Group creates a router for the routes under relativePath. The handlers run before the group's routes, after
+	// the parent router's middleware.
+	Group(relativePath string, handlers ...gin.HandlerFunc) *gin.RouterGroup
+	// BasePath is the path the router's routes are under.
+	BasePath() string
+	Handle(httpMethod string, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
+	HEAD(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
+	PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	OPTIONS(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 }
 
-type HttpError struct {
-	Message []string `json:"message"`
+type HttpEngine interface {
+	HttpRouter
+	Run(addr ...string) error
+	ServeHTTP(w http.ResponseWriter, req *http.Request)
+	// Routes lists the routes registered on the engine.
+	Routes() gin.RoutesInfo
+}
+
+// HttpServer is the server that serves an HttpEngine, it can be drained on shutdown.
+type HttpServer interface {
+	ListenAndServe() error
+	Shutdown(ctx context.Context) error
 }
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var (
+	_ HttpEngine = (*gin.Engine)(nil)
+	_ HttpRouter = (*gin.RouterGroup)(nil)
+)
+
+func record(name string, calls *[]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		*calls = append(*calls, name)
+	}
+}
+
+func TestHttpRouter_GroupMiddleware(t *testing.T) {
+	var calls []string
+	var engine HttpEngine = gin.New()
+	engine.Use(record("engine", &calls))
+	var v1 HttpRouter = engine.Group("/v1", record("v1", &calls))
+	var users HttpRouter = v1.Group("/users")
+	users.Use(record("users", &calls))
+	users.PATCH("/me", record("handler", &calls))
+	engine.GET("/health", record("handler", &calls))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		calls  []string
+	}{
+		{"PATCH", "/v1/users/me", http.StatusOK, []string{"engine", "v1", "users", "handler"}},
+		{"GET", "/health", http.StatusOK, []string{"engine", "handler"}},
+		{"PATCH", "/users/me", http.StatusNotFound, []string{"engine"}},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			calls = nil
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.status, rr.Code)
+			assert.Equal(t, test.calls, calls)
+		})
+	}
+}
+
+func TestHttpRouter_HEAD(t *testing.T) {
+	var engine HttpEngine = gin.New()
+	engine.Group("/v1").HEAD("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("HEAD", "/v1/ping", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "HEAD", engine.Routes()[0].Method)
+	assert.Equal(t, "/v1/ping", engine.Routes()[0].Path)
+}
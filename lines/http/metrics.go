@@ -0,0 +1,32 @@
+package http
+
+import (
+	"expvar"
+	"lines/internal"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsPath is where MetricsHandler serves the process's expvar metrics, e.g. the scheduler's "scheduled_tasks".
+const MetricsPath = "/debug/vars"
+
+// MetricsHandler serves the process's expvar metrics as JSON at MetricsPath.
+func MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, expvar.Handler())
+	return mux
+}
+
+// CreateMetricsServer creates a plain HTTP server for the metrics on the configured metrics port, for processes that
+// don't serve the API, like the worker. It shouldn't be reachable from outside the cluster.
+func CreateMetricsServer(config *internal.MainConfig) *Server {
+	return NewServer(ServerConfig{
+		Addr:              ":" + strconv.Itoa(config.MetricsPort),
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+	}, MetricsHandler(), config.Logger)
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"lines/internal"
+	"lines/lines/schedule"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsHandler_ServesSchedulerCounters(t *testing.T) {
+	schedule.Metrics.Add("metrics_test.runs", 2)
+	schedule.Metrics.Add("metrics_test.failures", 1)
+
+	rr := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var metrics struct {
+		ScheduledTasks map[string]float64 `json:"scheduled_tasks"`
+	}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &metrics))
+	assert.Equal(t, float64(2), metrics.ScheduledTasks["metrics_test.runs"])
+	assert.Equal(t, float64(1), metrics.ScheduledTasks["metrics_test.failures"])
+}
+
+func TestCreateMetricsServer(t *testing.T) {
+	server := CreateMetricsServer(&internal.MainConfig{MetricsPort: 9100})
+
+	assert.Equal(t, ":9100", server.Addr)
+	assert.Nil(t, server.TLSConfig)
+}
@@ -0,0 +1,283 @@
+package http
+
+import (
+	_ "embed"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OpenAPIPath and DocsPath are where CreateEngine serves the OpenAPI document and the docs UI.
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// docsContentSecurityPolicy lets the docs page run its inline script and styles and fetch the OpenAPI document.
+const docsContentSecurityPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; " +
+	"connect-src 'self'; frame-ancestors 'none'"
+
+// Operation describes a route for the OpenAPI document.
+type Operation struct {
+	Summary     string
+	Description string
+	// Tags group operations in the docs, usually by app.
+	Tags []string
+	// Parameters are the route's query parameters, its path parameters are documented from its path.
+	Parameters []OpenAPIParameter
+	// Request is the type of the request's JSON body, e.g. UserLogin{}, nil if the route doesn't take one.
+	Request any
+	// Responses are the types of the successful responses' JSON bodies by status, a nil type is a response without
+	// a body. Every operation can also respond with a problem.
+	Responses map[int]any
+	// Auth is the name of the security scheme the route requires, it's empty for public routes. Schemes are
+	// registered with RegisterSecurityScheme.
+	Auth string
+	// AuthOptional marks routes that accept the scheme but let anonymous requests through, like OptionalAuth.
+	AuthOptional bool
+}
+
+// SecurityScheme is an OpenAPI security scheme object.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// documentation is the operations and security schemes registered by every ingress in the process.
+var documentation = struct {
+	sync.RWMutex
+	operations      map[string]Operation
+	securitySchemes map[string]SecurityScheme
+}{
+	operations:      map[string]Operation{},
+	securitySchemes: map[string]SecurityScheme{},
+}
+
+// Handle registers the route on the router, like the router's GET, POST and so on, and documents it with the
+// operation.
+func Handle(router HttpRouter, method string, relativePath string, operation Operation, handlers ...gin.HandlerFunc) {
+	router.Handle(method, relativePath, handlers...)
+	documentation.Lock()
+	defer documentation.Unlock()
+	documentation.operations[routeKey(method, joinPaths(router.BasePath(), relativePath))] = operation
+}
+
+// RegisterSecurityScheme registers a security scheme operations can require by name.
+func RegisterSecurityScheme(name string, scheme SecurityScheme) {
+	documentation.Lock()
+	defer documentation.Unlock()
+	documentation.securitySchemes[name] = scheme
+}
+
+// UndocumentedRoutes returns the engine's routes that weren't registered with Handle. Ingress tests assert there
+// aren't any, so the OpenAPI document covers every route.
+func UndocumentedRoutes(engine HttpEngine) gin.RoutesInfo {
+	documentation.RLock()
+	defer documentation.RUnlock()
+	var undocumented gin.RoutesInfo
+	for _, route := range engine.Routes() {
+		if _, ok := documentation.operations[routeKey(route.Method, route.Path)]; !ok {
+			undocumented = append(undocumented, route)
+		}
+	}
+	return undocumented
+}
+
+func routeKey(method string, path string) string {
+	return method + " " + path
+}
+
+// joinPaths joins a router's base path and a route's relative path the way gin does, so documented paths match the
+// engine's routes.
+func joinPaths(basePath string, relativePath string) string {
+	if relativePath == "" {
+		return basePath
+	}
+	joined := path.Join(basePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
+// OpenAPIInfo is the OpenAPI document's info object.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIDocument is an OpenAPI 3.0 document.
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// NewOpenAPIDocument documents the engine's documented routes, UndocumentedRoutes lists the ones it leaves out.
+func NewOpenAPIDocument(engine HttpEngine, info OpenAPIInfo) *OpenAPIDocument {
+	documentation.RLock()
+	defer documentation.RUnlock()
+	schemas := newSchemaRegistry()
+	problem := schemas.schemaOf(Problem{})
+	document := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+	usedSchemes := map[string]SecurityScheme{}
+	for _, route := range engine.Routes() {
+		operation, ok := documentation.operations[routeKey(route.Method, route.Path)]
+		if !ok {
+			continue
+		}
+		openAPIPath, parameters := openAPIPath(route.Path)
+		documented := &OpenAPIOperation{
+			OperationID: operationID(route.Method, route.Path),
+			Summary:     operation.Summary,
+			Description: operation.Description,
+			Tags:        operation.Tags,
+			Parameters:  append(parameters, operation.Parameters...),
+			Responses: map[string]*OpenAPIResponse{
+				"default": {
+					Description: "A problem.",
+					Content:     map[string]*OpenAPIMediaType{ProblemContentType: {Schema: problem}},
+				},
+			},
+		}
+		if operation.Request != nil {
+			documented.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]*OpenAPIMediaType{"application/json": {Schema: schemas.schemaOf(operation.Request)}},
+			}
+		}
+		for status, body := range operation.Responses {
+			response := &OpenAPIResponse{Description: http.StatusText(status)}
+			if body != nil {
+				response.Content = map[string]*OpenAPIMediaType{"application/json": {Schema: schemas.schemaOf(body)}}
+			}
+			documented.Responses[strconv.Itoa(status)] = response
+		}
+		if operation.Auth != "" {
+			documented.Security = []map[string][]string{{operation.Auth: {}}}
+			if operation.AuthOptional {
+				documented.Security = append(documented.Security, map[string][]string{})
+			}
+			if scheme, ok := documentation.securitySchemes[operation.Auth]; ok {
+				usedSchemes[operation.Auth] = scheme
+			}
+		}
+		if document.Paths[openAPIPath] == nil {
+			document.Paths[openAPIPath] = map[string]*OpenAPIOperation{}
+		}
+		document.Paths[openAPIPath][strings.ToLower(route.Method)] = documented
+	}
+	document.Components = OpenAPIComponents{Schemas: schemas.components, SecuritySchemes: usedSchemes}
+	return document
+}
+
+// openAPIPath turns a gin path into an OpenAPI one, e.g. "/users/:id" is "/users/{id}", along with its parameters.
+func openAPIPath(ginPath string) (string, []OpenAPIParameter) {
+	var parameters []OpenAPIParameter
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		parameters = append(parameters, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// operationID derives a unique ID from the route, e.g. "post_v1_users_sign_in".
+func operationID(method string, ginPath string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(ginPath, func(r rune) bool { return !isIDChar(r) }) {
+		id += "_" + segment
+	}
+	return id
+}
+
+func isIDChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// ServeOpenAPI serves the engine's OpenAPI document at OpenAPIPath and a docs UI for it at DocsPath. The document
+// is generated on the first request, once every app has registered its routes. The docs page's inline script and
+// styles are allowed by its Content-Security-Policy.
+func ServeOpenAPI(engine HttpEngine, info OpenAPIInfo) {
+	var once sync.Once
+	var document *OpenAPIDocument
+	Handle(engine, http.MethodGet, OpenAPIPath, Operation{
+		Summary:   "The OpenAPI document describing the API.",
+		Tags:      []string{"docs"},
+		Responses: map[int]any{http.StatusOK: nil},
+	}, func(c *gin.Context) {
+		once.Do(func() {
+			document = NewOpenAPIDocument(engine, info)
+		})
+		c.JSON(http.StatusOK, document)
+	})
+	Handle(engine, http.MethodGet, DocsPath, Operation{
+		Summary:   "Browsable docs of the API.",
+		Tags:      []string{"docs"},
+		Responses: map[int]any{http.StatusOK: nil},
+	}, OverrideSecurityHeaders(func(headers *SecurityHeadersConfig) {
+		if headers.ContentSecurityPolicy != "" {
+			headers.ContentSecurityPolicy = docsContentSecurityPolicy
+		}
+	}), func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI 3.0 schema object, the subset the DTOs need.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	componentNameChar = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+	// typeArgumentPackage matches the package paths in the type arguments of a generic type's name, e.g.
+	// "lines/user/ingress/http." in "Page[lines/user/ingress/http.UserReadDTO]".
+	typeArgumentPackage = regexp.MustCompile(`[^\[\],*]*\.`)
+)
+
+// schemaRegistry turns Go types into schemas, named struct types become components that other schemas reference.
+type schemaRegistry struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaOf returns the schema of the value's type, as encoding/json would encode it.
+func (r *schemaRegistry) schemaOf(value any) *Schema {
+	return r.schema(reflect.TypeOf(value))
+}
+
+func (r *schemaRegistry) schema(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := r.schema(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// The type encodes itself, so its shape can't be told from its fields.
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := float64(0)
+		return &Schema{Type: "integer", Format: "int64", Minimum: &minimum}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + r.component(t)}
+	}
+	// Interfaces, and the kinds encoding/json can't encode, could be anything.
+	return &Schema{}
+}
+
+// component names the struct type's component, adding it to the registry the first time it's seen.
+func (r *schemaRegistry) component(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	// Generic types are named after their type arguments too, e.g. "Page_UserReadDTO".
+	name := typeArgumentPackage.ReplaceAllString(t.Name(), "")
+	name = strings.Trim(componentNameChar.ReplaceAllString(name, "_"), "_")
+	if _, taken := r.components[name]; taken {
+		// Another package has a type of the same name.
+		name = componentNameChar.ReplaceAllString(t.PkgPath(), "_") + "." + name
+	}
+	r.names[t] = name
+	// The component is added before its fields are, so a type that refers to itself refers to the component.
+	r.components[name] = &Schema{}
+	*r.components[name] = *r.structSchema(t)
+	return name
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the struct's fields to the schema, following encoding/json's rules: unexported and "-" fields are
+// skipped, and the fields of untagged embedded structs are promoted. Fields that aren't omitempty are required.
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			r.addFields(schema, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema := r.schema(field.Type)
+		if hasOption(options, "string") {
+			fieldSchema = &Schema{Type: "string"}
+		}
+		schema.Properties[name] = fieldSchema
+		if !hasOption(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func hasOption(options string, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
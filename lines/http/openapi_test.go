@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	Line string `json:"line"`
+}
+
+type testEmbedded struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testDTO struct {
+	testEmbedded
+	ID       uint           `json:"id"`
+	Name     string         `json:"name"`
+	Nickname *string        `json:"nickname"`
+	Score    float64        `json:"score,omitempty"`
+	Tags     []string       `json:"tags"`
+	Labels   map[string]int `json:"labels,omitempty"`
+	Address  testAddress    `json:"address"`
+	Previous *testDTO       `json:"previous,omitempty"`
+	Count    int64          `json:"count,string"`
+	Data     []byte         `json:"data,omitempty"`
+	Extra    any            `json:"extra,omitempty"`
+	Ignored  string         `json:"-"`
+	Untagged bool           `json:",omitempty"`
+	hidden   string
+	Custom   json.RawMessage   `json:"custom,omitempty"`
+	Nested   map[string][]bool `json:"nested,omitempty"`
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	schemas := newSchemaRegistry()
+
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testDTO"}, schemas.schemaOf(testDTO{}))
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/testDTO"}}, schemas.schemaOf([]testDTO{}))
+
+	dto := schemas.components["testDTO"]
+	assert.Equal(t, "object", dto.Type)
+	assert.Equal(t, []string{"created_at", "id", "name", "tags", "address", "count"}, dto.Required)
+	zero := float64(0)
+	assert.Equal(t, map[string]*Schema{
+		"created_at": {Type: "string", Format: "date-time"},
+		"id":         {Type: "integer", Format: "int64", Minimum: &zero},
+		"name":       {Type: "string"},
+		"nickname":   {Type: "string", Nullable: true},
+		"score":      {Type: "number", Format: "double"},
+		"tags":       {Type: "array", Items: &Schema{Type: "string"}},
+		"labels":     {Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}},
+		"address":    {Ref: "#/components/schemas/testAddress"},
+		"previous":   {Ref: "#/components/schemas/testDTO"},
+		"count":      {Type: "string"},
+		"data":       {Type: "string", Format: "byte"},
+		"extra":      {},
+		"Untagged":   {Type: "boolean"},
+		"custom":     {},
+		"nested":     {Type: "object", AdditionalProperties: &Schema{Type: "array", Items: &Schema{Type: "boolean"}}},
+	}, dto.Properties)
+	assert.Equal(t, &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"line": {Type: "string"}},
+		Required:   []string{"line"},
+	}, schemas.components["testAddress"])
+}
+
+func TestJoinPaths(t *testing.T) {
+	assert.Equal(t, "/v1/users", joinPaths("/v1/users", ""))
+	assert.Equal(t, "/v1/users/me", joinPaths("/v1/users", "/me"))
+	assert.Equal(t, "/v1/users/", joinPaths("/v1/users", "/"))
+	assert.Equal(t, "/me", joinPaths("/", "me"))
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	path, parameters := openAPIPath("/v1/items/:id/files/*path")
+
+	assert.Equal(t, "/v1/items/{id}/files/{path}", path)
+	assert.Equal(t, []OpenAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+		{Name: "path", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+	}, parameters)
+	assert.Equal(t, "get_v1_items_id_files_path", operationID("GET", "/v1/items/:id/files/*path"))
+}
+
+func documentedEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	RegisterSecurityScheme("test", SecurityScheme{Type: "http", Scheme: "bearer"})
+	items := engine.Group("/test/items")
+	Handle(items, http.MethodPost, "", Operation{
+		Summary:   "Create an item.",
+		Tags:      []string{"items"},
+		Request:   testAddress{},
+		Responses: map[int]any{http.StatusCreated: testDTO{}},
+		Auth:      "test",
+	}, func(c *gin.Context) {})
+	Handle(items, http.MethodGet, "/:id", Operation{
+		Summary:      "Get an item.",
+		Responses:    map[int]any{http.StatusOK: testDTO{}, http.StatusNoContent: nil},
+		Auth:         "test",
+		AuthOptional: true,
+	}, func(c *gin.Context) {})
+	items.DELETE("/:id", func(c *gin.Context) {})
+	return engine
+}
+
+func TestUndocumentedRoutes(t *testing.T) {
+	engine := documentedEngine()
+
+	undocumented := UndocumentedRoutes(engine)
+
+	assert.Len(t, undocumented, 1)
+	assert.Equal(t, "DELETE", undocumented[0].Method)
+	assert.Equal(t, "/test/items/:id", undocumented[0].Path)
+}
+
+func TestNewOpenAPIDocument(t *testing.T) {
+	engine := documentedEngine()
+
+	document := NewOpenAPIDocument(engine, OpenAPIInfo{Title: "Test", Version: "1"})
+
+	assert.Equal(t, "3.0.3", document.OpenAPI)
+	assert.Equal(t, OpenAPIInfo{Title: "Test", Version: "1"}, document.Info)
+	assert.Len(t, document.Paths, 2)
+
+	create := document.Paths["/test/items"]["post"]
+	assert.Equal(t, "post_test_items", create.OperationID)
+	assert.Equal(t, "Create an item.", create.Summary)
+	assert.Equal(t, []string{"items"}, create.Tags)
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testAddress"}, create.RequestBody.Content["application/json"].Schema)
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/testDTO"}, create.Responses["201"].Content["application/json"].Schema)
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/Problem"}, create.Responses["default"].Content[ProblemContentType].Schema)
+	assert.Equal(t, []map[string][]string{{"test": {}}}, create.Security)
+
+	get := document.Paths["/test/items/{id}"]["get"]
+	assert.Nil(t, get.RequestBody)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "No Content", get.Responses["204"].Description)
+	assert.Nil(t, get.Responses["204"].Content)
+	assert.Equal(t, []map[string][]string{{"test": {}}, {}}, get.Security)
+	assert.NotContains(t, document.Paths["/test/items/{id}"], "delete")
+
+	assert.Contains(t, document.Components.Schemas, "testDTO")
+	assert.Contains(t, document.Components.Schemas, "testAddress")
+	assert.Contains(t, document.Components.Schemas, "Problem")
+	assert.Equal(t, map[string]SecurityScheme{"test": {Type: "http", Scheme: "bearer"}}, document.Components.SecuritySchemes)
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	engine := documentedEngine()
+	ServeOpenAPI(engine, OpenAPIInfo{Title: "Test", Version: "1"})
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", OpenAPIPath, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	document := map[string]any{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &document))
+	assert.Equal(t, "3.0.3", document["openapi"])
+	assert.Contains(t, document["paths"], "/test/items/{id}")
+	assert.Contains(t, document["paths"], OpenAPIPath)
+
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", DocsPath, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `fetch("openapi.json")`)
+}
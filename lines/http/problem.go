@@ -0,0 +1,117 @@
+package http
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"lines/lines/domain"
+	"lines/lines/logging"
+	"lines/lines/store"
+	"net/http"
+)
+
+// ProblemContentType is the media type problems are sent with.
+const ProblemContentType = "application/problem+json"
+
+// The codes problems carry, clients switch on the code rather than the status or text.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorised     = "unauthorised"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInternal         = "internal_error"
+)
+
+// Problem is the error every endpoint responds with, an RFC 7807 problem details object with a machine-readable
+// code and, for validation failures, the errors of each field. It's an error, so it can be returned up the stack
+// and written with AbortWithProblem.
+type Problem struct {
+	// Type is "about:blank", the code identifies the kind of problem instead.
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+	// Errors are the field errors of a validation failure, they have the same shape as domain validation errors.
+	Errors []domain.DomainValidationErrors `json:"errors,omitempty"`
+	// RequestID is set when the problem is written, so a client can quote it when reporting the problem.
+	RequestID string `json:"request_id,omitempty"`
+	// Reference identifies the error report of an unexpected failure, like a panic, for the client to quote.
+	Reference string `json:"reference,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Code
+	}
+	return p.Code + ": " + p.Detail
+}
+
+// NewProblem creates a problem with the status' text as its title.
+func NewProblem(status int, code string, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func BadRequest(detail string) *Problem {
+	return NewProblem(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+func Unauthorised(detail string) *Problem {
+	return NewProblem(http.StatusUnauthorized, CodeUnauthorised, detail)
+}
+
+func Forbidden(detail string) *Problem {
+	return NewProblem(http.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(detail string) *Problem {
+	return NewProblem(http.StatusNotFound, CodeNotFound, detail)
+}
+
+func Internal(detail string) *Problem {
+	return NewProblem(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+// ValidationProblem turns validation errors into a 400 problem, or nil if there aren't any, so validators can return
+// it as is.
+func ValidationProblem(validationErrors []domain.DomainValidationErrors) *Problem {
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	problem := NewProblem(http.StatusBadRequest, CodeValidationFailed, "The request is invalid.")
+	problem.Errors = validationErrors
+	return problem
+}
+
+// ModelValidationProblem turns store validation errors into a 400 problem, or nil if there aren't any.
+func ModelValidationProblem(modelErrors []store.ModelValidationError) *Problem {
+	return ValidationProblem(domain.StoreValidationErrorToDomainValidationError(modelErrors))
+}
+
+// ProblemFromError returns the problem err is, or wraps, and an internal error problem for any other error, so
+// errors that aren't meant for clients aren't shown to them.
+func ProblemFromError(err error) *Problem {
+	if err == nil {
+		return nil
+	}
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+	return Internal("Something went wrong.")
+}
+
+// AbortWithProblem stops the request's handlers and writes the problem as application/problem+json.
+func AbortWithProblem(c *gin.Context, problem *Problem) {
+	written := *problem
+	written.RequestID = logging.RequestID(c.Request.Context())
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(written.Status, &written)
+}
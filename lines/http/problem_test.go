@@ -0,0 +1,140 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/internal"
+	"lines/lines/domain"
+	"lines/lines/logging"
+	"lines/lines/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemConstructors(t *testing.T) {
+	tests := []struct {
+		problem *Problem
+		status  int
+		code    string
+		title   string
+	}{
+		{BadRequest("detail"), http.StatusBadRequest, CodeBadRequest, "Bad Request"},
+		{Unauthorised("detail"), http.StatusUnauthorized, CodeUnauthorised, "Unauthorized"},
+		{Forbidden("detail"), http.StatusForbidden, CodeForbidden, "Forbidden"},
+		{NotFound("detail"), http.StatusNotFound, CodeNotFound, "Not Found"},
+		{Internal("detail"), http.StatusInternalServerError, CodeInternal, "Internal Server Error"},
+	}
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			assert.Equal(t, &Problem{
+				Type:   "about:blank",
+				Title:  test.title,
+				Status: test.status,
+				Detail: "detail",
+				Code:   test.code,
+			}, test.problem)
+			assert.EqualError(t, test.problem, test.code+": detail")
+		})
+	}
+}
+
+func TestValidationProblem(t *testing.T) {
+	assert.Nil(t, ValidationProblem(nil))
+
+	validationErrors := []domain.DomainValidationErrors{{Field: "email", Errors: []string{"email is required"}}}
+	problem := ValidationProblem(validationErrors)
+
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, CodeValidationFailed, problem.Code)
+	assert.Equal(t, validationErrors, problem.Errors)
+}
+
+func TestModelValidationProblem(t *testing.T) {
+	assert.Nil(t, ModelValidationProblem(nil))
+
+	problem := ModelValidationProblem([]store.ModelValidationError{
+		{Field: "Email", Message: "Email is required"},
+		{Field: "Email", Message: "Email is invalid"},
+	})
+
+	assert.Equal(t, CodeValidationFailed, problem.Code)
+	assert.Equal(t, []domain.DomainValidationErrors{{Field: "Email", Errors: []string{"Email is required", "Email is invalid"}}}, problem.Errors)
+}
+
+func TestProblemFromError(t *testing.T) {
+	notFound := NotFound("User not found.")
+	tests := []struct {
+		name     string
+		err      error
+		expected *Problem
+	}{
+		{"nil", nil, nil},
+		{"problem", notFound, notFound},
+		{"wrapped problem", fmt.Errorf("resolving user: %w", notFound), notFound},
+		{"other error", assert.AnError, Internal("Something went wrong.")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ProblemFromError(test.err))
+		})
+	}
+}
+
+func TestAbortWithProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	called := false
+	problem := NotFound("User not found.")
+	engine.GET("/", RequestID(), func(c *gin.Context) {
+		AbortWithProblem(c, problem)
+	}, func(c *gin.Context) {
+		called = true
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+
+	engine.ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Not Found",
+		"status": 404,
+		"detail": "User not found.",
+		"code": "not_found",
+		"request_id": "abc"
+	}`, rr.Body.String())
+	assert.Empty(t, problem.RequestID)
+}
+
+func TestCreateEngine_Problems(t *testing.T) {
+	engine := CreateEngine(&internal.MainConfig{CORSOrigins: []string{"http://localhost:3000"}, Logger: logging.NewLogrusHandler("fatal")})
+	engine.GET("/users", func(c *gin.Context) {})
+	tests := []struct {
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{"GET", "/nowhere", http.StatusNotFound, CodeNotFound},
+		{"DELETE", "/users", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+
+			assert.Equal(t, test.status, rr.Code)
+			assert.Equal(t, ProblemContentType, rr.Header().Get("Content-Type"))
+			problem := Problem{}
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, test.code, problem.Code)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CodeRateLimited is the code of the problem sent to requests over their rate limit.
+const CodeRateLimited = "rate_limited"
+
+// The headers rate limited responses carry, from the IETF RateLimit header fields draft.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// The algorithms a policy can be configured with.
+const (
+	TokenBucketAlgorithm   = "token_bucket"
+	SlidingWindowAlgorithm = "sliding_window"
+)
+
+// RateLimitState is what an algorithm keeps between requests for a key, a store saves it.
+type RateLimitState struct {
+	// Value is the tokens left in a token bucket, or the requests made in the current window of a sliding window.
+	Value float64
+	// Previous is the requests made in the previous window of a sliding window.
+	Previous float64
+	// Start is when a token bucket was last refilled, or when a sliding window's current window started.
+	Start time.Time
+}
+
+// RateLimitStatus is the outcome of a request against its limit.
+type RateLimitStatus struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the limit is fully available again.
+	Reset time.Duration
+	// RetryAfter is how long a request that wasn't allowed has to wait before one would be.
+	RetryAfter time.Duration
+}
+
+// RateLimitAlgorithm decides whether a request is allowed, updating the key's state. The state is zero for a key
+// that hasn't been seen, or whose state expired.
+type RateLimitAlgorithm interface {
+	Allow(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitStatus
+}
+
+// TokenBucket allows bursts of up to limit requests, refilling the bucket at limit tokens per window.
+type TokenBucket struct{}
+
+func (TokenBucket) Allow(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitStatus {
+	capacity := float64(limit)
+	perToken := window / time.Duration(limit)
+	if state.Start.IsZero() {
+		state.Value = capacity
+		state.Start = now
+	}
+	if elapsed := now.Sub(state.Start); elapsed > 0 {
+		state.Value = math.Min(capacity, state.Value+float64(elapsed)/float64(perToken))
+		state.Start = now
+	}
+
+	status := RateLimitStatus{Limit: limit}
+	if state.Value >= 1 {
+		state.Value--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = time.Duration((1 - state.Value) * float64(perToken))
+	}
+	status.Remaining = int(state.Value)
+	status.Reset = time.Duration((capacity - state.Value) * float64(perToken))
+	return status
+}
+
+// SlidingWindow allows limit requests in any window. It estimates the requests made in the last window from the
+// counts of the current and previous fixed windows, weighting the previous count by how much of it overlaps.
+type SlidingWindow struct{}
+
+func (SlidingWindow) Allow(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitStatus {
+	start := now.Truncate(window)
+	if !state.Start.Equal(start) {
+		if state.Start.Add(window).Equal(start) {
+			state.Previous = state.Value
+		} else {
+			state.Previous = 0
+		}
+		state.Value = 0
+		state.Start = start
+	}
+	capacity := float64(limit)
+	elapsed := now.Sub(start)
+	overlap := 1 - float64(elapsed)/float64(window)
+	estimate := state.Previous*overlap + state.Value
+
+	status := RateLimitStatus{Limit: limit, Reset: window - elapsed}
+	if estimate+1 <= capacity {
+		state.Value++
+		estimate++
+		status.Allowed = true
+	} else {
+		status.RetryAfter = slidingWindowRetryAfter(state, capacity, window, elapsed)
+	}
+	status.Remaining = int(math.Max(0, capacity-estimate))
+	if state.Value > 0 {
+		// The current window's requests still count until the end of the next one.
+		status.Reset += window
+	}
+	return status
+}
+
+// slidingWindowRetryAfter is how long until the estimate leaves room for one more request.
+func slidingWindowRetryAfter(state *RateLimitState, capacity float64, window, elapsed time.Duration) time.Duration {
+	room := capacity - 1 - state.Value
+	if room >= 0 {
+		// There's room once enough of the previous window has slid out.
+		return time.Duration((1-room/state.Previous)*float64(window)) - elapsed
+	}
+	// The current window is full, so there's room part way through the next one, once enough of this one has slid
+	// out.
+	return window - elapsed + time.Duration((1-(capacity-1)/state.Value)*float64(window))
+}
+
+// RateLimitKeyFunc returns who a request is limited as, requests with the same key share a limit. Requests with an
+// empty key aren't limited.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP limits requests by the client's IP, it's only taken from X-Forwarded-For on requests from the engine's
+// trusted proxies.
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByUser limits authenticated requests by their user, and anonymous ones by IP. It has to come after RequireAuth
+// or OptionalAuth, id returns the user's ID.
+func KeyByUser[U any](id func(user U) string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		user, ok := CurrentUser[U](c)
+		if !ok {
+			return KeyByIP(c)
+		}
+		return "user:" + id(user)
+	}
+}
+
+// RateLimitPolicy is a limit applied to a group of routes.
+type RateLimitPolicy struct {
+	// Name namespaces the policy's keys, routes with the same policy name share their limits.
+	Name string
+	// Limit is how many requests a key can make each window, the policy is off if it's 0.
+	Limit     int
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm
+	// Key defaults to KeyByIP.
+	Key RateLimitKeyFunc
+}
+
+// NewRateLimitPolicy reads the policy's limit, window and algorithm from RATE_LIMIT_<NAME>_LIMIT,
+// RATE_LIMIT_<NAME>_WINDOW_SECONDS and RATE_LIMIT_<NAME>_ALGORITHM, the given policy's are the defaults.
+func NewRateLimitPolicy(defaults RateLimitPolicy) RateLimitPolicy {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(defaults.Name) + "_"
+	policy := defaults
+	policy.Limit = utils.GetEnvOrDefault(prefix+"LIMIT", strconv.Itoa(defaults.Limit), "int").(int)
+	policy.Window = time.Duration(utils.GetEnvOrDefault(
+		prefix+"WINDOW_SECONDS",
+		strconv.Itoa(int(defaults.Window/time.Second)),
+		"int",
+	).(int)) * time.Second
+	algorithm := utils.GetEnvOrDefault(prefix+"ALGORITHM", "", "string").(string)
+	switch algorithm {
+	case TokenBucketAlgorithm:
+		policy.Algorithm = TokenBucket{}
+	case SlidingWindowAlgorithm:
+		policy.Algorithm = SlidingWindow{}
+	}
+	return policy
+}
+
+// header is the policy as the RateLimit-Policy header describes it, e.g. "10;w=60".
+func (p RateLimitPolicy) header() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(math.Ceil(p.Window.Seconds())))
+}
+
+// RateLimit is middleware that limits requests by the policy, responding to requests over the limit with a 429
+// problem. Every limited response carries RateLimit-* headers. If the store fails the request is let through, so an
+// outage of the store doesn't take the routes down with it.
+func RateLimit(store RateLimitStore, policy RateLimitPolicy, logger logging.Logger) gin.HandlerFunc {
+	if policy.Algorithm == nil {
+		policy.Algorithm = SlidingWindow{}
+	}
+	if policy.Key == nil {
+		policy.Key = KeyByIP
+	}
+	return func(c *gin.Context) {
+		if policy.Limit <= 0 || policy.Window <= 0 {
+			c.Next()
+			return
+		}
+		key := policy.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		var status RateLimitStatus
+		err := store.Update(policy.Name+":"+key, 2*policy.Window, func(state *RateLimitState, now time.Time) {
+			status = policy.Algorithm.Allow(state, policy.Limit, policy.Window, now)
+		})
+		if err != nil {
+			RequestLogger(c, logger).Error(
+				"http",
+				"RateLimit",
+				fmt.Sprintf("Could not check the %s rate limit: %v", policy.Name, err),
+			)
+			c.Next()
+			return
+		}
+		c.Header(RateLimitLimitHeader, strconv.Itoa(status.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(status.Remaining))
+		c.Header(RateLimitResetHeader, strconv.Itoa(seconds(status.Reset)))
+		c.Header(RateLimitPolicyHeader, policy.header())
+		if !status.Allowed {
+			c.Header("Retry-After", strconv.Itoa(seconds(status.RetryAfter)))
+			AbortWithProblem(c, NewProblem(http.StatusTooManyRequests, CodeRateLimited, "Too many requests, try again later."))
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds rounds a duration up to whole seconds, so a client waiting that long is never early.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lines/lines/store"
+	"lines/lines/utils"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often stores delete expired state.
+const rateLimitSweepInterval = time.Minute
+
+// The stores RATE_LIMIT_STORE can choose.
+const (
+	MemoryRateLimitStoreType   = "memory"
+	PostgresRateLimitStoreType = "postgres"
+)
+
+// RateLimitStore keeps the rate limit state of each key.
+type RateLimitStore interface {
+	// Update passes the key's state to update, along with the current time, and saves the changes. Updates of a key
+	// don't interleave. A key's state is reset once it's gone ttl without an update.
+	Update(key string, ttl time.Duration, update func(state *RateLimitState, now time.Time)) error
+	Close() error
+}
+
+// NewRateLimitStore creates the store RATE_LIMIT_STORE chooses, the memory store by default. The memory store's
+// limits are per process, so deployments running more than one replica should use the Postgres store.
+func NewRateLimitStore() (RateLimitStore, error) {
+	storeType := utils.GetEnvOrDefault("RATE_LIMIT_STORE", MemoryRateLimitStoreType, "string").(string)
+	switch storeType {
+	case MemoryRateLimitStoreType:
+		return NewMemoryRateLimitStore(rateLimitSweepInterval), nil
+	case PostgresRateLimitStoreType:
+		return NewPostgresRateLimitStore(rateLimitSweepInterval), nil
+	}
+	return nil, fmt.Errorf(
+		"unknown RATE_LIMIT_STORE %q, expected %q or %q",
+		storeType,
+		MemoryRateLimitStoreType,
+		PostgresRateLimitStoreType,
+	)
+}
+
+type memoryRateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// MemoryRateLimitStore keeps rate limit state in the process.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryRateLimitEntry
+	now     func() time.Time
+	sweeper *sweeper
+}
+
+// NewMemoryRateLimitStore creates a store that sweeps out expired state every sweepInterval, until it's closed.
+func NewMemoryRateLimitStore(sweepInterval time.Duration) *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		entries: map[string]*memoryRateLimitEntry{},
+		now:     time.Now,
+	}
+	s.sweeper = startSweeper(sweepInterval, s.deleteExpired)
+	return s
+}
+
+func (s *MemoryRateLimitStore) Update(
+	key string,
+	ttl time.Duration,
+	update func(state *RateLimitState, now time.Time),
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+	update(&entry.state, now)
+	entry.expiresAt = now.Add(ttl)
+	return nil
+}
+
+func (s *MemoryRateLimitStore) deleteExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Close stops sweeping out expired state.
+func (s *MemoryRateLimitStore) Close() error {
+	s.sweeper.Stop()
+	return nil
+}
+
+// RateLimitRecord is a key's state in the rate_limits table.
+type RateLimitRecord struct {
+	Key       string `gorm:"primarykey"`
+	Value     float64
+	Previous  float64
+	Start     time.Time
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (RateLimitRecord) TableName() string {
+	return "rate_limits"
+}
+
+func (r RateLimitRecord) Validate() []store.ModelValidationError {
+	var errors []store.ModelValidationError
+	if r.Key == "" {
+		errors = append(errors, store.ModelValidationError{Field: "Key", Message: "Key is required"})
+	}
+	return errors
+}
+
+// PostgresRateLimitStore keeps rate limit state in its own Postgres database, so every replica shares the limits.
+type PostgresRateLimitStore struct {
+	*store.PostgresStore
+	sweeper *sweeper
+}
+
+func (s *PostgresRateLimitStore) Models() []store.PostgresModel {
+	return []store.PostgresModel{
+		RateLimitRecord{},
+	}
+}
+
+func (s *PostgresRateLimitStore) Migrate() error {
+	return store.MigrateModels(s.Postgres, s.Models())
+}
+
+// NewPostgresRateLimitStore connects to the RATE_LIMIT_POSTGRES_URL database, the migrate command creates the
+// rate_limits table. It deletes expired state every sweepInterval, until it's closed.
+func NewPostgresRateLimitStore(sweepInterval time.Duration) *PostgresRateLimitStore {
+	config := store.CreatePostgresDBConfig("RATE_LIMIT")
+	rateLimitStore := &PostgresRateLimitStore{}
+	db := store.CreatePostgresDB(*config, rateLimitStore.Models())
+	rateLimitStore.PostgresStore = &store.PostgresStore{
+		Config:   *config,
+		Postgres: db,
+	}
+	rateLimitStore.sweeper = startSweeper(sweepInterval, func() {
+		// A failed sweep is retried on the next tick, the rows it missed are reset when their keys are used anyway.
+		_, _ = rateLimitStore.DeleteExpired()
+	})
+	return rateLimitStore
+}
+
+// Update locks the key's row for the update, so replicas checking the same key take turns. Expired rows are reset
+// here rather than deleted, the sweep clears out the ones that aren't used again.
+func (s *PostgresRateLimitStore) Update(
+	key string,
+	ttl time.Duration,
+	update func(state *RateLimitState, now time.Time),
+) error {
+	return s.Postgres.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RateLimitRecord{Key: key, ExpiresAt: now}).Error
+		if err != nil {
+			return err
+		}
+		var record RateLimitRecord
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&record).Error
+		if err != nil {
+			return err
+		}
+		state := RateLimitState{}
+		if now.Before(record.ExpiresAt) {
+			state = RateLimitState{Value: record.Value, Previous: record.Previous, Start: record.Start}
+		}
+		update(&state, now)
+		return tx.Model(&RateLimitRecord{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"value":      state.Value,
+			"previous":   state.Previous,
+			"start":      state.Start,
+			"expires_at": now.Add(ttl),
+		}).Error
+	})
+}
+
+// DeleteExpired deletes the state of keys that have gone their ttl without an update.
+func (s *PostgresRateLimitStore) DeleteExpired() (int64, error) {
+	result := s.Postgres.Where("expires_at <= ?", time.Now()).Delete(&RateLimitRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// Close stops the sweep and closes the database connection.
+func (s *PostgresRateLimitStore) Close() error {
+	s.sweeper.Stop()
+	return s.PostgresStore.Close()
+}
+
+// sweeper calls sweep every interval until it's stopped.
+type sweeper struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startSweeper(interval time.Duration, sweep func()) *sweeper {
+	s := &sweeper{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+	return s
+}
+
+// Stop stops the sweeper, waiting for a sweep in progress to finish.
+func (s *sweeper) Stop() {
+	close(s.stop)
+	<-s.done
+}
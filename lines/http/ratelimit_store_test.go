@@ -0,0 +1,101 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"lines/lines/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimitStore(t *testing.T) {
+	rateLimits, err := NewRateLimitStore()
+	assert.Nil(t, err)
+	assert.IsType(t, &MemoryRateLimitStore{}, rateLimits)
+	assert.Nil(t, rateLimits.Close())
+
+	t.Setenv("RATE_LIMIT_STORE", "redis")
+	_, err = NewRateLimitStore()
+	assert.EqualError(t, err, `unknown RATE_LIMIT_STORE "redis", expected "memory" or "postgres"`)
+}
+
+func TestMemoryRateLimitStore_Update(t *testing.T) {
+	rateLimits := NewMemoryRateLimitStore(time.Minute)
+	defer rateLimits.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rateLimits.now = func() time.Time { return now }
+	increment := func(state *RateLimitState, at time.Time) {
+		assert.Equal(t, now, at)
+		state.Value++
+	}
+
+	assert.Nil(t, rateLimits.Update("a", time.Minute, increment))
+	assert.Nil(t, rateLimits.Update("a", time.Minute, increment))
+	assert.Nil(t, rateLimits.Update("b", time.Minute, increment))
+	assert.Equal(t, float64(2), rateLimits.entries["a"].state.Value)
+	assert.Equal(t, float64(1), rateLimits.entries["b"].state.Value)
+
+	// State that's gone its ttl without an update is reset.
+	now = now.Add(time.Minute)
+	assert.Nil(t, rateLimits.Update("a", time.Minute, increment))
+	assert.Equal(t, float64(1), rateLimits.entries["a"].state.Value)
+
+	rateLimits.deleteExpired()
+	assert.Contains(t, rateLimits.entries, "a")
+	assert.NotContains(t, rateLimits.entries, "b")
+}
+
+func TestMemoryRateLimitStore_Update_Concurrent(t *testing.T) {
+	rateLimits := NewMemoryRateLimitStore(time.Minute)
+	defer rateLimits.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = rateLimits.Update("a", time.Minute, func(state *RateLimitState, _ time.Time) { state.Value++ })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, float64(100), rateLimits.entries["a"].state.Value)
+}
+
+func TestMemoryRateLimitStore_Sweeps(t *testing.T) {
+	rateLimits := NewMemoryRateLimitStore(time.Millisecond)
+	assert.Nil(t, rateLimits.Update("a", time.Nanosecond, func(*RateLimitState, time.Time) {}))
+
+	assert.Eventually(t, func() bool {
+		rateLimits.mu.Lock()
+		defer rateLimits.mu.Unlock()
+		return len(rateLimits.entries) == 0
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, rateLimits.Close())
+}
+
+func TestPostgresRateLimitStore_Update_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "RATE_LIMIT")
+	rateLimits := NewPostgresRateLimitStore(time.Hour)
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{rateLimits}, func(t *testing.T) {
+		var seen []RateLimitState
+		increment := func(state *RateLimitState, now time.Time) {
+			seen = append(seen, *state)
+			state.Value++
+			state.Start = now
+		}
+
+		assert.Nil(t, rateLimits.Update("a", time.Minute, increment))
+		assert.Nil(t, rateLimits.Update("a", time.Minute, increment))
+		assert.Equal(t, float64(0), seen[0].Value)
+		assert.Equal(t, float64(1), seen[1].Value)
+
+		// Expired state is reset, then deleted.
+		assert.Nil(t, rateLimits.Update("b", -time.Second, increment))
+		assert.Nil(t, rateLimits.Update("b", -time.Second, increment))
+		assert.Equal(t, float64(0), seen[3].Value)
+		deleted, err := rateLimits.DeleteExpired()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
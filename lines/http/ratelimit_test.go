@@ -0,0 +1,187 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &RateLimitState{}
+
+	// The bucket starts full, so a burst of the limit is allowed.
+	for i := 0; i < 3; i++ {
+		status := TokenBucket{}.Allow(state, 3, 3*time.Second, start)
+		assert.True(t, status.Allowed)
+		assert.Equal(t, 2-i, status.Remaining)
+	}
+	status := TokenBucket{}.Allow(state, 3, 3*time.Second, start)
+	assert.False(t, status.Allowed)
+	assert.Equal(t, 0, status.Remaining)
+	assert.Equal(t, time.Second, status.RetryAfter)
+	assert.Equal(t, 3*time.Second, status.Reset)
+
+	// A token is added every second.
+	status = TokenBucket{}.Allow(state, 3, 3*time.Second, start.Add(time.Second))
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 0, status.Remaining)
+
+	// The bucket doesn't fill past the limit.
+	status = TokenBucket{}.Allow(state, 3, 3*time.Second, start.Add(time.Hour))
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 2, status.Remaining)
+	assert.Equal(t, time.Second, status.Reset)
+}
+
+func TestSlidingWindow_Allow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &RateLimitState{}
+
+	for i := 0; i < 4; i++ {
+		status := SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(time.Duration(i)*time.Second))
+		assert.True(t, status.Allowed)
+		assert.Equal(t, 3-i, status.Remaining)
+	}
+	status := SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(5*time.Second))
+	assert.False(t, status.Allowed)
+	assert.Equal(t, 0, status.Remaining)
+	// The window is full, so there's room once a quarter of it has slid out of the next window.
+	assert.Equal(t, 5*time.Second+2500*time.Millisecond, status.RetryAfter)
+	assert.Equal(t, 15*time.Second, status.Reset)
+
+	// Half way through the next window, half of the previous window's requests still count.
+	status = SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(15*time.Second))
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 1, status.Remaining)
+	status = SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(15*time.Second))
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 0, status.Remaining)
+	status = SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(15*time.Second))
+	assert.False(t, status.Allowed)
+	// There's room once the previous window's requests count for one.
+	assert.Equal(t, 2500*time.Millisecond, status.RetryAfter)
+
+	// Windows older than the previous one don't count.
+	status = SlidingWindow{}.Allow(state, 4, 10*time.Second, start.Add(time.Minute))
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 3, status.Remaining)
+}
+
+func TestNewRateLimitPolicy(t *testing.T) {
+	defaults := RateLimitPolicy{Name: "sign_in", Limit: 5, Window: time.Minute, Algorithm: SlidingWindow{}}
+
+	assert.Equal(t, defaults, NewRateLimitPolicy(defaults))
+
+	t.Setenv("RATE_LIMIT_SIGN_IN_LIMIT", "20")
+	t.Setenv("RATE_LIMIT_SIGN_IN_WINDOW_SECONDS", "3600")
+	t.Setenv("RATE_LIMIT_SIGN_IN_ALGORITHM", TokenBucketAlgorithm)
+	policy := NewRateLimitPolicy(defaults)
+	assert.Equal(t, 20, policy.Limit)
+	assert.Equal(t, time.Hour, policy.Window)
+	assert.Equal(t, TokenBucket{}, policy.Algorithm)
+}
+
+func TestKeyByUser(t *testing.T) {
+	key := KeyByUser(func(user string) string { return user })
+	engine := gin.New()
+	var keys []string
+	engine.GET("/", func(c *gin.Context) {
+		keys = append(keys, key(c))
+		setAuthentication(c, &Authentication[string, string]{Claims: "claims", User: "jake"})
+		keys = append(keys, key(c))
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"ip:10.0.0.1", "user:jake"}, keys)
+}
+
+func rateLimitedEngine(store RateLimitStore, policy RateLimitPolicy, logger logging.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/", RateLimit(store, policy, logger), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return engine
+}
+
+func TestRateLimit(t *testing.T) {
+	store := NewMemoryRateLimitStore(time.Minute)
+	defer store.Close()
+	policy := RateLimitPolicy{Name: "test", Limit: 2, Window: time.Minute}
+	engine := rateLimitedEngine(store, policy, logging.NewLogrusHandler("info"))
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get(RateLimitLimitHeader))
+		assert.Equal(t, strconv.Itoa(1-i), rr.Header().Get(RateLimitRemainingHeader))
+		assert.Equal(t, "2;w=60", rr.Header().Get(RateLimitPolicyHeader))
+		assert.NotEmpty(t, rr.Header().Get(RateLimitResetHeader))
+	}
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get(RateLimitRemainingHeader))
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+	problem := Problem{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, CodeRateLimited, problem.Code)
+
+	// Other clients have their own limit.
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	policies := []RateLimitPolicy{
+		{Name: "off", Limit: 0, Window: time.Minute},
+		{Name: "unkeyed", Limit: 1, Window: time.Minute, Key: func(*gin.Context) string { return "" }},
+	}
+	for _, policy := range policies {
+		t.Run(policy.Name, func(t *testing.T) {
+			engine := rateLimitedEngine(&failingRateLimitStore{}, policy, nil)
+			rr := httptest.NewRecorder()
+			engine.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Empty(t, rr.Header().Get(RateLimitLimitHeader))
+		})
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Update(string, time.Duration, func(*RateLimitState, time.Time)) error {
+	return errors.New("store down")
+}
+
+func (failingRateLimitStore) Close() error { return nil }
+
+func TestRateLimit_StoreErrorLetsRequestsThrough(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogrusHandler("info")
+	logger.Logrus.Out = &buf
+	engine := rateLimitedEngine(failingRateLimitStore{}, RateLimitPolicy{Name: "test", Limit: 1, Window: time.Minute}, logger)
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Contains(t, buf.String(), "Could not check the test rate limit: store down")
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"lines/lines/logging"
+	"lines/lines/reporting"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Recovery is middleware that turns a panicking handler into a 500 problem, so it doesn't take the server down. The
+// panic is logged with its stack and reported to the reporter, under a reference the problem shows the client, so
+// their complaint can be matched up with the report.
+func Recovery(logger logging.Logger, reporter reporting.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if err, ok := r.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				// net/http's way of aborting a response, it isn't a failure.
+				panic(r)
+			}
+			reference := reporting.NewID()
+			requestID := logging.RequestID(c.Request.Context())
+			RequestLogger(c, logger).With("reference", reference).Error(
+				"http",
+				c.Request.Method+" "+c.FullPath(),
+				fmt.Sprintf("Recovered from panic: %v\n%s", r, debug.Stack()),
+			)
+			reporter.Report(reporting.Report{
+				ID:      reference,
+				Type:    "panic",
+				Message: fmt.Sprint(r),
+				// Leave out this function and the runtime's panic machinery.
+				Frames:  reporting.Callers(2),
+				Request: reporting.NewRequest(c.Request),
+				Tags:    map[string]string{logging.RequestIDField: requestID, "route": c.FullPath()},
+				Time:    time.Now(),
+			})
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			problem := Internal("Something went wrong.")
+			problem.Reference = reference
+			AbortWithProblem(c, problem)
+		}()
+		c.Next()
+	}
+}
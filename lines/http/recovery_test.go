@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"lines/lines/reporting"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingReporter struct {
+	reporting.NoopReporter
+	reports []reporting.Report
+}
+
+func (r *recordingReporter) Report(report reporting.Report) {
+	r.reports = append(r.reports, report)
+}
+
+func recoveryEngine(reporter reporting.Reporter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestID(), Recovery(logging.NewLogrusHandler("fatal"), reporter))
+	engine.GET("/recovery/panics/:id", func(c *gin.Context) { panic("nil map") })
+	engine.GET("/recovery/written", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+		c.Writer.WriteHeaderNow()
+		panic("too late")
+	})
+	engine.GET("/recovery/aborts", func(c *gin.Context) { panic(http.ErrAbortHandler) })
+	return engine
+}
+
+func TestRecovery_RespondsWithReferencedProblem(t *testing.T) {
+	reporter := &recordingReporter{}
+	engine := recoveryEngine(reporter)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/recovery/panics/1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	engine.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	var problem Problem
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, CodeInternal, problem.Code)
+	assert.Equal(t, "Something went wrong.", problem.Detail)
+	assert.Len(t, problem.Reference, 32)
+	assert.Equal(t, rr.Header().Get(RequestIDHeader), problem.RequestID)
+
+	assert.Len(t, reporter.reports, 1)
+	report := reporter.reports[0]
+	assert.Equal(t, problem.Reference, report.ID)
+	assert.Equal(t, "panic", report.Type)
+	assert.Equal(t, "nil map", report.Message)
+	assert.Equal(t, map[string]string{logging.RequestIDField: problem.RequestID, "route": "/recovery/panics/:id"}, report.Tags)
+	assert.Equal(t, "http://example.com/recovery/panics/1", report.Request.URL)
+	assert.NotContains(t, report.Request.Headers, "Authorization")
+	// The innermost frame is the handler that panicked.
+	assert.Contains(t, report.Frames[len(report.Frames)-1].Function, "recoveryEngine")
+}
+
+func TestRecovery_WrittenResponse(t *testing.T) {
+	reporter := &recordingReporter{}
+	engine := recoveryEngine(reporter)
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/recovery/written", nil))
+
+	// The status is already sent, the panic is still reported.
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Len(t, reporter.reports, 1)
+}
+
+func TestRecovery_RepanicsAbortHandler(t *testing.T) {
+	reporter := &recordingReporter{}
+	engine := recoveryEngine(reporter)
+
+	assert.PanicsWithError(t, http.ErrAbortHandler.Error(), func() {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recovery/aborts", nil))
+	})
+	assert.Empty(t, reporter.reports)
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"lines/lines/logging"
+)
+
+// RequestIDHeader carries a request's ID, on requests from clients and other deployments and on responses.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID is middleware that gives every request an ID. The ID is taken from the request's X-Request-ID header,
+// or generated if it's missing or malformed, and echoed on the response. It's stored in the request's context, where
+// logging.FromContext adds it to logs, and outgoing gRPC calls, published events and enqueued jobs carry it on.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// RequestLogger returns the request-scoped logger, it adds the request's ID to every log.
+func RequestLogger(c *gin.Context, logger logging.Logger) logging.Logger {
+	return logging.FromContext(c.Request.Context(), logger)
+}
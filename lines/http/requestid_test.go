@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/internal"
+	"lines/lines/logging"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"accepted", "abc-123", "abc-123"},
+		{"generated", "", ""},
+		{"malformed", "abc\ndef", ""},
+		{"too long", strings.Repeat("a", 129), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			var inContext string
+			engine.GET("/", RequestID(), func(c *gin.Context) {
+				inContext = logging.RequestID(c.Request.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(RequestIDHeader, test.header)
+			rr := httptest.NewRecorder()
+
+			engine.ServeHTTP(rr, req)
+
+			echoed := rr.Header().Get(RequestIDHeader)
+			assert.Equal(t, inContext, echoed)
+			if test.expected != "" {
+				assert.Equal(t, test.expected, echoed)
+			} else {
+				assert.Len(t, echoed, 32)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.NewLogrusHandler("info")
+	logger.Logrus.Out = &buf
+	engine := gin.New()
+	engine.GET("/", RequestID(), func(c *gin.Context) {
+		RequestLogger(c, logger).Info("test", "TestRequestLogger", "handled")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, buf.String(), `"request_id":"abc"`)
+}
+
+func TestCreateEngine_RequestID(t *testing.T) {
+	engine := CreateEngine(&internal.MainConfig{CORSOrigins: []string{"http://localhost:3000"}, Logger: logging.NewLogrusHandler("fatal")})
+	engine.GET("/", func(c *gin.Context) {})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+
+	engine.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc", rr.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,356 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"lines/lines/store"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ResourceAction is one of the routes a resource registers.
+type ResourceAction string
+
+const (
+	ActionList     ResourceAction = "list"
+	ActionRetrieve ResourceAction = "retrieve"
+	ActionCreate   ResourceAction = "create"
+	ActionUpdate   ResourceAction = "update"
+	ActionDelete   ResourceAction = "delete"
+)
+
+// AllActions are the actions a resource registers by default.
+var AllActions = []ResourceAction{ActionList, ActionRetrieve, ActionCreate, ActionUpdate, ActionDelete}
+
+// The query parameters of a resource's list route, the rest are its filters.
+const (
+	PageParameter     = "page"
+	PageSizeParameter = "page_size"
+	OrderingParameter = "ordering"
+)
+
+// Page is a page of a resource's list.
+type Page[R any] struct {
+	// Count is how many models match the list's filters, across every page.
+	Count    int64 `json:"count"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Results  []R   `json:"results"`
+}
+
+// Validatable is a write DTO that validates itself, like the user app's UserSignUp. A resource validates DTOs that
+// implement it before writing them to a model.
+type Validatable interface {
+	Validate() *Problem
+}
+
+// Resource is a model's CRUD routes, the generic equivalent of hand-rolled list, retrieve, create, update and delete
+// endpoints. M is the model, R the DTO it's read as and W the DTO it's written with. Models are validated with their
+// Validate before they're saved.
+type Resource[M store.PostgresModel, R any, W any] struct {
+	// Name is the model in the docs and in problems, e.g. "invoice".
+	Name  string
+	Store store.ModelStore[M]
+	// Read turns a model into the DTO it's read as.
+	Read func(model M) R
+	// Write copies a DTO onto a model, a new model on create and the stored one on update.
+	Write func(dto W, model *M)
+	// Actions are the routes to register, AllActions if it's empty.
+	Actions []ResourceAction
+	// Filters are the columns the list can be filtered on, with a query parameter of the same name, e.g. ?status=paid.
+	Filters []string
+	// Ordering are the columns the list can be ordered by, with the ordering query parameter, e.g.
+	// ?ordering=-created_at,name.
+	Ordering []string
+	// DefaultOrdering orders lists that don't ask for an order, e.g. "-created_at". It defaults to "id", so pages are
+	// stable.
+	DefaultOrdering []string
+	// PageSize defaults to 20, MaxPageSize to 100.
+	PageSize    int
+	MaxPageSize int
+	// Permission is called before every action, with the model for the actions on one. Returning a problem, usually
+	// a 403, stops the action. A resource without one lets anyone do anything its routes allow.
+	Permission func(c *gin.Context, action ResourceAction, model *M) *Problem
+	// Scope restricts the actions on existing models to the ones matching the filters it returns, e.g. the current
+	// user's. It doesn't apply to create, Write sets up new models.
+	Scope func(c *gin.Context) map[string]any
+	// Tags and Auth document the routes, see Operation.
+	Tags []string
+	Auth string
+}
+
+// Register registers the resource's routes under relativePath: GET for the list, POST to create, and GET, PUT and
+// DELETE on relativePath/:id for a model.
+func (r *Resource[M, R, W]) Register(router HttpRouter, relativePath string) {
+	actions := r.Actions
+	if len(actions) == 0 {
+		actions = AllActions
+	}
+	var read R
+	var write W
+	itemPath := strings.TrimSuffix(relativePath, "/") + "/:id"
+	if slices.Contains(actions, ActionList) {
+		Handle(router, http.MethodGet, relativePath, r.operation(Operation{
+			Summary:    fmt.Sprintf("List %s models.", r.Name),
+			Parameters: r.listParameters(),
+			Responses:  map[int]any{http.StatusOK: Page[R]{}},
+		}), r.list)
+	}
+	if slices.Contains(actions, ActionCreate) {
+		Handle(router, http.MethodPost, relativePath, r.operation(Operation{
+			Summary:   fmt.Sprintf("Create a %s.", r.Name),
+			Request:   write,
+			Responses: map[int]any{http.StatusCreated: read},
+		}), r.create)
+	}
+	if slices.Contains(actions, ActionRetrieve) {
+		Handle(router, http.MethodGet, itemPath, r.operation(Operation{
+			Summary:   fmt.Sprintf("Get a %s.", r.Name),
+			Responses: map[int]any{http.StatusOK: read},
+		}), r.retrieve)
+	}
+	if slices.Contains(actions, ActionUpdate) {
+		Handle(router, http.MethodPut, itemPath, r.operation(Operation{
+			Summary:   fmt.Sprintf("Update a %s.", r.Name),
+			Request:   write,
+			Responses: map[int]any{http.StatusOK: read},
+		}), r.update)
+	}
+	if slices.Contains(actions, ActionDelete) {
+		Handle(router, http.MethodDelete, itemPath, r.operation(Operation{
+			Summary:   fmt.Sprintf("Delete a %s.", r.Name),
+			Responses: map[int]any{http.StatusNoContent: nil},
+		}), r.delete)
+	}
+}
+
+func (r *Resource[M, R, W]) operation(operation Operation) Operation {
+	operation.Tags = r.Tags
+	operation.Auth = r.Auth
+	return operation
+}
+
+func (r *Resource[M, R, W]) listParameters() []OpenAPIParameter {
+	parameters := []OpenAPIParameter{
+		{Name: PageParameter, In: "query", Schema: &Schema{Type: "integer"}},
+		{Name: PageSizeParameter, In: "query", Schema: &Schema{Type: "integer"}},
+	}
+	if len(r.Ordering) > 0 {
+		parameters = append(parameters, OpenAPIParameter{
+			Name:        OrderingParameter,
+			In:          "query",
+			Description: "Comma separated columns, prefixed with - for descending: " + strings.Join(r.Ordering, ", ") + ".",
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	for _, filter := range r.Filters {
+		parameters = append(parameters, OpenAPIParameter{Name: filter, In: "query", Schema: &Schema{Type: "string"}})
+	}
+	return parameters
+}
+
+func (r *Resource[M, R, W]) permitted(c *gin.Context, action ResourceAction, model *M) bool {
+	if r.Permission == nil {
+		return true
+	}
+	if problem := r.Permission(c, action, model); problem != nil {
+		AbortWithProblem(c, problem)
+		return false
+	}
+	return true
+}
+
+func (r *Resource[M, R, W]) scope(c *gin.Context) map[string]any {
+	filters := map[string]any{}
+	if r.Scope != nil {
+		for column, value := range r.Scope(c) {
+			filters[column] = value
+		}
+	}
+	return filters
+}
+
+func (r *Resource[M, R, W]) list(c *gin.Context) {
+	if !r.permitted(c, ActionList, nil) {
+		return
+	}
+	query, problem := r.listQuery(c)
+	if problem != nil {
+		AbortWithProblem(c, problem)
+		return
+	}
+	models, count, err := r.Store.List(query)
+	if err != nil {
+		AbortWithProblem(c, Internal(fmt.Sprintf("Could not list %s models.", r.Name)))
+		return
+	}
+	results := make([]R, 0, len(models))
+	for _, model := range models {
+		results = append(results, r.Read(model))
+	}
+	c.JSON(http.StatusOK, Page[R]{
+		Count:    count,
+		Page:     query.Offset/query.Limit + 1,
+		PageSize: query.Limit,
+		Results:  results,
+	})
+}
+
+// listQuery reads the list's page, ordering and filters from the request's query. The scope's filters win over the
+// request's, so a request can't filter its way out of its scope.
+func (r *Resource[M, R, W]) listQuery(c *gin.Context) (store.Query, *Problem) {
+	pageSize, maxPageSize := r.PageSize, r.MaxPageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if maxPageSize <= 0 {
+		maxPageSize = 100
+	}
+	page, problem := positiveQueryInt(c, PageParameter, 1)
+	if problem != nil {
+		return store.Query{}, problem
+	}
+	pageSize, problem = positiveQueryInt(c, PageSizeParameter, pageSize)
+	if problem != nil {
+		return store.Query{}, problem
+	}
+	pageSize = min(pageSize, maxPageSize)
+	if page-1 > math.MaxInt/pageSize {
+		return store.Query{}, BadRequest(fmt.Sprintf("%s is too large.", PageParameter))
+	}
+
+	ordering := r.DefaultOrdering
+	if len(ordering) == 0 {
+		ordering = []string{"id"}
+	}
+	if requested := c.Query(OrderingParameter); requested != "" {
+		ordering = strings.Split(requested, ",")
+		for _, column := range ordering {
+			if !slices.Contains(r.Ordering, strings.TrimPrefix(column, "-")) {
+				return store.Query{}, BadRequest(fmt.Sprintf("Can't order by %q.", column))
+			}
+		}
+	}
+
+	filters := map[string]any{}
+	for _, filter := range r.Filters {
+		if value, ok := c.GetQuery(filter); ok {
+			filters[filter] = value
+		}
+	}
+	for column, value := range r.scope(c) {
+		filters[column] = value
+	}
+	return store.Query{
+		Filters:  filters,
+		Ordering: ordering,
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	}, nil
+}
+
+func positiveQueryInt(c *gin.Context, parameter string, defaultValue int) (int, *Problem) {
+	raw, ok := c.GetQuery(parameter)
+	if !ok {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, BadRequest(fmt.Sprintf("%s must be a positive whole number.", parameter))
+	}
+	return value, nil
+}
+
+// get fetches the model the request's :id is of, within the scope, aborting the request if there isn't one.
+func (r *Resource[M, R, W]) get(c *gin.Context) *M {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		AbortWithProblem(c, NotFound(fmt.Sprintf("No %s matches the ID.", r.Name)))
+		return nil
+	}
+	model, err := r.Store.Get(uint(id), r.scope(c))
+	if err != nil {
+		AbortWithProblem(c, Internal(fmt.Sprintf("Could not get the %s.", r.Name)))
+		return nil
+	}
+	if model == nil {
+		AbortWithProblem(c, NotFound(fmt.Sprintf("No %s matches the ID.", r.Name)))
+		return nil
+	}
+	return model
+}
+
+// bind reads and validates the request's DTO, then writes it to the model and validates that.
+func (r *Resource[M, R, W]) bind(c *gin.Context, model *M) bool {
+	var dto W
+	err := c.BindJSON(&dto)
+	if err != nil {
+		AbortWithProblem(c, BindProblem(err))
+		return false
+	}
+	if validatable, ok := any(&dto).(Validatable); ok {
+		if problem := validatable.Validate(); problem != nil {
+			AbortWithProblem(c, problem)
+			return false
+		}
+	}
+	r.Write(dto, model)
+	if problem := ModelValidationProblem((*model).Validate()); problem != nil {
+		AbortWithProblem(c, problem)
+		return false
+	}
+	return true
+}
+
+func (r *Resource[M, R, W]) retrieve(c *gin.Context) {
+	model := r.get(c)
+	if model == nil || !r.permitted(c, ActionRetrieve, model) {
+		return
+	}
+	c.JSON(http.StatusOK, r.Read(*model))
+}
+
+func (r *Resource[M, R, W]) create(c *gin.Context) {
+	if !r.permitted(c, ActionCreate, nil) {
+		return
+	}
+	var model M
+	if !r.bind(c, &model) {
+		return
+	}
+	err := r.Store.Create(&model)
+	if err != nil {
+		AbortWithProblem(c, Internal(fmt.Sprintf("Could not create the %s.", r.Name)))
+		return
+	}
+	c.JSON(http.StatusCreated, r.Read(model))
+}
+
+func (r *Resource[M, R, W]) update(c *gin.Context) {
+	model := r.get(c)
+	if model == nil || !r.permitted(c, ActionUpdate, model) || !r.bind(c, model) {
+		return
+	}
+	err := r.Store.Update(model)
+	if err != nil {
+		AbortWithProblem(c, Internal(fmt.Sprintf("Could not update the %s.", r.Name)))
+		return
+	}
+	c.JSON(http.StatusOK, r.Read(*model))
+}
+
+func (r *Resource[M, R, W]) delete(c *gin.Context) {
+	model := r.get(c)
+	if model == nil || !r.permitted(c, ActionDelete, model) {
+		return
+	}
+	err := r.Store.Delete(model)
+	if err != nil {
+		AbortWithProblem(c, Internal(fmt.Sprintf("Could not delete the %s.", r.Name)))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,311 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/domain"
+	"lines/lines/store"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testNote struct {
+	ID      uint
+	OwnerID uint
+	Title   string
+}
+
+func (n testNote) Validate() []store.ModelValidationError {
+	if n.Title == "forbidden" {
+		return []store.ModelValidationError{{Field: "Title", Message: "Title can't be forbidden"}}
+	}
+	return nil
+}
+
+type testNoteRead struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+type testNoteWrite struct {
+	Title string `json:"title"`
+}
+
+func (w *testNoteWrite) Validate() *Problem {
+	var validationErrors []domain.DomainValidationErrors
+	if w.Title == "" {
+		validationErrors = domain.AddValidationError("title", "Title is required.", validationErrors)
+	}
+	return ValidationProblem(validationErrors)
+}
+
+// fakeNoteStore keeps notes in memory, it only supports the owner_id filter.
+type fakeNoteStore struct {
+	notes   []testNote
+	queries []store.Query
+	err     error
+}
+
+func (s *fakeNoteStore) matches(note testNote, filters map[string]any) bool {
+	owner, ok := filters["owner_id"]
+	return !ok || owner == note.OwnerID
+}
+
+func (s *fakeNoteStore) List(query store.Query) ([]testNote, int64, error) {
+	s.queries = append(s.queries, query)
+	var matching []testNote
+	for _, note := range s.notes {
+		if s.matches(note, query.Filters) {
+			matching = append(matching, note)
+		}
+	}
+	end := min(query.Offset+query.Limit, len(matching))
+	return matching[min(query.Offset, end):end], int64(len(matching)), s.err
+}
+
+func (s *fakeNoteStore) Get(id uint, filters map[string]any) (*testNote, error) {
+	for _, note := range s.notes {
+		if note.ID == id && s.matches(note, filters) {
+			return &note, s.err
+		}
+	}
+	return nil, s.err
+}
+
+func (s *fakeNoteStore) Create(note *testNote) error {
+	note.ID = uint(len(s.notes) + 1)
+	s.notes = append(s.notes, *note)
+	return s.err
+}
+
+func (s *fakeNoteStore) Update(note *testNote) error {
+	s.notes[note.ID-1] = *note
+	return s.err
+}
+
+func (s *fakeNoteStore) Delete(note *testNote) error {
+	s.notes = append(s.notes[:note.ID-1], s.notes[note.ID:]...)
+	return s.err
+}
+
+func newNoteResource(notes *fakeNoteStore) *Resource[testNote, testNoteRead, testNoteWrite] {
+	return &Resource[testNote, testNoteRead, testNoteWrite]{
+		Name:     "note",
+		Store:    notes,
+		Read:     func(note testNote) testNoteRead { return testNoteRead{ID: note.ID, Title: note.Title} },
+		Write:    func(dto testNoteWrite, note *testNote) { note.Title = dto.Title },
+		Filters:  []string{"title"},
+		Ordering: []string{"title", "id"},
+		PageSize: 2,
+	}
+}
+
+func serveResource(resource *Resource[testNote, testNoteRead, testNoteWrite], method string, target string, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	resource.Register(engine.Group("/test"), "/notes")
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rr
+}
+
+func TestResource_Register(t *testing.T) {
+	engine := gin.New()
+	resource := newNoteResource(&fakeNoteStore{})
+	resource.Register(engine.Group("/test"), "/notes")
+
+	var routes []string
+	for _, route := range engine.Routes() {
+		routes = append(routes, route.Method+" "+route.Path)
+	}
+	assert.ElementsMatch(t, []string{
+		"GET /test/notes", "POST /test/notes", "GET /test/notes/:id", "PUT /test/notes/:id", "DELETE /test/notes/:id",
+	}, routes)
+	assert.Empty(t, UndocumentedRoutes(engine))
+
+	document := NewOpenAPIDocument(engine, OpenAPIInfo{})
+	list := document.Paths["/test/notes"]["get"]
+	assert.Equal(t, "#/components/schemas/Page_testNoteRead", list.Responses["200"].Content["application/json"].Schema.Ref)
+	var parameters []string
+	for _, parameter := range list.Parameters {
+		parameters = append(parameters, parameter.Name)
+	}
+	assert.Equal(t, []string{PageParameter, PageSizeParameter, OrderingParameter, "title"}, parameters)
+
+	readOnly := gin.New()
+	resource.Actions = []ResourceAction{ActionList, ActionRetrieve}
+	resource.Register(readOnly, "/notes")
+	assert.Len(t, readOnly.Routes(), 2)
+}
+
+func TestResource_List(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}, {ID: 3, Title: "c"}}}
+	resource := newNoteResource(notes)
+
+	rr := serveResource(resource, "GET", "/test/notes?page=2&ordering=-title,id&title=c&other=1", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	page := Page[testNoteRead]{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, Page[testNoteRead]{Count: 3, Page: 2, PageSize: 2, Results: []testNoteRead{{ID: 3, Title: "c"}}}, page)
+	assert.Equal(t, store.Query{
+		Filters:  map[string]any{"title": "c"},
+		Ordering: []string{"-title", "id"},
+		Offset:   2,
+		Limit:    2,
+	}, notes.queries[0])
+}
+
+func TestResource_List_Defaults(t *testing.T) {
+	notes := &fakeNoteStore{}
+	resource := newNoteResource(notes)
+	resource.MaxPageSize = 5
+
+	rr := serveResource(resource, "GET", "/test/notes?page_size=50", "")
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"count": 0, "page": 1, "page_size": 5, "results": []}`, rr.Body.String())
+	assert.Equal(t, store.Query{Filters: map[string]any{}, Ordering: []string{"id"}, Limit: 5}, notes.queries[0])
+}
+
+func TestResource_List_BadQuery(t *testing.T) {
+	for _, query := range []string{
+		"page=0",
+		"page=x",
+		"page=" + strconv.Itoa(math.MaxInt),
+		"page_size=-1",
+		"ordering=secret",
+		"ordering=-secret",
+	} {
+		t.Run(query, func(t *testing.T) {
+			rr := serveResource(newNoteResource(&fakeNoteStore{}), "GET", "/test/notes?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestResource_Scope(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, OwnerID: 1, Title: "mine"}, {ID: 2, OwnerID: 2, Title: "theirs"}}}
+	resource := newNoteResource(notes)
+	resource.Filters = append(resource.Filters, "owner_id")
+	resource.Scope = func(c *gin.Context) map[string]any { return map[string]any{"owner_id": uint(1)} }
+
+	rr := serveResource(resource, "GET", "/test/notes?owner_id=2", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	// The scope wins over the request's filter.
+	assert.Equal(t, map[string]any{"owner_id": uint(1)}, notes.queries[0].Filters)
+
+	assert.Equal(t, http.StatusOK, serveResource(resource, "GET", "/test/notes/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveResource(resource, "GET", "/test/notes/2", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveResource(resource, "DELETE", "/test/notes/2", "").Code)
+	assert.Len(t, notes.notes, 2)
+}
+
+func TestResource_Retrieve(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, Title: "a"}}}
+
+	rr := serveResource(newNoteResource(notes), "GET", "/test/notes/1", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"id": 1, "title": "a"}`, rr.Body.String())
+
+	for _, target := range []string{"/test/notes/2", "/test/notes/x"} {
+		rr = serveResource(newNoteResource(notes), "GET", target, "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		problem := Problem{}
+		assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, "No note matches the ID.", problem.Detail)
+	}
+}
+
+func TestResource_Create(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"created", `{"title": "a"}`, http.StatusCreated, ""},
+		{"bad json", `{`, http.StatusBadRequest, CodeBadRequest},
+		{"invalid dto", `{}`, http.StatusBadRequest, CodeValidationFailed},
+		{"invalid model", `{"title": "forbidden"}`, http.StatusBadRequest, CodeValidationFailed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notes := &fakeNoteStore{}
+
+			rr := serveResource(newNoteResource(notes), "POST", "/test/notes", test.body)
+
+			assert.Equal(t, test.status, rr.Code)
+			if test.code == "" {
+				assert.JSONEq(t, `{"id": 1, "title": "a"}`, rr.Body.String())
+				assert.Equal(t, []testNote{{ID: 1, Title: "a"}}, notes.notes)
+				return
+			}
+			problem := Problem{}
+			assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, test.code, problem.Code)
+			assert.Empty(t, notes.notes)
+		})
+	}
+}
+
+func TestResource_Update(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, OwnerID: 3, Title: "a"}}}
+
+	rr := serveResource(newNoteResource(notes), "PUT", "/test/notes/1", `{"title": "b"}`)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"id": 1, "title": "b"}`, rr.Body.String())
+	// Fields the DTO doesn't write are kept.
+	assert.Equal(t, []testNote{{ID: 1, OwnerID: 3, Title: "b"}}, notes.notes)
+
+	rr = serveResource(newNoteResource(notes), "PUT", "/test/notes/1", `{"title": "forbidden"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "b", notes.notes[0].Title)
+}
+
+func TestResource_Delete(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, Title: "a"}}}
+
+	rr := serveResource(newNoteResource(notes), "DELETE", "/test/notes/1", "")
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, notes.notes)
+}
+
+func TestResource_Permission(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, Title: "a"}}}
+	resource := newNoteResource(notes)
+	var checked []ResourceAction
+	resource.Permission = func(c *gin.Context, action ResourceAction, note *testNote) *Problem {
+		checked = append(checked, action)
+		if action == ActionList || action == ActionRetrieve {
+			return nil
+		}
+		return Forbidden("Notes are read only.")
+	}
+
+	assert.Equal(t, http.StatusOK, serveResource(resource, "GET", "/test/notes", "").Code)
+	assert.Equal(t, http.StatusOK, serveResource(resource, "GET", "/test/notes/1", "").Code)
+	assert.Equal(t, http.StatusForbidden, serveResource(resource, "POST", "/test/notes", `{"title": "b"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serveResource(resource, "PUT", "/test/notes/1", `{"title": "b"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serveResource(resource, "DELETE", "/test/notes/1", "").Code)
+	assert.Equal(t, []ResourceAction{ActionList, ActionRetrieve, ActionCreate, ActionUpdate, ActionDelete}, checked)
+	assert.Equal(t, []testNote{{ID: 1, Title: "a"}}, notes.notes)
+}
+
+func TestResource_StoreError(t *testing.T) {
+	notes := &fakeNoteStore{notes: []testNote{{ID: 1, Title: "a"}}, err: assert.AnError}
+
+	for _, method := range []string{"GET", "POST"} {
+		rr := serveResource(newNoteResource(notes), method, "/test/notes", `{"title": "b"}`)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	}
+	rr := serveResource(newNoteResource(notes), "GET", "/test/notes/1", "")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"lines/internal"
+	"time"
+)
+
+// securityHeadersKey is the gin context key of the security headers config a request's responses are sent with.
+const securityHeadersKey = "lines.security_headers"
+
+// SecurityHeadersConfig is the security headers responses are sent with, a header is left out if it's empty.
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge is how long browsers only visit the site over HTTPS once they've seen the header, HSTS is off if
+	// it's 0.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	ContentSecurityPolicy string
+	// NoSniff stops browsers guessing responses' content types, with X-Content-Type-Options: nosniff.
+	NoSniff           bool
+	ReferrerPolicy    string
+	FrameOptions      string
+	PermissionsPolicy string
+}
+
+// NewSecurityHeadersConfig returns the production defaults for an API, which serves JSON that nothing should frame,
+// embed or run: HSTS for a year when the config uses SSL, a Content-Security-Policy that allows nothing, no referrer
+// and no browser features. Local development relaxes them to no Content-Security-Policy and same-origin framing, so
+// local frontends and tools can use the API.
+func NewSecurityHeadersConfig(config *internal.MainConfig) SecurityHeadersConfig {
+	headers := SecurityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		NoSniff:               true,
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "DENY",
+		PermissionsPolicy:     "accelerometer=(), camera=(), geolocation=(), gyroscope=(), microphone=(), payment=(), usb=()",
+	}
+	if config.UseSSL {
+		headers.HSTSMaxAge = 365 * 24 * time.Hour
+		headers.HSTSIncludeSubdomains = true
+	}
+	if config.LocalDev {
+		headers.ContentSecurityPolicy = ""
+		headers.FrameOptions = "SAMEORIGIN"
+	}
+	return headers
+}
+
+// write sets the headers on the response, removing the ones the config leaves out.
+func (h SecurityHeadersConfig) write(c *gin.Context) {
+	hsts := ""
+	if h.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(h.HSTSMaxAge.Seconds()))
+		if h.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	noSniff := ""
+	if h.NoSniff {
+		noSniff = "nosniff"
+	}
+	// c.Header removes a header when it's given an empty value.
+	c.Header("Strict-Transport-Security", hsts)
+	c.Header("Content-Security-Policy", h.ContentSecurityPolicy)
+	c.Header("X-Content-Type-Options", noSniff)
+	c.Header("Referrer-Policy", h.ReferrerPolicy)
+	c.Header("X-Frame-Options", h.FrameOptions)
+	c.Header("Permissions-Policy", h.PermissionsPolicy)
+}
+
+// SecurityHeaders is middleware that sends every response with the config's security headers. Route groups that
+// need other headers, like an HTML page that runs scripts, change them with OverrideSecurityHeaders.
+func SecurityHeaders(config SecurityHeadersConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(securityHeadersKey, config)
+		config.write(c)
+		c.Next()
+	}
+}
+
+// OverrideSecurityHeaders is middleware for a route group that changes the security headers SecurityHeaders set,
+// e.g. to loosen the Content-Security-Policy for a page:
+//
+//	pages := engine.Group("/pages", OverrideSecurityHeaders(func(headers *SecurityHeadersConfig) {
+//		headers.ContentSecurityPolicy = "default-src 'self'"
+//	}))
+func OverrideSecurityHeaders(override func(headers *SecurityHeadersConfig)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var headers SecurityHeadersConfig
+		if config, ok := c.Get(securityHeadersKey); ok {
+			headers = config.(SecurityHeadersConfig)
+		}
+		override(&headers)
+		c.Set(securityHeadersKey, headers)
+		headers.write(c)
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"lines/internal"
+	"lines/lines/logging"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewSecurityHeadersConfig(t *testing.T) {
+	production := NewSecurityHeadersConfig(&internal.MainConfig{UseSSL: true})
+	assert.Equal(t, 365*24*time.Hour, production.HSTSMaxAge)
+	assert.True(t, production.HSTSIncludeSubdomains)
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", production.ContentSecurityPolicy)
+	assert.True(t, production.NoSniff)
+	assert.Equal(t, "no-referrer", production.ReferrerPolicy)
+	assert.Equal(t, "DENY", production.FrameOptions)
+	assert.NotEmpty(t, production.PermissionsPolicy)
+
+	local := NewSecurityHeadersConfig(&internal.MainConfig{LocalDev: true})
+	assert.Zero(t, local.HSTSMaxAge)
+	assert.Empty(t, local.ContentSecurityPolicy)
+	assert.Equal(t, "SAMEORIGIN", local.FrameOptions)
+	assert.True(t, local.NoSniff)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(SecurityHeaders(SecurityHeadersConfig{
+		HSTSMaxAge:            time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: "default-src 'none'",
+		NoSniff:               true,
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "DENY",
+		PermissionsPolicy:     "camera=()",
+	}))
+	engine.GET("/api", func(c *gin.Context) { c.Status(http.StatusOK) })
+	pages := engine.Group("/pages", OverrideSecurityHeaders(func(headers *SecurityHeadersConfig) {
+		headers.ContentSecurityPolicy = "default-src 'self'"
+		headers.FrameOptions = ""
+	}))
+	pages.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", "/api", nil))
+	assert.Equal(t, "max-age=3600; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "default-src 'none'", rr.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "no-referrer", rr.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "camera=()", rr.Header().Get("Permissions-Policy"))
+
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", "/pages", nil))
+	assert.Equal(t, "default-src 'self'", rr.Header().Get("Content-Security-Policy"))
+	assert.NotContains(t, rr.Header(), "X-Frame-Options")
+	assert.Equal(t, "max-age=3600; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+}
+
+func TestCreateEngine_DocsContentSecurityPolicy(t *testing.T) {
+	engine := CreateEngine(&internal.MainConfig{CORSOrigins: []string{"http://localhost:3000"}, Logger: logging.NewLogrusHandler("fatal")})
+
+	rr := httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", OpenAPIPath, nil))
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rr.Header().Get("Content-Security-Policy"))
+
+	rr = httptest.NewRecorder()
+	engine.ServeHTTP(rr, httptest.NewRequest("GET", DocsPath, nil))
+	assert.Equal(t, docsContentSecurityPolicy, rr.Header().Get("Content-Security-Policy"))
+}
@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"lines/internal"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// MaxBodyBytes is the largest request body CreateEngine accepts, route groups change it with LimitBodySize.
+	MaxBodyBytes int64
+	// TLSCertFile and TLSKeyFile serve HTTPS, the server serves plain HTTP if they're empty. The files are checked
+	// for changes every TLSReloadInterval, so renewed certificates are served without a restart.
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSReloadInterval time.Duration
+	// RedirectAddr is where a plain HTTP server redirects requests to HTTPS, there isn't one if it's empty.
+	RedirectAddr string
+	// HTTP2 serves HTTP/2 to clients that support it over TLS.
+	HTTP2 bool
+}
+
+// NewServerConfig reads the server's settings from the environment, serving on the config's HTTP port.
+func NewServerConfig(config *internal.MainConfig) ServerConfig {
+	serverConfig := ServerConfig{
+		Addr:              ":" + strconv.Itoa(config.HTTPPort),
+		ReadTimeout:       envSeconds("HTTP_READ_TIMEOUT_SECONDS", 30),
+		ReadHeaderTimeout: envSeconds("HTTP_READ_HEADER_TIMEOUT_SECONDS", 10),
+		WriteTimeout:      envSeconds("HTTP_WRITE_TIMEOUT_SECONDS", 60),
+		IdleTimeout:       envSeconds("HTTP_IDLE_TIMEOUT_SECONDS", 120),
+		MaxHeaderBytes:    utils.GetEnvOrDefault("HTTP_MAX_HEADER_BYTES", strconv.Itoa(http.DefaultMaxHeaderBytes), "int").(int),
+		MaxBodyBytes:      int64(utils.GetEnvOrDefault("HTTP_MAX_BODY_BYTES", "10485760", "int").(int)),
+		TLSCertFile:       utils.GetEnvOrDefault("TLS_CERT_FILE", "", "string").(string),
+		TLSKeyFile:        utils.GetEnvOrDefault("TLS_KEY_FILE", "", "string").(string),
+		TLSReloadInterval: envSeconds("TLS_RELOAD_INTERVAL_SECONDS", 60),
+		HTTP2:             utils.GetEnvOrDefault("HTTP2", "true", "bool").(bool),
+	}
+	if redirectPort := utils.GetEnvOrDefault("HTTP_REDIRECT_PORT", "0", "int").(int); redirectPort > 0 {
+		serverConfig.RedirectAddr = ":" + strconv.Itoa(redirectPort)
+	}
+	return serverConfig
+}
+
+func envSeconds(key string, defaultSeconds int) time.Duration {
+	return time.Duration(utils.GetEnvOrDefault(key, strconv.Itoa(defaultSeconds), "int").(int)) * time.Second
+}
+
+// Server is the HttpServer implementation, wrapping an http.Server. With TLS configured it serves HTTPS, and
+// redirects plain HTTP to it if the config has a RedirectAddr.
+type Server struct {
+	*http.Server
+	config       ServerConfig
+	logger       logging.Logger
+	redirect     *http.Server
+	certificates *certificateReloader
+}
+
+// NewServer creates a server for the handler, it doesn't listen until ListenAndServe is called.
+func NewServer(config ServerConfig, handler http.Handler, logger logging.Logger) *Server {
+	s := &Server{
+		Server: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+			MaxHeaderBytes:    config.MaxHeaderBytes,
+		},
+		config: config,
+		logger: logger,
+	}
+	if !config.HTTP2 {
+		// A non-nil, empty TLSNextProto turns off the server's automatic HTTP/2.
+		s.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	if config.TLSCertFile == "" {
+		return s
+	}
+	s.certificates = newCertificateReloader(config.TLSCertFile, config.TLSKeyFile, logger)
+	s.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: s.certificates.GetCertificate,
+	}
+	if config.RedirectAddr != "" {
+		s.redirect = &http.Server{
+			Addr:              config.RedirectAddr,
+			Handler:           RedirectToHTTPS(config.Addr),
+			ReadHeaderTimeout: config.ReadHeaderTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		}
+	}
+	return s
+}
+
+// ListenAndServe serves until the server is shut down, over HTTPS if the config has a certificate. It returns the
+// first error of the server and the redirect server.
+func (s *Server) ListenAndServe() error {
+	if s.certificates == nil {
+		return s.Server.ListenAndServe()
+	}
+	err := s.certificates.reload()
+	if err != nil {
+		return err
+	}
+	go s.certificates.watch(s.config.TLSReloadInterval)
+
+	serverErrors := make(chan error, 2)
+	if s.redirect != nil {
+		go func() {
+			serverErrors <- s.redirect.ListenAndServe()
+		}()
+	}
+	go func() {
+		serverErrors <- s.Server.ListenAndServeTLS("", "")
+	}()
+	return <-serverErrors
+}
+
+// Shutdown drains the server and the redirect server, and stops reloading certificates.
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.Server.Shutdown(ctx)
+	if s.redirect != nil {
+		err = errors.Join(err, s.redirect.Shutdown(ctx))
+	}
+	if s.certificates != nil {
+		s.certificates.Stop()
+	}
+	return err
+}
+
+// RedirectToHTTPS redirects requests to the same URL over HTTPS, on the port of httpsAddr. Redirects are permanent
+// and keep the request's method.
+func RedirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// certificateReloader serves a certificate from files, loading it again when the files change.
+type certificateReloader struct {
+	certFile    string
+	keyFile     string
+	logger      logging.Logger
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	modified    time.Time
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+func newCertificateReloader(certFile string, keyFile string, logger logging.Logger) *certificateReloader {
+	return &certificateReloader{certFile: certFile, keyFile: keyFile, logger: logger, stop: make(chan struct{})}
+}
+
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.certificate == nil {
+		return nil, errors.New("the TLS certificate isn't loaded")
+	}
+	return r.certificate, nil
+}
+
+// modifiedAt is when the later of the files was last modified.
+func (r *certificateReloader) modifiedAt() (time.Time, error) {
+	var modified time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	return modified, nil
+}
+
+// reload loads the certificate if its files changed since it was last loaded.
+func (r *certificateReloader) reload() error {
+	modified, err := r.modifiedAt()
+	if err != nil {
+		return fmt.Errorf("failed to read the TLS certificate: %w", err)
+	}
+	r.mu.RLock()
+	unchanged := r.certificate != nil && modified.Equal(r.modified)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.certificate = &certificate
+	r.modified = modified
+	return nil
+}
+
+// watch reloads the certificate every interval until it's stopped. A certificate that fails to load, e.g. because
+// it's checked halfway through being replaced, is logged and the previous one is served until the next check.
+func (r *certificateReloader) watch(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.reload(); err != nil {
+				r.logger.Error("http", "certificateReloader.watch", err.Error())
+			}
+		}
+	}
+}
+
+func (r *certificateReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
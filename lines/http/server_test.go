@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"lines/internal"
+	"lines/lines/logging"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewServerConfig(t *testing.T) {
+	t.Setenv("HTTP_READ_TIMEOUT_SECONDS", "5")
+	t.Setenv("HTTP_MAX_BODY_BYTES", "1024")
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TLS_KEY_FILE", "key.pem")
+	t.Setenv("HTTP_REDIRECT_PORT", "8081")
+	t.Setenv("HTTP2", "false")
+
+	config := NewServerConfig(&internal.MainConfig{HTTPPort: 8443})
+
+	assert.Equal(t, ":8443", config.Addr)
+	assert.Equal(t, 5*time.Second, config.ReadTimeout)
+	assert.Equal(t, 10*time.Second, config.ReadHeaderTimeout)
+	assert.Equal(t, http.DefaultMaxHeaderBytes, config.MaxHeaderBytes)
+	assert.Equal(t, int64(1024), config.MaxBodyBytes)
+	assert.Equal(t, "cert.pem", config.TLSCertFile)
+	assert.Equal(t, "key.pem", config.TLSKeyFile)
+	assert.Equal(t, ":8081", config.RedirectAddr)
+	assert.False(t, config.HTTP2)
+}
+
+func TestNewServer(t *testing.T) {
+	handler := http.NotFoundHandler()
+	server := NewServer(ServerConfig{
+		Addr:              ":8080",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    1024,
+		RedirectAddr:      ":8081",
+	}, handler, logging.NewLogrusHandler("fatal"))
+
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, time.Second, server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, server.WriteTimeout)
+	assert.Equal(t, 4*time.Second, server.IdleTimeout)
+	assert.Equal(t, 1024, server.MaxHeaderBytes)
+	assert.NotNil(t, server.TLSNextProto)
+	// Without TLS there's nothing to redirect to.
+	assert.Nil(t, server.redirect)
+	assert.Nil(t, server.TLSConfig)
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	for httpsAddr, location := range map[string]string{
+		":443":  "https://example.com/v1/users?page=2",
+		":8443": "https://example.com:8443/v1/users?page=2",
+	} {
+		rr := httptest.NewRecorder()
+		RedirectToHTTPS(httpsAddr).ServeHTTP(rr, httptest.NewRequest("POST", "http://example.com:8080/v1/users?page=2", nil))
+
+		assert.Equal(t, http.StatusPermanentRedirect, rr.Code)
+		assert.Equal(t, location, rr.Header().Get("Location"))
+	}
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 with the serial number to the files.
+func writeCertificate(t *testing.T, certFile string, keyFile string, serial int64) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	// Make sure the files look modified, whatever the resolution of the file system's times.
+	modified := time.Now().Add(time.Duration(serial) * time.Second)
+	assert.Nil(t, os.Chtimes(certFile, modified, modified))
+	assert.Nil(t, os.Chtimes(keyFile, modified, modified))
+}
+
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServer_ServesTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, 1)
+	server := NewServer(ServerConfig{
+		Addr:              freeAddr(t),
+		TLSCertFile:       certFile,
+		TLSKeyFile:        keyFile,
+		TLSReloadInterval: 10 * time.Millisecond,
+		RedirectAddr:      freeAddr(t),
+		HTTP2:             true,
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}), logging.NewLogrusHandler("fatal"))
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- server.ListenAndServe()
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(url string) *http.Response {
+		var response *http.Response
+		assert.Eventually(t, func() bool {
+			var err error
+			response, err = client.Get(url)
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+		return response
+	}
+
+	response := get("https://" + server.Addr + "/")
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "HTTP/2.0", string(body))
+	assert.Equal(t, int64(1), response.TLS.PeerCertificates[0].SerialNumber.Int64())
+
+	// Renewed certificates are served without a restart.
+	writeCertificate(t, certFile, keyFile, 2)
+	assert.Eventually(t, func() bool {
+		response := get("https://" + server.Addr + "/")
+		client.CloseIdleConnections()
+		return response.TLS.PeerCertificates[0].SerialNumber.Int64() == 2
+	}, time.Second, 20*time.Millisecond)
+
+	response = get("http://" + server.redirect.Addr + "/path")
+	_, port, _ := net.SplitHostPort(server.Addr)
+	assert.Equal(t, http.StatusPermanentRedirect, response.StatusCode)
+	assert.Equal(t, "https://127.0.0.1:"+port+"/path", response.Header.Get("Location"))
+
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.True(t, errors.Is(<-serverErrors, http.ErrServerClosed))
+}
+
+func TestServer_ListenAndServe_MissingCertificate(t *testing.T) {
+	server := NewServer(ServerConfig{
+		Addr:        freeAddr(t),
+		TLSCertFile: filepath.Join(t.TempDir(), "cert.pem"),
+		TLSKeyFile:  filepath.Join(t.TempDir(), "key.pem"),
+	}, http.NotFoundHandler(), logging.NewLogrusHandler("fatal"))
+
+	err := server.ListenAndServe()
+
+	assert.ErrorContains(t, err, "failed to read the TLS certificate")
+}
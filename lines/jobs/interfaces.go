@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// DefaultQueue is the queue jobs go on unless they implement QueuedJob.
+const DefaultQueue = "default"
+
+// Job is a unit of background work, e.g. sending an email. It's serialised to JSON when it's enqueued.
+type Job interface {
+	// JobName identifies the job's type, e.g. "user.send_welcome_email".
+	JobName() string
+}
+
+// QueuedJob is an optional interface for jobs that run on a queue other than the default one.
+type QueuedJob interface {
+	Job
+	Queue() string
+}
+
+// Handler runs a job. A returned error, or a panic, fails the attempt and the job is retried later.
+type Handler func(ctx context.Context, job Job) error
+
+// Enqueuer adds jobs to the queue.
+type Enqueuer interface {
+	// Enqueue adds a job to its queue. It returns false if the job has a unique key and a job with the same key
+	// hasn't finished yet.
+	Enqueue(ctx context.Context, job Job, options ...EnqueueOption) (bool, error)
+}
+
+// Registry is what apps register their job handlers with, apps should keep it to enqueue jobs.
+type Registry interface {
+	Enqueuer
+	// Register sets the handler for jobs with the given name, a job name can only have one handler.
+	Register(name string, handler Handler) error
+}
+
+// Worker runs the jobs registered with it in the background.
+type Worker interface {
+	Registry
+	// Start starts working the configured queues.
+	Start()
+	// Stop stops claiming jobs and waits for running jobs to finish, cancelling them if ctx expires.
+	Stop(ctx context.Context) error
+}
+
+// Handle registers a handler for jobs of type T, decoding them from their stored JSON.
+func Handle[T Job](registry Registry, handler func(ctx context.Context, job T) error) error {
+	var zero T
+	return registry.Register(zero.JobName(), func(ctx context.Context, job Job) error {
+		switch j := job.(type) {
+		case T:
+			return handler(ctx, j)
+		case RawJob:
+			var typed T
+			err := json.Unmarshal(j.Payload, &typed)
+			if err != nil {
+				return err
+			}
+			return handler(ctx, typed)
+		default:
+			return &UnexpectedJobError{JobName: job.JobName()}
+		}
+	})
+}
+
+// UnexpectedJobError is returned when a job can't be converted to the type its handler was registered with.
+type UnexpectedJobError struct {
+	JobName string
+}
+
+func (e *UnexpectedJobError) Error() string {
+	return "unexpected type for job " + e.JobName
+}
+
+// RawJob is a job read back from the queue. Typed handlers decode its payload.
+type RawJob struct {
+	Name    string
+	Payload json.RawMessage
+}
+
+func (j RawJob) JobName() string {
+	return j.Name
+}
+
+// EnqueueOption changes how a job is enqueued.
+type EnqueueOption func(record *JobRecord)
+
+// WithUniqueKey stops the job being enqueued while an unfinished job has the same key.
+func WithUniqueKey(key string) EnqueueOption {
+	return func(record *JobRecord) {
+		record.UniqueKey = &key
+	}
+}
+
+// WithDelay runs the job no sooner than delay from now.
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(record *JobRecord) {
+		record.RunAt = time.Now().Add(delay)
+	}
+}
+
+// WithMaxAttempts overrides how many times the job is attempted before it's dead-lettered.
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return func(record *JobRecord) {
+		record.MaxAttempts = attempts
+	}
+}
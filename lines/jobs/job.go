@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"encoding/json"
+	"lines/lines/store"
+	"time"
+)
+
+// JobRecord is a job in the jobs table. A job is finished once it succeeds or runs out of attempts, jobs that ran
+// out of attempts are dead-lettered and kept until they're dealt with by hand.
+type JobRecord struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Queue     string `gorm:"index:idx_jobs_due"`
+	Name      string
+	Payload   []byte `gorm:"type:jsonb"`
+	// UniqueKey is unique among the unfinished jobs, so a key can be reused once its job has finished.
+	UniqueKey   *string `gorm:"uniqueIndex:idx_jobs_unique_key,where:finished_at IS NULL"`
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time  `gorm:"index:idx_jobs_due"`
+	FinishedAt  *time.Time `gorm:"index"`
+	// LockedUntil is when the lease of the worker running the job expires, it's nil while the job isn't running.
+	LockedUntil *time.Time
+	DeadAt      *time.Time `gorm:"index"`
+	LastError   string
+	// RequestID is the ID of the request the job was enqueued for, if any, the job's logs carry it.
+	RequestID string
+}
+
+func (JobRecord) TableName() string {
+	return "jobs"
+}
+
+func (r JobRecord) Validate() []store.ModelValidationError {
+	var errors []store.ModelValidationError
+	if r.Queue == "" {
+		errors = append(errors, store.ModelValidationError{Field: "Queue", Message: "Queue is required"})
+	}
+	if r.Name == "" {
+		errors = append(errors, store.ModelValidationError{Field: "Name", Message: "Name is required"})
+	}
+	if r.MaxAttempts < 1 {
+		errors = append(errors, store.ModelValidationError{Field: "MaxAttempts", Message: "MaxAttempts must be at least 1"})
+	}
+	return errors
+}
+
+// Job returns the record as a job that typed handlers can decode.
+func (r JobRecord) Job() RawJob {
+	return RawJob{Name: r.Name, Payload: r.Payload}
+}
+
+// NewJobRecord serialises a job into a JobRecord that's due now.
+func NewJobRecord(job Job, maxAttempts int, options ...EnqueueOption) (*JobRecord, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	queue := DefaultQueue
+	if queued, ok := job.(QueuedJob); ok {
+		queue = queued.Queue()
+	}
+	record := &JobRecord{
+		Queue:       queue,
+		Name:        job.JobName(),
+		Payload:     payload,
+		MaxAttempts: maxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, option := range options {
+		option(record)
+	}
+	return record, nil
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/store"
+	"testing"
+	"time"
+)
+
+type testJob struct {
+	ID int `json:"id"`
+}
+
+func (j testJob) JobName() string { return "test.job" }
+
+type emailJob struct {
+	To string `json:"to"`
+}
+
+func (j emailJob) JobName() string { return "test.email" }
+
+func (j emailJob) Queue() string { return "email" }
+
+func TestNewJobRecord(t *testing.T) {
+	record, err := NewJobRecord(testJob{ID: 1}, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultQueue, record.Queue)
+	assert.Equal(t, "test.job", record.Name)
+	assert.JSONEq(t, `{"id":1}`, string(record.Payload))
+	assert.Equal(t, 5, record.MaxAttempts)
+	assert.Nil(t, record.UniqueKey)
+	assert.WithinDuration(t, time.Now(), record.RunAt, time.Second)
+	assert.Empty(t, record.Validate())
+}
+
+func TestNewJobRecord_QueuedJob(t *testing.T) {
+	record, err := NewJobRecord(emailJob{To: "jake@example.com"}, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "email", record.Queue)
+}
+
+func TestNewJobRecord_Options(t *testing.T) {
+	record, err := NewJobRecord(
+		testJob{ID: 1},
+		5,
+		WithUniqueKey("test:1"),
+		WithDelay(time.Hour),
+		WithMaxAttempts(2),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "test:1", *record.UniqueKey)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), record.RunAt, time.Second)
+	assert.Equal(t, 2, record.MaxAttempts)
+}
+
+func TestJobRecord_Validate(t *testing.T) {
+	errors := JobRecord{}.Validate()
+	assert.Equal(t, []store.ModelValidationError{
+		{Field: "Queue", Message: "Queue is required"},
+		{Field: "Name", Message: "Name is required"},
+		{Field: "MaxAttempts", Message: "MaxAttempts must be at least 1"},
+	}, errors)
+}
+
+func TestJobRecord_Job(t *testing.T) {
+	record := JobRecord{Name: "test.job", Payload: []byte(`{"id":1}`)}
+	assert.Equal(t, RawJob{Name: "test.job", Payload: []byte(`{"id":1}`)}, record.Job())
+}
+
+type mockRegistry struct {
+	Registry
+	handlers map[string]Handler
+}
+
+func (m *mockRegistry) Register(name string, handler Handler) error {
+	m.handlers[name] = handler
+	return nil
+}
+
+func TestHandle(t *testing.T) {
+	registry := &mockRegistry{handlers: map[string]Handler{}}
+	var handled []testJob
+	err := Handle(registry, func(ctx context.Context, job testJob) error {
+		handled = append(handled, job)
+		return nil
+	})
+	assert.Nil(t, err)
+	handler := registry.handlers["test.job"]
+
+	assert.Nil(t, handler(context.Background(), testJob{ID: 1}))
+	assert.Nil(t, handler(context.Background(), RawJob{Name: "test.job", Payload: []byte(`{"id":2}`)}))
+	assert.NotNil(t, handler(context.Background(), RawJob{Name: "test.job", Payload: []byte(`not json`)}))
+	err = handler(context.Background(), emailJob{})
+	assert.Equal(t, &UnexpectedJobError{JobName: "test.email"}, err)
+	assert.Equal(t, []testJob{{ID: 1}, {ID: 2}}, handled)
+}
@@ -0,0 +1,263 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is the configuration for a PostgresQueue.
+type Config struct {
+	Logger logging.Logger
+	// Queues maps the queues this process works to how many of their jobs it runs at once.
+	Queues map[string]int
+	// PollInterval is how often an idle queue is checked for due jobs.
+	PollInterval time.Duration
+	// MaxAttempts is how many times a job is attempted before it's dead-lettered, unless it was enqueued
+	// WithMaxAttempts.
+	MaxAttempts int
+	// RetryBackoff is the delay before a failed job is first retried, it doubles with each attempt.
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the delay between retries.
+	MaxRetryBackoff time.Duration
+	// Retention is how long jobs that succeeded are kept before they're cleaned up.
+	Retention time.Duration
+	// Lease is how long a job can run before it's cancelled and another worker can claim it, it should be longer than
+	// the slowest job.
+	Lease time.Duration
+}
+
+// NewConfig creates a new Config, reading from environment variables.
+// JOBS_QUEUES lists the queues to work as name:concurrency pairs, e.g. "default:4,email:2".
+func NewConfig(logger logging.Logger) Config {
+	return Config{
+		Logger:          logger,
+		Queues:          parseQueues(utils.GetEnvOrDefault("JOBS_QUEUES", DefaultQueue+":4", "[]string").([]string)),
+		PollInterval:    time.Duration(utils.GetEnvOrDefault("JOBS_POLL_INTERVAL_MS", "1000", "int").(int)) * time.Millisecond,
+		MaxAttempts:     utils.GetEnvOrDefault("JOBS_MAX_ATTEMPTS", "10", "int").(int),
+		RetryBackoff:    time.Duration(utils.GetEnvOrDefault("JOBS_RETRY_BACKOFF_MS", "1000", "int").(int)) * time.Millisecond,
+		MaxRetryBackoff: time.Duration(utils.GetEnvOrDefault("JOBS_MAX_RETRY_BACKOFF_MS", "3600000", "int").(int)) * time.Millisecond,
+		Retention:       time.Duration(utils.GetEnvOrDefault("JOBS_RETENTION_HOURS", "168", "int").(int)) * time.Hour,
+		Lease:           time.Duration(utils.GetEnvOrDefault("JOBS_LEASE_MS", "300000", "int").(int)) * time.Millisecond,
+	}
+}
+
+// parseQueues parses name:concurrency pairs, a queue without a valid concurrency gets 1.
+func parseQueues(pairs []string) map[string]int {
+	queues := map[string]int{}
+	for _, pair := range pairs {
+		name, concurrency, _ := strings.Cut(strings.TrimSpace(pair), ":")
+		if name == "" {
+			continue
+		}
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 1 {
+			n = 1
+		}
+		queues[name] = n
+	}
+	return queues
+}
+
+// PostgresQueue is the Worker backed by a JobStore. Any process can enqueue jobs, the ones that call Start work
+// the queues in their config.
+type PostgresQueue struct {
+	config     Config
+	store      JobStore
+	mu         sync.RWMutex
+	handlers   map[string]Handler
+	stopping   chan struct{}
+	stopOnce   sync.Once
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewPostgresQueue creates a new PostgresQueue, call Start to begin working.
+func NewPostgresQueue(config Config, store JobStore) *PostgresQueue {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	return &PostgresQueue{
+		config:     config,
+		store:      store,
+		handlers:   map[string]Handler{},
+		stopping:   make(chan struct{}),
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+func (q *PostgresQueue) Register(name string, handler Handler) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.handlers[name]; ok {
+		return fmt.Errorf("job %s already has a handler", name)
+	}
+	q.handlers[name] = handler
+	return nil
+}
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, job Job, options ...EnqueueOption) (bool, error) {
+	record, err := NewJobRecord(job, q.config.MaxAttempts, options...)
+	if err != nil {
+		return false, err
+	}
+	record.RequestID = logging.RequestID(ctx)
+	return q.store.EnqueueJob(record)
+}
+
+func (q *PostgresQueue) Start() {
+	for queue, concurrency := range q.config.Queues {
+		for i := 0; i < concurrency; i++ {
+			q.wg.Add(1)
+			go q.work(queue)
+		}
+	}
+	q.wg.Add(1)
+	go q.cleanup()
+}
+
+// Stop stops claiming jobs and waits for the running ones to finish. If ctx expires first, the running jobs'
+// contexts are cancelled, and the jobs are retried later if they fail.
+func (q *PostgresQueue) Stop(ctx context.Context) error {
+	q.stopOnce.Do(func() {
+		close(q.stopping)
+	})
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+// Close releases the queue's store connections.
+func (q *PostgresQueue) Close() error {
+	return q.store.Close()
+}
+
+func (q *PostgresQueue) work(queue string) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stopping:
+			return
+		default:
+		}
+		claimed, err := q.RunNext(queue)
+		if err != nil {
+			q.config.Logger.Error("jobs", "PostgresQueue.work", fmt.Sprintf("Failed to run job on %s: %s", queue, err.Error()))
+		}
+		if claimed && err == nil {
+			continue
+		}
+		select {
+		case <-time.After(q.config.PollInterval):
+		case <-q.stopping:
+			return
+		}
+	}
+}
+
+func (q *PostgresQueue) cleanup() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		err := q.Cleanup()
+		if err != nil {
+			q.config.Logger.Error("jobs", "PostgresQueue.cleanup", fmt.Sprintf("Failed to clean up jobs: %s", err.Error()))
+		}
+		select {
+		case <-ticker.C:
+		case <-q.stopping:
+			return
+		}
+	}
+}
+
+// RunNext runs the next due job on the queue, it returns false if there wasn't one.
+func (q *PostgresQueue) RunNext(queue string) (bool, error) {
+	return q.store.RunNextJob(queue, q.config.Lease, q.run, q.backoff)
+}
+
+// Cleanup deletes jobs that succeeded longer ago than the retention period.
+func (q *PostgresQueue) Cleanup() error {
+	deleted, err := q.store.DeleteFinishedJobs(time.Now().Add(-q.config.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		q.config.Logger.Debug("jobs", "PostgresQueue.Cleanup", fmt.Sprintf("Deleted %d finished jobs", deleted))
+	}
+	return nil
+}
+
+// run runs a claimed job. The job runs in the context of the request it was enqueued for, so the request ID is on
+// its logs, and is cancelled when its lease expires.
+func (q *PostgresQueue) run(record JobRecord) error {
+	ctx, cancel := context.WithTimeout(q.jobsCtx, q.config.Lease)
+	defer cancel()
+	if record.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, record.RequestID)
+	}
+	err := q.handle(ctx, record)
+	if err == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx, q.config.Logger)
+	attempt := record.Attempts
+	if attempt >= record.MaxAttempts {
+		logger.Error(
+			"jobs",
+			"PostgresQueue.run",
+			fmt.Sprintf("Job %s %d failed after %d attempts, dead-lettering it: %s", record.Name, record.ID, attempt, err.Error()),
+		)
+	} else {
+		logger.Warn(
+			"jobs",
+			"PostgresQueue.run",
+			fmt.Sprintf("Job %s %d failed on attempt %d of %d: %s", record.Name, record.ID, attempt, record.MaxAttempts, err.Error()),
+		)
+	}
+	return err
+}
+
+// handle runs the job's handler, turning a panic into an error.
+func (q *PostgresQueue) handle(ctx context.Context, record JobRecord) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[record.Name]
+	q.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no handler is registered for job %s", record.Name)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v\n%s", record.Name, r, debug.Stack())
+		}
+	}()
+	return handler(ctx, record.Job())
+}
+
+// backoff returns how long to wait before retrying a job that has failed the given number of times.
+func (q *PostgresQueue) backoff(attempts int) time.Duration {
+	backoff := q.config.RetryBackoff
+	for i := 1; i < attempts && backoff < q.config.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > q.config.MaxRetryBackoff {
+		return q.config.MaxRetryBackoff
+	}
+	return backoff
+}
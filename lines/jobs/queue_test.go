@@ -0,0 +1,386 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	t.Setenv("JOBS_QUEUES", "default:2,email, broken:x,:3")
+	logger := logging.NewLogrusHandler("info")
+	config := NewConfig(logger)
+	assert.Equal(t, logger, config.Logger)
+	assert.Equal(t, map[string]int{"default": 2, "email": 1, "broken": 1}, config.Queues)
+	assert.Equal(t, time.Second, config.PollInterval)
+	assert.Equal(t, 10, config.MaxAttempts)
+	assert.Equal(t, time.Second, config.RetryBackoff)
+	assert.Equal(t, time.Hour, config.MaxRetryBackoff)
+	assert.Equal(t, 168*time.Hour, config.Retention)
+	assert.Equal(t, 5*time.Minute, config.Lease)
+}
+
+func TestNewConfig_Defaults(t *testing.T) {
+	config := NewConfig(logging.NewLogrusHandler("info"))
+	assert.Equal(t, map[string]int{DefaultQueue: 4}, config.Queues)
+}
+
+// fakeJobStore keeps jobs in memory, claiming them the same way PostgresJobStore does.
+type fakeJobStore struct {
+	mu      sync.Mutex
+	records []*JobRecord
+	running map[uint]bool
+	deleted []time.Time
+	err     error
+}
+
+func newFakeJobStore() *fakeJobStore {
+	return &fakeJobStore{running: map[uint]bool{}}
+}
+
+func (f *fakeJobStore) EnqueueJob(record *JobRecord) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	for _, existing := range f.records {
+		if record.UniqueKey != nil && existing.UniqueKey != nil && *existing.UniqueKey == *record.UniqueKey && existing.FinishedAt == nil {
+			return false, nil
+		}
+	}
+	record.ID = uint(len(f.records) + 1)
+	f.records = append(f.records, record)
+	return true, nil
+}
+
+func (f *fakeJobStore) RunNextJob(
+	queue string,
+	lease time.Duration,
+	run func(record JobRecord) error,
+	backoff func(attempts int) time.Duration,
+) (bool, error) {
+	f.mu.Lock()
+	if f.err != nil {
+		f.mu.Unlock()
+		return false, f.err
+	}
+	var claimed *JobRecord
+	for _, record := range f.records {
+		if record.Queue == queue && record.FinishedAt == nil && !record.RunAt.After(time.Now()) && !f.running[record.ID] {
+			claimed = record
+			break
+		}
+	}
+	if claimed == nil {
+		f.mu.Unlock()
+		return false, nil
+	}
+	f.running[claimed.ID] = true
+	claimed.Attempts++
+	record := *claimed
+	f.mu.Unlock()
+
+	runErr := run(record)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.running, claimed.ID)
+	now := time.Now()
+	if runErr == nil {
+		claimed.FinishedAt = &now
+		return true, nil
+	}
+	claimed.LastError = runErr.Error()
+	if claimed.Attempts >= claimed.MaxAttempts {
+		claimed.FinishedAt = &now
+		claimed.DeadAt = &now
+	} else {
+		claimed.RunAt = now.Add(backoff(claimed.Attempts))
+	}
+	return true, nil
+}
+
+func (f *fakeJobStore) DeleteFinishedJobs(before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, before)
+	return 0, f.err
+}
+
+func (f *fakeJobStore) Close() error {
+	return nil
+}
+
+func (f *fakeJobStore) record(id uint) JobRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.records[id-1]
+}
+
+type mockLogger struct {
+	logging.Logger
+	mu         sync.Mutex
+	WarnCalls  int
+	ErrorCalls int
+	WithFields []string
+}
+
+func (m *mockLogger) Warn(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.WarnCalls++
+}
+
+func (m *mockLogger) Error(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorCalls++
+}
+
+func (m *mockLogger) Debug(appName string, caller string, message string) {}
+
+func (m *mockLogger) With(key string, value string) logging.Logger {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.WithFields = append(m.WithFields, key+"="+value)
+	return m
+}
+
+func newTestQueue(store JobStore, logger logging.Logger) *PostgresQueue {
+	return NewPostgresQueue(Config{
+		Logger:          logger,
+		Queues:          map[string]int{DefaultQueue: 2},
+		PollInterval:    time.Millisecond,
+		MaxAttempts:     3,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 4 * time.Millisecond,
+		Retention:       time.Hour,
+		Lease:           time.Minute,
+	}, store)
+}
+
+func TestPostgresQueue_Register_Duplicate(t *testing.T) {
+	queue := newTestQueue(newFakeJobStore(), &mockLogger{})
+	handler := func(ctx context.Context, job Job) error { return nil }
+
+	assert.Nil(t, queue.Register("test.job", handler))
+	assert.EqualError(t, queue.Register("test.job", handler), "job test.job already has a handler")
+}
+
+func TestPostgresQueue_Enqueue(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+
+	enqueued, err := queue.Enqueue(context.Background(), testJob{ID: 1}, WithUniqueKey("test:1"))
+	assert.Nil(t, err)
+	assert.True(t, enqueued)
+	enqueued, err = queue.Enqueue(context.Background(), testJob{ID: 1}, WithUniqueKey("test:1"))
+	assert.Nil(t, err)
+	assert.False(t, enqueued)
+
+	assert.Len(t, store.records, 1)
+	assert.Equal(t, 3, store.records[0].MaxAttempts)
+}
+
+func TestPostgresQueue_RunNext(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	var handled []testJob
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		handled = append(handled, job)
+		return nil
+	}))
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+
+	claimed, err := queue.RunNext(DefaultQueue)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	claimed, err = queue.RunNext(DefaultQueue)
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+
+	assert.Equal(t, []testJob{{ID: 1}}, handled)
+	assert.NotNil(t, store.record(1).FinishedAt)
+	assert.Nil(t, store.record(1).DeadAt)
+}
+
+func TestPostgresQueue_RunNext_CancelsJobWhenLeaseExpires(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	queue.config.Lease = 10 * time.Millisecond
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+
+	_, err = queue.RunNext(DefaultQueue)
+
+	assert.Nil(t, err)
+	assert.Equal(t, context.DeadlineExceeded.Error(), store.record(1).LastError)
+}
+
+func TestPostgresQueue_RunNext_CarriesRequestID(t *testing.T) {
+	store := newFakeJobStore()
+	logger := &mockLogger{}
+	queue := newTestQueue(store, logger)
+	var requestIDs []string
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		requestIDs = append(requestIDs, logging.RequestID(ctx))
+		return assert.AnError
+	}))
+	_, err := queue.Enqueue(logging.WithRequestID(context.Background(), "abc"), testJob{ID: 1})
+	assert.Nil(t, err)
+	_, err = queue.Enqueue(context.Background(), testJob{ID: 2})
+	assert.Nil(t, err)
+
+	_, err = queue.RunNext(DefaultQueue)
+	assert.Nil(t, err)
+	_, err = queue.RunNext(DefaultQueue)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "abc", store.record(1).RequestID)
+	assert.Equal(t, []string{"abc", ""}, requestIDs)
+	assert.Equal(t, []string{"request_id=abc"}, logger.WithFields)
+	assert.Equal(t, 2, logger.WarnCalls)
+}
+
+func TestPostgresQueue_RunNext_RetriesThenDeadLetters(t *testing.T) {
+	store := newFakeJobStore()
+	logger := &mockLogger{}
+	queue := newTestQueue(store, logger)
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		return errors.New("smtp down")
+	}))
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.Eventually(t, func() bool {
+			claimed, err := queue.RunNext(DefaultQueue)
+			return claimed && err == nil
+		}, time.Second, time.Millisecond)
+	}
+
+	record := store.record(1)
+	assert.Equal(t, 3, record.Attempts)
+	assert.NotNil(t, record.DeadAt)
+	assert.Equal(t, "smtp down", record.LastError)
+	assert.Equal(t, 2, logger.WarnCalls)
+	assert.Equal(t, 1, logger.ErrorCalls)
+}
+
+func TestPostgresQueue_RunNext_RecoversPanics(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	assert.Nil(t, queue.Register("test.job", func(ctx context.Context, job Job) error {
+		panic("boom")
+	}))
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+
+	claimed, err := queue.RunNext(DefaultQueue)
+
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	assert.Contains(t, store.record(1).LastError, "job test.job panicked: boom")
+}
+
+func TestPostgresQueue_RunNext_NoHandler(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+
+	_, err = queue.RunNext(DefaultQueue)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "no handler is registered for job test.job", store.record(1).LastError)
+}
+
+func TestPostgresQueue_Backoff(t *testing.T) {
+	queue := newTestQueue(newFakeJobStore(), &mockLogger{})
+	assert.Equal(t, time.Millisecond, queue.backoff(1))
+	assert.Equal(t, 2*time.Millisecond, queue.backoff(2))
+	assert.Equal(t, 4*time.Millisecond, queue.backoff(3))
+	assert.Equal(t, 4*time.Millisecond, queue.backoff(10))
+}
+
+func TestPostgresQueue_StartStop_RunsJobsConcurrently(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}))
+	for i := 0; i < 3; i++ {
+		_, err := queue.Enqueue(context.Background(), testJob{ID: i})
+		assert.Nil(t, err)
+	}
+
+	queue.Start()
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Error("Expected at most 2 jobs to run at once")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		return store.record(3).FinishedAt != nil
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, queue.Stop(context.Background()))
+	assert.NotEmpty(t, store.deleted)
+}
+
+func TestPostgresQueue_Stop_CancelsJobsAfterDeadline(t *testing.T) {
+	store := newFakeJobStore()
+	queue := newTestQueue(store, &mockLogger{})
+	started := make(chan struct{})
+	assert.Nil(t, Handle(queue, func(ctx context.Context, job testJob) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	_, err := queue.Enqueue(context.Background(), testJob{ID: 1})
+	assert.Nil(t, err)
+	queue.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = queue.Stop(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Eventually(t, func() bool {
+		return store.record(1).LastError == context.Canceled.Error()
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, store.record(1).FinishedAt)
+}
+
+func TestPostgresQueue_StoreError_LogsError(t *testing.T) {
+	store := newFakeJobStore()
+	store.err = assert.AnError
+	logger := &mockLogger{}
+	queue := newTestQueue(store, logger)
+
+	queue.Start()
+	assert.Eventually(t, func() bool {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return logger.ErrorCalls > 0
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, queue.Stop(context.Background()))
+}
@@ -0,0 +1,135 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lines/lines/store"
+	"time"
+)
+
+// JobStore is the storage a PostgresQueue works against.
+type JobStore interface {
+	// EnqueueJob saves the record, it returns false if an unfinished job has the same unique key.
+	EnqueueJob(record *JobRecord) (bool, error)
+	// RunNextJob leases the next due job on the queue for the given duration and passes it to run, with the attempt
+	// counted in its Attempts. The job isn't claimed again until it finishes or its lease expires. A failed job is
+	// retried after backoff(attempts), or dead-lettered once it has no attempts left. It returns false if no job was
+	// due.
+	RunNextJob(
+		queue string,
+		lease time.Duration,
+		run func(record JobRecord) error,
+		backoff func(attempts int) time.Duration,
+	) (bool, error)
+	// DeleteFinishedJobs deletes jobs that succeeded before the given time, dead-lettered jobs are kept.
+	DeleteFinishedJobs(before time.Time) (int64, error)
+	Close() error
+}
+
+// PostgresJobStore keeps jobs in their own Postgres database.
+type PostgresJobStore struct {
+	*store.PostgresStore
+}
+
+func (s *PostgresJobStore) Models() []store.PostgresModel {
+	return []store.PostgresModel{
+		JobRecord{},
+	}
+}
+
+func (s *PostgresJobStore) Migrate() error {
+	return store.MigrateModels(s.Postgres, s.Models())
+}
+
+// NewPostgresJobStore connects to the JOBS_POSTGRES_URL database, the migrate command creates the jobs table.
+func NewPostgresJobStore() *PostgresJobStore {
+	config := store.CreatePostgresDBConfig("JOBS")
+	jobStore := &PostgresJobStore{}
+	db := store.CreatePostgresDB(*config, jobStore.Models())
+	jobStore.PostgresStore = &store.PostgresStore{
+		Config:   *config,
+		Postgres: db,
+	}
+	return jobStore
+}
+
+func (s *PostgresJobStore) EnqueueJob(record *JobRecord) (bool, error) {
+	result := s.Postgres.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+// claimJobSQL leases the next due job on a queue and counts the attempt. Leased jobs are skipped until their lease
+// expires, so several workers can share a queue without running a job twice at the same time, and a job whose worker
+// died is claimed again once its lease is up.
+const claimJobSQL = `
+UPDATE jobs
+SET locked_until = ?, attempts = attempts + 1
+WHERE id = (
+  SELECT id FROM jobs
+  WHERE queue = ?
+    AND finished_at IS NULL
+    AND run_at <= ?
+    AND (locked_until IS NULL OR locked_until <= ?)
+  ORDER BY run_at, id
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+func (s *PostgresJobStore) RunNextJob(
+	queue string,
+	lease time.Duration,
+	run func(record JobRecord) error,
+	backoff func(attempts int) time.Duration,
+) (bool, error) {
+	now := time.Now()
+	// Postgres keeps microseconds, the lease is compared with what's stored when the job finishes.
+	lockedUntil := now.Add(lease).Truncate(time.Microsecond)
+	var records []JobRecord
+	err := s.Postgres.Transaction(func(tx *gorm.DB) error {
+		return tx.Raw(claimJobSQL, lockedUntil, queue, now, now).Scan(&records).Error
+	})
+	if err != nil || len(records) == 0 {
+		return false, err
+	}
+	record := records[0]
+	var runErr error
+	if record.Attempts > record.MaxAttempts {
+		// The last attempt's worker died before the job finished.
+		runErr = errors.New("the job's lease expired before it finished")
+		record.Attempts = record.MaxAttempts
+	} else {
+		runErr = run(record)
+	}
+
+	now = time.Now()
+	update := map[string]interface{}{
+		"attempts":     record.Attempts,
+		"finished_at":  now,
+		"locked_until": nil,
+	}
+	if runErr != nil {
+		update["last_error"] = runErr.Error()
+		if record.Attempts >= record.MaxAttempts {
+			update["dead_at"] = now
+		} else {
+			update["finished_at"] = nil
+			update["run_at"] = now.Add(backoff(record.Attempts))
+		}
+	}
+	result := s.Postgres.Where("id = ? AND locked_until = ?", record.ID, lockedUntil).Model(&JobRecord{}).Updates(update)
+	if result.Error != nil {
+		return true, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return true, fmt.Errorf("job %s %d's lease expired before it finished", record.Name, record.ID)
+	}
+	return true, nil
+}
+
+func (s *PostgresJobStore) DeleteFinishedJobs(before time.Time) (int64, error) {
+	result := s.Postgres.Where("finished_at < ? AND dead_at IS NULL", before).Delete(&JobRecord{})
+	return result.RowsAffected, result.Error
+}
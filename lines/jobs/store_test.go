@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/store"
+	"testing"
+	"time"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestPostgresJobStore_EnqueueJob_UniqueKey_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	jobStore := NewPostgresJobStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{jobStore}, func(t *testing.T) {
+		first, err := NewJobRecord(testJob{ID: 1}, 3, WithUniqueKey("test:1"))
+		assert.Nil(t, err)
+		enqueued, err := jobStore.EnqueueJob(first)
+		assert.Nil(t, err)
+		assert.True(t, enqueued)
+
+		second, err := NewJobRecord(testJob{ID: 1}, 3, WithUniqueKey("test:1"))
+		assert.Nil(t, err)
+		enqueued, err = jobStore.EnqueueJob(second)
+		assert.Nil(t, err)
+		assert.False(t, enqueued)
+
+		// Once the first job has finished, the key can be used again.
+		claimed, err := jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error { return nil }, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+		third, err := NewJobRecord(testJob{ID: 1}, 3, WithUniqueKey("test:1"))
+		assert.Nil(t, err)
+		enqueued, err = jobStore.EnqueueJob(third)
+		assert.Nil(t, err)
+		assert.True(t, enqueued)
+	})
+}
+
+func TestPostgresJobStore_RunNextJob_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	jobStore := NewPostgresJobStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{jobStore}, func(t *testing.T) {
+		record, err := NewJobRecord(testJob{ID: 1}, 3)
+		assert.Nil(t, err)
+		_, err = jobStore.EnqueueJob(record)
+		assert.Nil(t, err)
+		later, err := NewJobRecord(testJob{ID: 2}, 3, WithDelay(time.Hour))
+		assert.Nil(t, err)
+		_, err = jobStore.EnqueueJob(later)
+		assert.Nil(t, err)
+
+		var ran []JobRecord
+		claimed, err := jobStore.RunNextJob(DefaultQueue, time.Minute, func(r JobRecord) error {
+			ran = append(ran, r)
+			return nil
+		}, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+		claimed, err = jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error { return nil }, noBackoff)
+		assert.Nil(t, err)
+		assert.False(t, claimed)
+
+		assert.Len(t, ran, 1)
+		assert.Equal(t, record.ID, ran[0].ID)
+		assert.JSONEq(t, `{"id":1}`, string(ran[0].Payload))
+		var stored JobRecord
+		assert.Nil(t, jobStore.Postgres.First(&stored, record.ID).Error)
+		assert.Equal(t, 1, stored.Attempts)
+		assert.NotNil(t, stored.FinishedAt)
+		assert.Nil(t, stored.DeadAt)
+	})
+}
+
+func TestPostgresJobStore_RunNextJob_RetriesThenDeadLetters_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	jobStore := NewPostgresJobStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{jobStore}, func(t *testing.T) {
+		record, err := NewJobRecord(testJob{ID: 1}, 2)
+		assert.Nil(t, err)
+		_, err = jobStore.EnqueueJob(record)
+		assert.Nil(t, err)
+		fail := func(JobRecord) error { return errors.New("smtp down") }
+
+		claimed, err := jobStore.RunNextJob(DefaultQueue, time.Minute, fail, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+		var stored JobRecord
+		assert.Nil(t, jobStore.Postgres.First(&stored, record.ID).Error)
+		assert.Equal(t, 1, stored.Attempts)
+		assert.Nil(t, stored.FinishedAt)
+		assert.Equal(t, "smtp down", stored.LastError)
+
+		claimed, err = jobStore.RunNextJob(DefaultQueue, time.Minute, fail, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+		stored = JobRecord{}
+		assert.Nil(t, jobStore.Postgres.First(&stored, record.ID).Error)
+		assert.Equal(t, 2, stored.Attempts)
+		assert.NotNil(t, stored.FinishedAt)
+		assert.NotNil(t, stored.DeadAt)
+
+		// Dead-lettered jobs are kept by the cleanup.
+		_, err = jobStore.DeleteFinishedJobs(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Nil(t, jobStore.Postgres.First(&JobRecord{}, record.ID).Error)
+	})
+}
+
+func TestPostgresJobStore_RunNextJob_Lease_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	jobStore := NewPostgresJobStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{jobStore}, func(t *testing.T) {
+		record, err := NewJobRecord(testJob{ID: 1}, 2)
+		assert.Nil(t, err)
+		_, err = jobStore.EnqueueJob(record)
+		assert.Nil(t, err)
+
+		// The job is leased while it runs, so it can't be claimed again.
+		claimed, err := jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error {
+			claimedAgain, err := jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error { return nil }, noBackoff)
+			assert.Nil(t, err)
+			assert.False(t, claimedAgain)
+			return errors.New("worker died")
+		}, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+
+		// A worker died on the last attempt and its lease expired, the job is dead-lettered without running.
+		expired := time.Now().Add(-time.Minute)
+		assert.Nil(t, jobStore.Postgres.Where("id = ?", record.ID).Model(&JobRecord{}).
+			Updates(map[string]interface{}{"attempts": 2, "locked_until": expired}).Error)
+		claimed, err = jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error {
+			t.Error("The job ran after its last attempt")
+			return nil
+		}, noBackoff)
+		assert.Nil(t, err)
+		assert.True(t, claimed)
+		var stored JobRecord
+		assert.Nil(t, jobStore.Postgres.First(&stored, record.ID).Error)
+		assert.Equal(t, 2, stored.Attempts)
+		assert.NotNil(t, stored.DeadAt)
+		assert.Nil(t, stored.LockedUntil)
+	})
+}
+
+func TestPostgresJobStore_DeleteFinishedJobs_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	jobStore := NewPostgresJobStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{jobStore}, func(t *testing.T) {
+		record, err := NewJobRecord(testJob{ID: 1}, 3)
+		assert.Nil(t, err)
+		_, err = jobStore.EnqueueJob(record)
+		assert.Nil(t, err)
+		_, err = jobStore.RunNextJob(DefaultQueue, time.Minute, func(JobRecord) error { return nil }, noBackoff)
+		assert.Nil(t, err)
+
+		deleted, err := jobStore.DeleteFinishedJobs(time.Now().Add(time.Hour))
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
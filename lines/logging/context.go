@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// RequestIDField is the field request-scoped loggers add the request ID to.
+const RequestIDField = "request_id"
+
+type requestIDKey struct{}
+
+// validRequestID stops callers putting arbitrary text in our logs through the IDs they send.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it's for. Work done on the request's behalf,
+// like calls to other deployments and background jobs, carries the ID on so their logs can be tied back to it.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of the request ctx is for, or "" if it isn't for one.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// ValidRequestID reports whether a request ID sent by a caller can be used, rather than generating one.
+func ValidRequestID(requestID string) bool {
+	return validRequestID.MatchString(requestID)
+}
+
+// NewRequestID generates a random request ID.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// FromContext returns the request-scoped logger for ctx, it adds the request ID to every log. It returns logger as is
+// if ctx isn't for a request.
+func FromContext(ctx context.Context, logger Logger) Logger {
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		return logger
+	}
+	return logger.With(RequestIDField, requestID)
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	if RequestID(context.Background()) != "" {
+		t.Error("Expected a background context to have no request ID")
+	}
+	ctx := WithRequestID(context.Background(), "abc")
+	if RequestID(ctx) != "abc" {
+		t.Errorf("Expected request ID abc, got %q", RequestID(ctx))
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	first, second := NewRequestID(), NewRequestID()
+	if len(first) != 32 {
+		t.Errorf("Expected a 32 character request ID, got %q", first)
+	}
+	if first == second {
+		t.Error("Expected request IDs to be random")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogrusHandler("info")
+	logger.Logrus.Out = &buf
+
+	if FromContext(context.Background(), logger) != Logger(logger) {
+		t.Error("Expected the logger to be returned as is outside a request")
+	}
+	FromContext(WithRequestID(context.Background(), "abc"), logger).Info("test", "TestFromContext", "message")
+
+	if !strings.Contains(buf.String(), `"request_id":"abc"`) {
+		t.Errorf("Expected the log to have the request ID, got %s", buf.String())
+	}
+}
@@ -11,6 +11,8 @@ type Logger interface {
 	Warn(appName string, caller string, message string)
 	Debug(appName string, caller string, message string)
 	Fatal(appName string, caller string, message string)
+	// With returns a logger that adds the field to every log it emits.
+	With(key string, value string) Logger
 }
 
 type StructuredLog struct {
@@ -32,6 +34,21 @@ func NewStructuredLog(appName string, caller, message string) *StructuredLog {
 
 type LogrusHandler struct {
 	Logrus *logrus.Logger
+	// fields are added to every log, e.g. the request ID of a request-scoped logger.
+	fields logrus.Fields
+}
+
+func (l *LogrusHandler) entry(appName string, caller string, message string) *logrus.Entry {
+	fields := logrus.Fields{
+		"caller":    caller,
+		"message":   message,
+		"app_name":  appName,
+		"timestamp": time.Now(),
+	}
+	for key, value := range l.fields {
+		fields[key] = value
+	}
+	return l.Logrus.WithFields(fields)
 }
 
 func (l *LogrusHandler) Info(
@@ -39,14 +56,7 @@ func (l *LogrusHandler) Info(
 	caller string,
 	message string,
 ) {
-	l.Logrus.WithFields(
-		logrus.Fields{
-			"caller":    caller,
-			"message":   message,
-			"app_name":  appName,
-			"timestamp": time.Now(),
-		},
-	).Info()
+	l.entry(appName, caller, message).Info()
 }
 
 func (l *LogrusHandler) Error(
@@ -54,14 +64,7 @@ func (l *LogrusHandler) Error(
 	caller string,
 	message string,
 ) {
-	l.Logrus.WithFields(
-		logrus.Fields{
-			"caller":    caller,
-			"message":   message,
-			"app_name":  appName,
-			"timestamp": time.Now(),
-		},
-	).Error()
+	l.entry(appName, caller, message).Error()
 }
 
 func (l *LogrusHandler) Warn(
@@ -69,14 +72,7 @@ func (l *LogrusHandler) Warn(
 	caller string,
 	message string,
 ) {
-	l.Logrus.WithFields(
-		logrus.Fields{
-			"caller":    caller,
-			"message":   message,
-			"app_name":  appName,
-			"timestamp": time.Now(),
-		},
-	).Warn()
+	l.entry(appName, caller, message).Warn()
 }
 
 func (l *LogrusHandler) Debug(
@@ -84,14 +80,7 @@ func (l *LogrusHandler) Debug(
 	caller string,
 	message string,
 ) {
-	l.Logrus.WithFields(
-		logrus.Fields{
-			"caller":    caller,
-			"message":   message,
-			"app_name":  appName,
-			"timestamp": time.Now(),
-		},
-	).Debug()
+	l.entry(appName, caller, message).Debug()
 }
 
 func (l *LogrusHandler) Fatal(
@@ -99,14 +88,17 @@ func (l *LogrusHandler) Fatal(
 	caller string,
 	message string,
 ) {
-	l.Logrus.WithFields(
-		logrus.Fields{
-			"caller":    caller,
-			"message":   message,
-			"app_name":  appName,
-			"timestamp": time.Now(),
-		},
-	).Fatal()
+	l.entry(appName, caller, message).Fatal()
+}
+
+// With returns a logger that adds the field to every log, sharing this logger's output and level.
+func (l *LogrusHandler) With(key string, value string) Logger {
+	fields := make(logrus.Fields, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return &LogrusHandler{Logrus: l.Logrus, fields: fields}
 }
 
 func NewLogrusHandler(level string) *LogrusHandler {
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -121,3 +122,22 @@ func TestLogrusHandler_Fatal(t *testing.T) {
 		t.Errorf("\n  output:  %s\nexpected: %s\n", logOutput, expected)
 	}
 }
+
+func TestLogrusHandler_With(t *testing.T) {
+	var buf bytes.Buffer
+	logrusHandler := NewLogrusHandler("debug")
+	logrusHandler.Logrus.Out = &buf
+
+	scoped := logrusHandler.With("request_id", "abc").With("job", "send_email")
+	scoped.Info("test", "TestWith", "This is a test log")
+	logrusHandler.Info("test", "TestWith", "This is a test log")
+
+	logs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := `{"app_name":"test","caller":"TestWith","job":"send_email","level":"info","message":"This is a test log","msg":"","request_id":"abc","time":"`
+	if !strings.HasPrefix(logs[0], expected) {
+		t.Errorf("\n  output:  %s\nexpected: %s\n", logs[0], expected)
+	}
+	if strings.Contains(logs[1], "request_id") {
+		t.Errorf("Expected the parent logger not to log the request ID, but it did: %s", logs[1])
+	}
+}
@@ -0,0 +1,119 @@
+package reporting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"lines/lines/logging"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Reporter forwards errors to an error tracker, so they're seen without trawling the logs.
+type Reporter interface {
+	// Report sends the report in the background, it doesn't block the caller on the tracker.
+	Report(report Report)
+	// Close sends the reports still queued, giving up when ctx is done.
+	Close(ctx context.Context) error
+}
+
+// Report is an error to report.
+type Report struct {
+	// ID is the report's reference, clients are shown it so the report can be found from their complaint.
+	ID string
+	// Type is the kind of error, e.g. "panic".
+	Type    string
+	Message string
+	// Frames is the stack the error happened in, oldest call first.
+	Frames  []Frame
+	Request *Request
+	// Tags are searchable values, e.g. the request ID.
+	Tags map[string]string
+	Time time.Time
+}
+
+// Frame is a call in a report's stack.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Request is the request a report happened in.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+}
+
+// scrubbedHeaders carry credentials, they aren't sent to the tracker.
+var scrubbedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Csrf-Token":  true,
+}
+
+// NewRequest copies what a report needs from the request, leaving out its credentials.
+func NewRequest(r *http.Request) *Request {
+	headers := map[string]string{}
+	for name, values := range r.Header {
+		if !scrubbedHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return &Request{
+		Method:  r.Method,
+		URL:     scheme + "://" + r.Host + r.URL.Path,
+		Headers: headers,
+	}
+}
+
+// NewID returns a random report ID, 32 hex characters like Sentry's event IDs.
+func NewID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Callers returns the calling goroutine's stack, oldest call first, leaving out the skip innermost calls above
+// Callers' caller.
+func Callers(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	callers := runtime.CallersFrames(pcs[:n])
+	var frames []Frame
+	for {
+		frame, more := callers.Next()
+		frames = append(frames, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// NewReporter returns a Sentry reporter for the DSN, or a NoopReporter if there isn't one.
+func NewReporter(dsn string, logger logging.Logger) (Reporter, error) {
+	if dsn == "" {
+		return NoopReporter{}, nil
+	}
+	return NewSentryReporter(dsn, logger)
+}
+
+// NoopReporter drops reports, for deployments without an error tracker. Errors are still logged.
+type NoopReporter struct{}
+
+func (NoopReporter) Report(Report) {}
+
+func (NoopReporter) Close(context.Context) error {
+	return nil
+}
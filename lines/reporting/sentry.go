@@ -0,0 +1,233 @@
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lines/lines/logging"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sentryQueueSize is how many reports can wait to be sent before new ones are dropped.
+const sentryQueueSize = 100
+
+// SentryDSN is where a Sentry project takes events, parsed from a DSN like
+// https://<public key>@o1.ingest.sentry.io/<project ID>.
+type SentryDSN struct {
+	// StoreURL is the project's event endpoint.
+	StoreURL  string
+	PublicKey string
+	SecretKey string
+}
+
+// ParseSentryDSN parses a DSN, returning an error describing what's wrong with it if it isn't one.
+func ParseSentryDSN(dsn string) (SentryDSN, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return SentryDSN{}, fmt.Errorf("SENTRY_DSN is not a URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return SentryDSN{}, errors.New("SENTRY_DSN must be an http or https URL")
+	}
+	if parsed.User == nil || parsed.User.Username() == "" {
+		return SentryDSN{}, errors.New("SENTRY_DSN has no public key")
+	}
+	// The project ID is the path's last segment, Sentry's API is under the path before it.
+	path := strings.TrimSuffix(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	path = path[:max(slash, 0)]
+	if projectID == "" {
+		return SentryDSN{}, errors.New("SENTRY_DSN has no project ID")
+	}
+	secretKey, _ := parsed.User.Password()
+	return SentryDSN{
+		StoreURL:  fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, path, projectID),
+		PublicKey: parsed.User.Username(),
+		SecretKey: secretKey,
+	}, nil
+}
+
+// authHeader is the X-Sentry-Auth header events are sent with.
+func (d SentryDSN) authHeader() string {
+	header := "Sentry sentry_version=7, sentry_client=lines/1.0, sentry_key=" + d.PublicKey
+	if d.SecretKey != "" {
+		header += ", sentry_secret=" + d.SecretKey
+	}
+	return header
+}
+
+// SentryReporter sends reports to a Sentry project as events, over Sentry's store API. Reports are queued and sent
+// by a goroutine, if the queue is full they're dropped, since reporting mustn't slow requests down.
+type SentryReporter struct {
+	dsn        SentryDSN
+	client     *http.Client
+	logger     logging.Logger
+	serverName string
+	events     chan sentryEvent
+	done       chan struct{}
+	mu         sync.RWMutex
+	closed     bool
+}
+
+// NewSentryReporter creates a reporter for the DSN and starts sending its reports.
+func NewSentryReporter(dsn string, logger logging.Logger) (*SentryReporter, error) {
+	parsed, err := ParseSentryDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	serverName, _ := os.Hostname()
+	r := &SentryReporter{
+		dsn:        parsed,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		logger:     logger,
+		serverName: serverName,
+		events:     make(chan sentryEvent, sentryQueueSize),
+		done:       make(chan struct{}),
+	}
+	go r.send()
+	return r, nil
+}
+
+type sentryEvent struct {
+	EventID    string            `json:"event_id"`
+	Timestamp  string            `json:"timestamp"`
+	Level      string            `json:"level"`
+	Platform   string            `json:"platform"`
+	ServerName string            `json:"server_name,omitempty"`
+	Exception  *sentryExceptions `json:"exception,omitempty"`
+	Request    *sentryRequest    `json:"request,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+}
+
+type sentryRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (r *SentryReporter) event(report Report) sentryEvent {
+	exception := sentryException{Type: report.Type, Value: report.Message}
+	if len(report.Frames) > 0 {
+		exception.Stacktrace = &sentryStacktrace{}
+		for _, frame := range report.Frames {
+			exception.Stacktrace.Frames = append(exception.Stacktrace.Frames, sentryFrame{
+				Function: frame.Function,
+				AbsPath:  frame.File,
+				Lineno:   frame.Line,
+			})
+		}
+	}
+	timestamp := report.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	event := sentryEvent{
+		EventID:    report.ID,
+		Timestamp:  timestamp.UTC().Format(time.RFC3339Nano),
+		Level:      "error",
+		Platform:   "go",
+		ServerName: r.serverName,
+		Exception:  &sentryExceptions{Values: []sentryException{exception}},
+		Tags:       report.Tags,
+	}
+	if report.Request != nil {
+		event.Request = &sentryRequest{
+			Method:  report.Request.Method,
+			URL:     report.Request.URL,
+			Headers: report.Request.Headers,
+		}
+	}
+	return event
+}
+
+func (r *SentryReporter) Report(report Report) {
+	if report.ID == "" {
+		report.ID = NewID()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.events <- r.event(report):
+	default:
+		r.logger.Warn("reporting", "SentryReporter.Report", fmt.Sprintf("The report queue is full, dropped report %s.", report.ID))
+	}
+}
+
+func (r *SentryReporter) send() {
+	defer close(r.done)
+	for event := range r.events {
+		err := r.post(event)
+		if err != nil {
+			r.logger.Warn("reporting", "SentryReporter.send", fmt.Sprintf("Failed to send report %s: %v", event.EventID, err))
+		}
+	}
+}
+
+func (r *SentryReporter) post(event sentryEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.dsn.StoreURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.dsn.authHeader())
+	response, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded %s", response.Status)
+	}
+	return nil
+}
+
+// Close stops taking reports and waits for the queued ones to be sent, or ctx to be done.
+func (r *SentryReporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
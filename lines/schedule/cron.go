@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"strings"
+	"time"
+)
+
+// Cron parses a standard five field cron expression, "minute hour day-of-month month day-of-week", e.g.
+// "*/15 9-17 * * mon-fri". Descriptors such as @daily and @hourly are accepted too, as is "@every <duration>" for an
+// interval. Expressions are evaluated in UTC unless they start with CRON_TZ=<zone>, so replicas in different time
+// zones agree on when a run is due.
+func Cron(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(expression)
+	if interval, ok := strings.CutPrefix(expression, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expression, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("cron expression %q: interval must be at least a second", expression)
+		}
+		return Every(d), nil
+	}
+	spec := expression
+	if !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = "CRON_TZ=UTC " + spec
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("cron expression %q: %w", expression, err)
+	}
+	// The parser accepts dates that don't exist, whose next run is the zero time.
+	if schedule.Next(time.Unix(0, 0)).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches a date", expression)
+	}
+	return schedule, nil
+}
+
+// MustCron is Cron for expressions that are known to be valid, it panics if the expression can't be parsed.
+func MustCron(expression string) Schedule {
+	schedule, err := Cron(expression)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// interval runs a task every fixed duration.
+type interval struct {
+	every time.Duration
+}
+
+// Every runs a task at a fixed, positive interval. Runs are aligned to multiples of the interval rather than to
+// when the scheduler started, so every replica agrees on when a run is due.
+func Every(every time.Duration) Schedule {
+	return interval{every: every}
+}
+
+func (i interval) Next(after time.Time) time.Time {
+	return after.Truncate(i.every).Add(i.every)
+}
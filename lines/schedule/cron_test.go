@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestCron_Next(t *testing.T) {
+	tests := []struct {
+		expression string
+		after      string
+		next       string
+	}{
+		{"* * * * *", "2024-05-01T10:15:30Z", "2024-05-01T10:16:00Z"},
+		{"*/15 * * * *", "2024-05-01T10:15:00Z", "2024-05-01T10:30:00Z"},
+		{"0 3 * * *", "2024-05-01T10:15:00Z", "2024-05-02T03:00:00Z"},
+		{"30 9-17/4 * * *", "2024-05-01T13:31:00Z", "2024-05-01T17:30:00Z"},
+		{"0 9 * * mon-fri", "2024-05-03T10:00:00Z", "2024-05-06T09:00:00Z"},
+		{"CRON_TZ=Europe/London 0 9 * * *", "2024-05-01T10:15:00Z", "2024-05-02T08:00:00Z"},
+		{"0 0 1,15 * *", "2024-05-02T00:00:00Z", "2024-05-15T00:00:00Z"},
+		// When both day fields are restricted, either one matching is enough.
+		{"0 0 13 * fri", "2024-05-01T00:00:00Z", "2024-05-03T00:00:00Z"},
+		{"0 0 29 feb *", "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"@hourly", "2024-05-01T10:15:00Z", "2024-05-01T11:00:00Z"},
+		{"@monthly", "2024-12-31T10:15:00Z", "2025-01-01T00:00:00Z"},
+		{"@every 10m", "2024-05-01T10:15:00Z", "2024-05-01T10:20:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			schedule, err := Cron(test.expression)
+			assert.Nil(t, err)
+			assert.Equal(t, at(test.next), schedule.Next(at(test.after)).UTC())
+		})
+	}
+}
+
+func TestCron_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"* * * *":       `cron expression "* * * *": expected exactly 5 fields, found 4: [* * * *]`,
+		"60 * * * *":    `cron expression "60 * * * *": end of range (60) above maximum (59): 60`,
+		"0 0 31 2 *":    `cron expression "0 0 31 2 *" never matches a date`,
+		"@every 10ms":   `cron expression "@every 10ms": interval must be at least a second`,
+		"@every always": `cron expression "@every always": time: invalid duration "always"`,
+	}
+	for expression, message := range tests {
+		t.Run(expression, func(t *testing.T) {
+			_, err := Cron(expression)
+			assert.EqualError(t, err, message)
+		})
+	}
+}
+
+func TestMustCron_Panics(t *testing.T) {
+	assert.Panics(t, func() { MustCron("nonsense") })
+}
+
+func TestEvery_Next(t *testing.T) {
+	schedule := Every(time.Hour)
+	assert.Equal(t, at("2024-05-01T11:00:00Z"), schedule.Next(at("2024-05-01T10:15:00Z")))
+	assert.Equal(t, at("2024-05-01T12:00:00Z"), schedule.Next(at("2024-05-01T11:00:00Z")))
+}
@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// Schedule decides when a task runs.
+type Schedule interface {
+	// Next returns the first time the task is due strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+// MissedRunPolicy decides what happens to runs that were due while no scheduler was running, e.g. during a deploy.
+type MissedRunPolicy int
+
+const (
+	// SkipMissed drops missed runs, the task next runs at its next scheduled time.
+	SkipMissed MissedRunPolicy = iota
+	// RunMissedOnce runs the task once when the scheduler starts if any runs were missed since its last run.
+	RunMissedOnce
+)
+
+// Task is a piece of periodic work, e.g. purging old rows.
+type Task struct {
+	// Name identifies the task across replicas, e.g. "user.purge_deleted_users".
+	Name     string
+	Schedule Schedule
+	// Run does the work. A returned error, or a panic, is recorded in the task's run history and the task runs
+	// again at its next scheduled time.
+	Run func(ctx context.Context) error
+	// Jitter is the most a run is randomly delayed past its scheduled time, zero uses the scheduler's default.
+	Jitter     time.Duration
+	MissedRuns MissedRunPolicy
+}
+
+// Registry is what apps register their scheduled tasks with.
+type Registry interface {
+	// Register adds a task, a task name can only be registered once.
+	Register(task Task) error
+}
+
+// Scheduler runs the tasks registered with it, each run happens on at most one replica.
+type Scheduler interface {
+	Registry
+	// Start starts running the registered tasks on their schedules.
+	Start()
+	// Stop stops scheduling runs and waits for running tasks to finish, cancelling them if ctx expires.
+	Stop(ctx context.Context) error
+}
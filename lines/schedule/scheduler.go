@@ -0,0 +1,266 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"math/rand"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Metrics are the scheduler's counters, published with expvar as "scheduled_tasks" and served by the worker on
+// METRICS_PORT. Each task has "<task>.runs", "<task>.failures", "<task>.skipped" for times another replica ran, and
+// "<task>.last_duration_ms".
+var Metrics = expvar.NewMap("scheduled_tasks")
+
+// Config is the configuration for a PostgresScheduler.
+type Config struct {
+	Logger logging.Logger
+	// Jitter is the most a run is randomly delayed past its scheduled time, so tasks due at the same time don't
+	// all start at once. Tasks can override it.
+	Jitter time.Duration
+	// Retention is how long the run history is kept.
+	Retention time.Duration
+}
+
+// NewConfig creates a new Config, reading from environment variables.
+func NewConfig(logger logging.Logger) Config {
+	return Config{
+		Logger:    logger,
+		Jitter:    time.Duration(utils.GetEnvOrDefault("SCHEDULER_JITTER_MS", "10000", "int").(int)) * time.Millisecond,
+		Retention: time.Duration(utils.GetEnvOrDefault("SCHEDULER_RETENTION_HOURS", "720", "int").(int)) * time.Hour,
+	}
+}
+
+// PostgresScheduler is the Scheduler backed by a ScheduleStore. Every replica can run it, the store makes sure
+// each scheduled time runs once.
+type PostgresScheduler struct {
+	config      Config
+	store       ScheduleStore
+	mu          sync.Mutex
+	tasks       map[string]Task
+	stopping    chan struct{}
+	stopOnce    sync.Once
+	tasksCtx    context.Context
+	cancelTasks context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewPostgresScheduler creates a new PostgresScheduler, call Start to begin running tasks.
+func NewPostgresScheduler(config Config, store ScheduleStore) *PostgresScheduler {
+	tasksCtx, cancelTasks := context.WithCancel(context.Background())
+	return &PostgresScheduler{
+		config:      config,
+		store:       store,
+		tasks:       map[string]Task{},
+		stopping:    make(chan struct{}),
+		tasksCtx:    tasksCtx,
+		cancelTasks: cancelTasks,
+	}
+}
+
+func (s *PostgresScheduler) Register(task Task) error {
+	if task.Name == "" {
+		return errors.New("a scheduled task must have a name")
+	}
+	if task.Run == nil || task.Schedule == nil {
+		return fmt.Errorf("scheduled task %s must have a schedule and a run function", task.Name)
+	}
+	now := time.Now()
+	if !task.Schedule.Next(now).After(now) {
+		return fmt.Errorf("scheduled task %s has a schedule that's never due", task.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[task.Name]; ok {
+		return fmt.Errorf("scheduled task %s is already registered", task.Name)
+	}
+	s.tasks[task.Name] = task
+	return nil
+}
+
+func (s *PostgresScheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.tasks {
+		s.wg.Add(1)
+		go s.schedule(task)
+	}
+	s.wg.Add(1)
+	go s.cleanup()
+}
+
+// Stop stops scheduling runs and waits for the running tasks to finish. If ctx expires first, the running tasks'
+// contexts are cancelled.
+func (s *PostgresScheduler) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+	})
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelTasks()
+		return ctx.Err()
+	}
+}
+
+// Close releases the scheduler's store connections.
+func (s *PostgresScheduler) Close() error {
+	return s.store.Close()
+}
+
+func (s *PostgresScheduler) schedule(task Task) {
+	defer s.wg.Done()
+	if task.MissedRuns == RunMissedOnce {
+		s.runMissed(task)
+	}
+	for {
+		scheduledFor := task.Schedule.Next(time.Now())
+		select {
+		case <-time.After(time.Until(scheduledFor) + s.jitter(task)):
+		case <-s.stopping:
+			return
+		}
+		s.runAndLog(task, scheduledFor)
+	}
+}
+
+// runMissed runs the task once if a scheduled time passed since its last run. A task that has never run hasn't
+// missed anything.
+func (s *PostgresScheduler) runMissed(task Task) {
+	last, err := s.store.LastRun(task.Name)
+	if err != nil {
+		s.config.Logger.Error(
+			"schedule",
+			"PostgresScheduler.runMissed",
+			fmt.Sprintf("Failed to read the last run of %s: %s", task.Name, err.Error()),
+		)
+		return
+	}
+	if last == nil {
+		return
+	}
+	missed := task.Schedule.Next(last.ScheduledFor)
+	if missed.After(time.Now()) {
+		return
+	}
+	s.config.Logger.Info(
+		"schedule",
+		"PostgresScheduler.runMissed",
+		fmt.Sprintf("Task %s missed its run at %s, running it now", task.Name, missed.Format(time.RFC3339)),
+	)
+	s.runAndLog(task, missed)
+}
+
+func (s *PostgresScheduler) runAndLog(task Task, scheduledFor time.Time) {
+	_, err := s.RunTask(task, scheduledFor)
+	if err != nil {
+		s.config.Logger.Error(
+			"schedule",
+			"PostgresScheduler.schedule",
+			fmt.Sprintf("Failed to run task %s: %s", task.Name, err.Error()),
+		)
+	}
+}
+
+// RunTask runs the task for the given scheduled time unless another replica is running it or already has. It
+// returns false if the task didn't run here, the task's own error is recorded in its history rather than returned.
+func (s *PostgresScheduler) RunTask(task Task, scheduledFor time.Time) (bool, error) {
+	started := time.Now()
+	var runErr error
+	ran, err := s.store.RunTask(task.Name, scheduledFor, func() error {
+		runErr = s.handle(task)
+		return runErr
+	})
+	if err != nil {
+		return ran, err
+	}
+	if !ran {
+		Metrics.Add(task.Name+".skipped", 1)
+		s.config.Logger.Debug(
+			"schedule",
+			"PostgresScheduler.RunTask",
+			fmt.Sprintf("Task %s at %s ran on another replica", task.Name, scheduledFor.Format(time.RFC3339)),
+		)
+		return false, nil
+	}
+	duration := new(expvar.Int)
+	duration.Set(time.Since(started).Milliseconds())
+	Metrics.Set(task.Name+".last_duration_ms", duration)
+	Metrics.Add(task.Name+".runs", 1)
+	if runErr != nil {
+		Metrics.Add(task.Name+".failures", 1)
+		s.config.Logger.Error(
+			"schedule",
+			"PostgresScheduler.RunTask",
+			fmt.Sprintf("Task %s at %s failed: %s", task.Name, scheduledFor.Format(time.RFC3339), runErr.Error()),
+		)
+	}
+	return true, nil
+}
+
+// handle runs the task, turning a panic into an error.
+func (s *PostgresScheduler) handle(task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task %s panicked: %v\n%s", task.Name, r, debug.Stack())
+		}
+	}()
+	return task.Run(s.tasksCtx)
+}
+
+// jitter returns a random delay of at most the task's jitter, or the configured jitter if the task has none.
+func (s *PostgresScheduler) jitter(task Task) time.Duration {
+	jitter := task.Jitter
+	if jitter == 0 {
+		jitter = s.config.Jitter
+	}
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jitter)))
+}
+
+func (s *PostgresScheduler) cleanup() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		err := s.Cleanup()
+		if err != nil {
+			s.config.Logger.Error(
+				"schedule",
+				"PostgresScheduler.cleanup",
+				fmt.Sprintf("Failed to clean up task runs: %s", err.Error()),
+			)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// Cleanup deletes the history of runs that finished longer ago than the retention period.
+func (s *PostgresScheduler) Cleanup() error {
+	deleted, err := s.store.DeleteRunsBefore(time.Now().Add(-s.config.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.config.Logger.Debug("schedule", "PostgresScheduler.Cleanup", fmt.Sprintf("Deleted %d task runs", deleted))
+	}
+	return nil
+}
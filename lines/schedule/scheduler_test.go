@@ -0,0 +1,283 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/logging"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	logger := logging.NewLogrusHandler("info")
+	config := NewConfig(logger)
+	assert.Equal(t, logger, config.Logger)
+	assert.Equal(t, 10*time.Second, config.Jitter)
+	assert.Equal(t, 720*time.Hour, config.Retention)
+}
+
+// fakeScheduleStore keeps runs in memory, running each scheduled time once the same way PostgresScheduleStore does.
+type fakeScheduleStore struct {
+	mu      sync.Mutex
+	runs    []TaskRun
+	deleted []time.Time
+	err     error
+}
+
+func newFakeScheduleStore() *fakeScheduleStore {
+	return &fakeScheduleStore{}
+}
+
+func (f *fakeScheduleStore) RunTask(name string, scheduledFor time.Time, run func() error) (bool, error) {
+	f.mu.Lock()
+	if f.err != nil {
+		f.mu.Unlock()
+		return false, f.err
+	}
+	for _, previous := range f.runs {
+		if previous.Task == name && previous.ScheduledFor.Equal(scheduledFor) {
+			f.mu.Unlock()
+			return false, nil
+		}
+	}
+	f.runs = append(f.runs, TaskRun{Task: name, ScheduledFor: scheduledFor, StartedAt: time.Now()})
+	index := len(f.runs) - 1
+	f.mu.Unlock()
+
+	runErr := run()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	finishedAt := time.Now()
+	f.runs[index].FinishedAt = &finishedAt
+	if runErr != nil {
+		f.runs[index].Error = runErr.Error()
+	}
+	return true, nil
+}
+
+func (f *fakeScheduleStore) LastRun(name string) (*TaskRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var last *TaskRun
+	for i, run := range f.runs {
+		if run.Task == name && (last == nil || run.ScheduledFor.After(last.ScheduledFor)) {
+			last = &f.runs[i]
+		}
+	}
+	return last, f.err
+}
+
+func (f *fakeScheduleStore) DeleteRunsBefore(before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, before)
+	return 0, f.err
+}
+
+func (f *fakeScheduleStore) Close() error {
+	return nil
+}
+
+func (f *fakeScheduleStore) runsOf(name string) []TaskRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var runs []TaskRun
+	for _, run := range f.runs {
+		if run.Task == name {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+type mockLogger struct {
+	logging.Logger
+	mu         sync.Mutex
+	InfoCalls  int
+	ErrorCalls int
+}
+
+func (m *mockLogger) Info(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InfoCalls++
+}
+
+func (m *mockLogger) Error(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorCalls++
+}
+
+func (m *mockLogger) Debug(appName string, caller string, message string) {}
+
+func newTestScheduler(store ScheduleStore, logger logging.Logger) *PostgresScheduler {
+	return NewPostgresScheduler(Config{
+		Logger:    logger,
+		Jitter:    -1,
+		Retention: time.Hour,
+	}, store)
+}
+
+func noop(context.Context) error { return nil }
+
+func TestPostgresScheduler_Register_Invalid(t *testing.T) {
+	scheduler := newTestScheduler(newFakeScheduleStore(), &mockLogger{})
+
+	assert.EqualError(t, scheduler.Register(Task{Schedule: Every(time.Hour), Run: noop}), "a scheduled task must have a name")
+	assert.EqualError(
+		t,
+		scheduler.Register(Task{Name: "test.task", Run: noop}),
+		"scheduled task test.task must have a schedule and a run function",
+	)
+	assert.EqualError(
+		t,
+		scheduler.Register(Task{Name: "test.task", Schedule: Every(0), Run: noop}),
+		"scheduled task test.task has a schedule that's never due",
+	)
+	assert.Nil(t, scheduler.Register(Task{Name: "test.task", Schedule: Every(time.Hour), Run: noop}))
+	assert.EqualError(
+		t,
+		scheduler.Register(Task{Name: "test.task", Schedule: Every(time.Hour), Run: noop}),
+		"scheduled task test.task is already registered",
+	)
+}
+
+func TestPostgresScheduler_RunTask(t *testing.T) {
+	store := newFakeScheduleStore()
+	scheduler := newTestScheduler(store, &mockLogger{})
+	calls := 0
+	task := Task{Name: "test.run_task", Schedule: Every(time.Hour), Run: func(context.Context) error {
+		calls++
+		return nil
+	}}
+	scheduledFor := at("2024-05-01T10:00:00Z")
+
+	ran, err := scheduler.RunTask(task, scheduledFor)
+	assert.Nil(t, err)
+	assert.True(t, ran)
+	// The same scheduled time doesn't run twice, e.g. on another replica.
+	ran, err = scheduler.RunTask(task, scheduledFor)
+	assert.Nil(t, err)
+	assert.False(t, ran)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "1", Metrics.Get("test.run_task.runs").String())
+	assert.Equal(t, "1", Metrics.Get("test.run_task.skipped").String())
+	assert.NotNil(t, Metrics.Get("test.run_task.last_duration_ms"))
+}
+
+func TestPostgresScheduler_RunTask_RecordsFailures(t *testing.T) {
+	store := newFakeScheduleStore()
+	logger := &mockLogger{}
+	scheduler := newTestScheduler(store, logger)
+	failing := Task{Name: "test.failing", Schedule: Every(time.Hour), Run: func(context.Context) error {
+		return errors.New("boom")
+	}}
+	panicking := Task{Name: "test.panicking", Schedule: Every(time.Hour), Run: func(context.Context) error {
+		panic("boom")
+	}}
+
+	ran, err := scheduler.RunTask(failing, at("2024-05-01T10:00:00Z"))
+	assert.Nil(t, err)
+	assert.True(t, ran)
+	ran, err = scheduler.RunTask(panicking, at("2024-05-01T10:00:00Z"))
+	assert.Nil(t, err)
+	assert.True(t, ran)
+
+	assert.Equal(t, "boom", store.runsOf("test.failing")[0].Error)
+	assert.Contains(t, store.runsOf("test.panicking")[0].Error, "task test.panicking panicked: boom")
+	assert.Equal(t, "1", Metrics.Get("test.failing.failures").String())
+	assert.Equal(t, 2, logger.ErrorCalls)
+}
+
+func TestPostgresScheduler_RunTask_StoreError(t *testing.T) {
+	store := newFakeScheduleStore()
+	store.err = assert.AnError
+	scheduler := newTestScheduler(store, &mockLogger{})
+
+	ran, err := scheduler.RunTask(Task{Name: "test.store_error", Schedule: Every(time.Hour), Run: noop}, time.Now())
+	assert.Equal(t, assert.AnError, err)
+	assert.False(t, ran)
+}
+
+func TestPostgresScheduler_StartStop_RunsOnSchedule(t *testing.T) {
+	store := newFakeScheduleStore()
+	scheduler := newTestScheduler(store, &mockLogger{})
+	assert.Nil(t, scheduler.Register(Task{Name: "test.every_second", Schedule: Every(time.Second), Run: noop}))
+
+	scheduler.Start()
+	assert.Eventually(t, func() bool {
+		return len(store.runsOf("test.every_second")) >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, scheduler.Stop(context.Background()))
+
+	runs := store.runsOf("test.every_second")
+	assert.Equal(t, time.Second, runs[1].ScheduledFor.Sub(runs[0].ScheduledFor))
+	assert.Len(t, store.deleted, 1)
+}
+
+func TestPostgresScheduler_Start_RunsMissedOnce(t *testing.T) {
+	store := newFakeScheduleStore()
+	logger := &mockLogger{}
+	lastRun := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	for _, name := range []string{"test.run_missed", "test.skip_missed"} {
+		store.runs = append(store.runs, TaskRun{Task: name, ScheduledFor: lastRun})
+	}
+	scheduler := newTestScheduler(store, logger)
+	assert.Nil(t, scheduler.Register(Task{
+		Name:       "test.run_missed",
+		Schedule:   Every(time.Hour),
+		Run:        noop,
+		MissedRuns: RunMissedOnce,
+	}))
+	assert.Nil(t, scheduler.Register(Task{Name: "test.skip_missed", Schedule: Every(time.Hour), Run: noop}))
+
+	scheduler.Start()
+	assert.Eventually(t, func() bool {
+		return len(store.runsOf("test.run_missed")) == 2
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, scheduler.Stop(context.Background()))
+
+	// Only the first missed run is made up, and only for the task that asked for it.
+	assert.Equal(t, lastRun.Add(time.Hour), store.runsOf("test.run_missed")[1].ScheduledFor)
+	assert.Len(t, store.runsOf("test.skip_missed"), 1)
+	assert.Equal(t, 1, logger.InfoCalls)
+}
+
+func TestPostgresScheduler_Stop_CancelsTasksAfterDeadline(t *testing.T) {
+	store := newFakeScheduleStore()
+	scheduler := newTestScheduler(store, &mockLogger{})
+	started := make(chan struct{})
+	assert.Nil(t, scheduler.Register(Task{
+		Name:     "test.slow",
+		Schedule: Every(time.Second),
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+
+	scheduler.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, scheduler.Stop(ctx))
+	assert.Eventually(t, func() bool {
+		runs := store.runsOf("test.slow")
+		return len(runs) == 1 && runs[0].Error == context.Canceled.Error()
+	}, time.Second, time.Millisecond)
+}
+
+func TestPostgresScheduler_Jitter(t *testing.T) {
+	scheduler := newTestScheduler(newFakeScheduleStore(), &mockLogger{})
+	assert.Equal(t, time.Duration(0), scheduler.jitter(Task{}))
+	for i := 0; i < 100; i++ {
+		jitter := scheduler.jitter(Task{Jitter: time.Millisecond})
+		assert.True(t, jitter >= 0 && jitter < time.Millisecond)
+	}
+}
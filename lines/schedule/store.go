@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"gorm.io/gorm/clause"
+	"lines/lines/store"
+	"time"
+)
+
+// TaskRun is a row in a task's run history. A run is recorded when a task starts for a scheduled time, so a time
+// can't be run twice even by different replicas, or by a replica that died mid-run.
+type TaskRun struct {
+	ID           uint      `gorm:"primarykey"`
+	Task         string    `gorm:"uniqueIndex:idx_task_runs_slot"`
+	ScheduledFor time.Time `gorm:"uniqueIndex:idx_task_runs_slot"`
+	StartedAt    time.Time `gorm:"index"`
+	// FinishedAt is nil while the task runs, and stays nil if its replica died mid-run.
+	FinishedAt *time.Time
+	// Error is empty if the run succeeded.
+	Error string
+}
+
+func (TaskRun) TableName() string {
+	return "scheduled_task_runs"
+}
+
+func (r TaskRun) Validate() []store.ModelValidationError {
+	var errors []store.ModelValidationError
+	if r.Task == "" {
+		errors = append(errors, store.ModelValidationError{Field: "Task", Message: "Task is required"})
+	}
+	return errors
+}
+
+// ScheduleStore is the storage a PostgresScheduler records task runs in.
+type ScheduleStore interface {
+	// RunTask records the run for the given scheduled time, runs the task and records how it finished. It returns
+	// false without running the task if another replica has already started that time.
+	RunTask(name string, scheduledFor time.Time, run func() error) (bool, error)
+	// LastRun returns the task's most recent run, or nil if it has never run.
+	LastRun(name string) (*TaskRun, error)
+	// DeleteRunsBefore deletes the history of runs that started before the given time.
+	DeleteRunsBefore(before time.Time) (int64, error)
+	Close() error
+}
+
+// PostgresScheduleStore keeps the run history in the jobs database. The run's row is committed before the task runs,
+// so its unique scheduled time means one replica runs it.
+type PostgresScheduleStore struct {
+	*store.PostgresStore
+}
+
+func (s *PostgresScheduleStore) Models() []store.PostgresModel {
+	return []store.PostgresModel{
+		TaskRun{},
+	}
+}
+
+func (s *PostgresScheduleStore) Migrate() error {
+	return store.MigrateModels(s.Postgres, s.Models())
+}
+
+// NewPostgresScheduleStore connects to the JOBS_POSTGRES_URL database, the migrate command creates the run history
+// table.
+func NewPostgresScheduleStore() *PostgresScheduleStore {
+	config := store.CreatePostgresDBConfig("JOBS")
+	scheduleStore := &PostgresScheduleStore{}
+	db := store.CreatePostgresDB(*config, scheduleStore.Models())
+	scheduleStore.PostgresStore = &store.PostgresStore{
+		Config:   *config,
+		Postgres: db,
+	}
+	return scheduleStore
+}
+
+func (s *PostgresScheduleStore) RunTask(name string, scheduledFor time.Time, run func() error) (bool, error) {
+	record := TaskRun{Task: name, ScheduledFor: scheduledFor, StartedAt: time.Now()}
+	result := s.Postgres.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	runErr := run()
+	update := map[string]interface{}{"finished_at": time.Now()}
+	if runErr != nil {
+		update["error"] = runErr.Error()
+	}
+	return true, s.Postgres.Where("id = ?", record.ID).Model(&TaskRun{}).Updates(update).Error
+}
+
+func (s *PostgresScheduleStore) LastRun(name string) (*TaskRun, error) {
+	var runs []TaskRun
+	err := s.Postgres.Where("task = ?", name).Order("scheduled_for DESC").Limit(1).Find(&runs).Error
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return &runs[0], nil
+}
+
+func (s *PostgresScheduleStore) DeleteRunsBefore(before time.Time) (int64, error) {
+	result := s.Postgres.Where("started_at < ?", before).Delete(&TaskRun{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/store"
+	"testing"
+	"time"
+)
+
+func TestPostgresScheduleStore_RunTask_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	scheduleStore := NewPostgresScheduleStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{scheduleStore}, func(t *testing.T) {
+		scheduledFor := time.Now().Truncate(time.Hour)
+		calls := 0
+
+		ran, err := scheduleStore.RunTask("test.task", scheduledFor, func() error {
+			calls++
+			running, err := scheduleStore.LastRun("test.task")
+			assert.Nil(t, err)
+			assert.Nil(t, running.FinishedAt, "The run is recorded before the task runs")
+			return errors.New("boom")
+		})
+		assert.Nil(t, err)
+		assert.True(t, ran)
+		ran, err = scheduleStore.RunTask("test.task", scheduledFor, func() error {
+			calls++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.False(t, ran)
+		assert.Equal(t, 1, calls)
+
+		last, err := scheduleStore.LastRun("test.task")
+		assert.Nil(t, err)
+		assert.Equal(t, "boom", last.Error)
+		assert.NotNil(t, last.FinishedAt)
+		assert.True(t, last.ScheduledFor.Equal(scheduledFor))
+	})
+}
+
+func TestPostgresScheduleStore_LastRun_NeverRun_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	scheduleStore := NewPostgresScheduleStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{scheduleStore}, func(t *testing.T) {
+		last, err := scheduleStore.LastRun("test.never_run")
+		assert.Nil(t, err)
+		assert.Nil(t, last)
+	})
+}
+
+func TestPostgresScheduleStore_DeleteRunsBefore_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "JOBS")
+	scheduleStore := NewPostgresScheduleStore()
+	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{scheduleStore}, func(t *testing.T) {
+		_, err := scheduleStore.RunTask("test.task", time.Now(), func() error { return nil })
+		assert.Nil(t, err)
+
+		deleted, err := scheduleStore.DeleteRunsBefore(time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), deleted)
+		deleted, err = scheduleStore.DeleteRunsBefore(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
@@ -34,7 +34,8 @@ func CreatePostgresDBConfig(AppName string) *PostgresDBConfig {
 
 // CreatePostgresDB creates a new PostgresDB instance.
 // It connects to the database using the provided configuration.
-// It also migrates the provided models to the database.
+// The schema is left to the migrate command, except that test databases are migrated to the provided models, so
+// integration tests run against the current models.
 func CreatePostgresDB(config PostgresDBConfig, models []PostgresModel) *gorm.DB {
 	db, err := gorm.Open(postgres.Open(config.ConnectionString), &gorm.Config{})
 	if err != nil {
@@ -45,15 +46,27 @@ func CreatePostgresDB(config PostgresDBConfig, models []PostgresModel) *gorm.DB
 		)
 		return nil
 	}
+	if !config.TestRunner {
+		return db
+	}
+	err = MigrateModels(db, models)
+	if err != nil {
+		config.Logger.Fatal(
+			config.AppName,
+			"CreatePostgresDB",
+			fmt.Sprintf("Failed to migrate the database: %v", err),
+		)
+	}
+	return db
+}
+
+// MigrateModels brings the models' tables up to date.
+func MigrateModels(db GormInstanceInterface, models []PostgresModel) error {
 	for _, model := range models {
-		err = db.AutoMigrate(model)
+		err := db.AutoMigrate(model)
 		if err != nil {
-			config.Logger.Fatal(
-				config.AppName,
-				"CreatePostgresDB",
-				fmt.Sprintf("Failed to migrate the database: %v", err),
-			)
+			return err
 		}
 	}
-	return db
+	return nil
 }
@@ -29,3 +29,34 @@ func TestCreatePostgresDBConfig_nonDefaults(t *testing.T) {
 	assert.Equal(t, "Testapp", config.AppName)
 	os.Clearenv()
 }
+
+type mockGormInstanceMigrate struct {
+	GormInstanceInterface
+	migrated []interface{}
+	err      error
+}
+
+func (m *mockGormInstanceMigrate) AutoMigrate(dst ...interface{}) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.migrated = append(m.migrated, dst...)
+	return nil
+}
+
+func TestMigrateModels(t *testing.T) {
+	db := &mockGormInstanceMigrate{}
+
+	err := MigrateModels(db, []PostgresModel{OutboxMessage{}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{OutboxMessage{}}, db.migrated)
+}
+
+func TestMigrateModels_Error(t *testing.T) {
+	db := &mockGormInstanceMigrate{err: assert.AnError}
+
+	err := MigrateModels(db, []PostgresModel{OutboxMessage{}})
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"lines/lines/logging"
 )
 
@@ -19,6 +20,7 @@ type PostgresStoreInterface interface {
 	BeginTransaction() error
 	RollbackTransaction() error
 	Models() []PostgresModel
+	Close() error
 }
 
 // PostgresStore is a struct that contains an initialized PostgresDB instance.
@@ -37,6 +39,15 @@ func (s *PostgresStore) RollbackTransaction() error {
 	return nil
 }
 
+// Close closes the underlying connection pool.
+func (s *PostgresStore) Close() error {
+	db, err := s.Postgres.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
 func (s *PostgresStore) Models() []PostgresModel {
 	return []PostgresModel{}
 }
@@ -64,8 +75,12 @@ type GormInstanceInterface interface {
 	Begin(opts ...*sql.TxOptions) *gorm.DB
 	Rollback() *gorm.DB
 	AutoMigrate(dst ...interface{}) error
+	Clauses(conds ...clause.Expression) *gorm.DB
 	Where(query interface{}, args ...interface{}) *gorm.DB
 	First(dest interface{}, conds ...interface{}) *gorm.DB
 	Save(value interface{}) *gorm.DB
 	Delete(value interface{}, conds ...interface{}) *gorm.DB
+	Unscoped() *gorm.DB
+	DB() (*sql.DB, error)
+	Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error
 }
@@ -55,3 +55,41 @@ func TestPostgresStore_RecordNotFound(t *testing.T) {
 	isError = store.RecordNotFound(errors.New("some error"))
 	assert.False(t, isError)
 }
+
+type MockGormInstanceDBError struct {
+	GormInstanceInterface
+}
+
+func (m *MockGormInstanceDBError) DB() (*sql.DB, error) {
+	return nil, assert.AnError
+}
+
+func TestPostgresStore_Close_DBError(t *testing.T) {
+	store := &PostgresStore{
+		Postgres: &MockGormInstanceDBError{},
+	}
+
+	err := store.Close()
+	assert.Equal(t, assert.AnError, err)
+}
+
+type MockGormInstanceWithDB struct {
+	GormInstanceInterface
+	db *sql.DB
+}
+
+func (m *MockGormInstanceWithDB) DB() (*sql.DB, error) {
+	return m.db, nil
+}
+
+func TestPostgresStore_Close(t *testing.T) {
+	db, err := sql.Open("pgx", "postgres://localhost:5432/test")
+	assert.Nil(t, err)
+	store := &PostgresStore{
+		Postgres: &MockGormInstanceWithDB{db: db},
+	}
+
+	err = store.Close()
+	assert.Nil(t, err)
+	assert.NotNil(t, db.Ping())
+}
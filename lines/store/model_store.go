@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// Query narrows down, orders and pages a list of models.
+type Query struct {
+	// Filters are equality conditions by column. Columns are quoted, so they're safe to take from requests once
+	// they've been checked against the columns that can be filtered on.
+	Filters map[string]any
+	// Ordering are the columns to order by, a column prefixed with "-" is in descending order.
+	Ordering []string
+	Offset   int
+	// Limit is the most models to return, there's no limit if it's 0.
+	Limit int
+}
+
+// ModelStore is the storage of one model, generic resources are built on it.
+type ModelStore[M PostgresModel] interface {
+	// List returns the models matching the query, and how many match its filters in total.
+	List(query Query) ([]M, int64, error)
+	// Get returns the model with the ID that also matches the filters, or nil if there isn't one.
+	Get(id uint, filters map[string]any) (*M, error)
+	Create(model *M) error
+	// Update saves every field of the model.
+	Update(model *M) error
+	Delete(model *M) error
+}
+
+// PostgresModelStore stores a model in the database of an app's PostgresStore.
+type PostgresModelStore[M PostgresModel] struct {
+	Postgres GormInstanceInterface
+}
+
+func NewPostgresModelStore[M PostgresModel](postgres GormInstanceInterface) *PostgresModelStore[M] {
+	return &PostgresModelStore[M]{Postgres: postgres}
+}
+
+// where starts a statement on the model's table with the filters applied. The statement can be reused, so a list
+// can be counted and fetched with the same conditions.
+func (s *PostgresModelStore[M]) where(filters map[string]any) *gorm.DB {
+	db := s.Postgres.Clauses().Model(new(M))
+	for column, value := range filters {
+		db = db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	}
+	return db.Session(&gorm.Session{})
+}
+
+func (s *PostgresModelStore[M]) List(query Query) ([]M, int64, error) {
+	db := s.where(query.Filters)
+	var count int64
+	err := db.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, column := range query.Ordering {
+		name, descending := strings.CutPrefix(column, "-")
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: name}, Desc: descending})
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	models := []M{}
+	err = db.Offset(query.Offset).Find(&models).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return models, count, nil
+}
+
+func (s *PostgresModelStore[M]) Get(id uint, filters map[string]any) (*M, error) {
+	var model M
+	err := s.where(filters).First(&model, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (s *PostgresModelStore[M]) Create(model *M) error {
+	return s.Postgres.Create(model).Error
+}
+
+func (s *PostgresModelStore[M]) Update(model *M) error {
+	return s.Postgres.Save(model).Error
+}
+
+func (s *PostgresModelStore[M]) Delete(model *M) error {
+	return s.Postgres.Delete(model).Error
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type testWidget struct {
+	gorm.Model
+	Name   string
+	Colour string
+}
+
+func (w testWidget) Validate() []ModelValidationError {
+	return nil
+}
+
+func TestPostgresModelStore_Integration(t *testing.T) {
+	SkipWithoutTestDB(t, "STORE")
+	// The test_widgets table is migrated into the STORE_POSTGRES_URL_TEST database.
+	config := CreatePostgresDBConfig("STORE")
+	postgresStore := &PostgresStore{Config: *config, Postgres: CreatePostgresDB(*config, []PostgresModel{testWidget{}})}
+	IsolatedIntegrationTest(t, []IntegrationTestStore{postgresStore}, func(t *testing.T) {
+		widgets := NewPostgresModelStore[testWidget](postgresStore.Postgres)
+		for _, widget := range []testWidget{{Name: "a", Colour: "red"}, {Name: "b", Colour: "blue"}, {Name: "c", Colour: "red"}} {
+			assert.Nil(t, widgets.Create(&widget))
+		}
+
+		listed, count, err := widgets.List(Query{
+			Filters:  map[string]any{"colour": "red"},
+			Ordering: []string{"-name"},
+			Limit:    1,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.Len(t, listed, 1)
+		assert.Equal(t, "c", listed[0].Name)
+
+		got, err := widgets.Get(listed[0].ID, map[string]any{"colour": "red"})
+		assert.Nil(t, err)
+		assert.Equal(t, "c", got.Name)
+		got, err = widgets.Get(listed[0].ID, map[string]any{"colour": "blue"})
+		assert.Nil(t, err)
+		assert.Nil(t, got)
+
+		listed[0].Name = "d"
+		assert.Nil(t, widgets.Update(&listed[0]))
+		got, err = widgets.Get(listed[0].ID, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "d", got.Name)
+
+		assert.Nil(t, widgets.Delete(got))
+		got, err = widgets.Get(listed[0].ID, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, got)
+	})
+}
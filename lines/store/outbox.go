@@ -0,0 +1,169 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"lines/lines/events"
+	"sort"
+	"time"
+)
+
+// OutboxMessage is an event waiting to be relayed. It is written in the same transaction as the change it
+// describes, so the event is recorded if and only if the change is committed.
+type OutboxMessage struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	AggregateType string `gorm:"index:idx_outbox_messages_aggregate"`
+	AggregateID   string `gorm:"index:idx_outbox_messages_aggregate"`
+	EventName     string
+	Payload       []byte `gorm:"type:jsonb"`
+	Attempts      int
+	NextAttemptAt time.Time  `gorm:"index"`
+	DeliveredAt   *time.Time `gorm:"index"`
+	LockedUntil   *time.Time
+	LastError     string
+}
+
+func (m OutboxMessage) Validate() []ModelValidationError {
+	var errors []ModelValidationError
+	if m.AggregateType == "" {
+		errors = append(errors, ModelValidationError{Field: "AggregateType", Message: "AggregateType is required"})
+	}
+	if m.AggregateID == "" {
+		errors = append(errors, ModelValidationError{Field: "AggregateID", Message: "AggregateID is required"})
+	}
+	if m.EventName == "" {
+		errors = append(errors, ModelValidationError{Field: "EventName", Message: "EventName is required"})
+	}
+	return errors
+}
+
+// Event returns the message as an event that typed subscribers can decode.
+func (m OutboxMessage) Event() events.RawEvent {
+	return events.RawEvent{Name: m.EventName, Payload: m.Payload}
+}
+
+// NewOutboxMessage serialises an event raised by an aggregate, e.g. the user with ID 1, into an OutboxMessage.
+func NewOutboxMessage(aggregateType string, aggregateID string, event events.Event) (*OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventName:     event.EventName(),
+		Payload:       payload,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// WriteOutbox records events raised by an aggregate in tx, so they're only relayed if tx commits.
+func WriteOutbox(tx *gorm.DB, aggregateType string, aggregateID string, outboxEvents ...events.Event) error {
+	for _, event := range outboxEvents {
+		message, err := NewOutboxMessage(aggregateType, aggregateID, event)
+		if err != nil {
+			return err
+		}
+		err = tx.Create(message).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutboxStore is the storage an OutboxRelay works against, PostgresStore implements it.
+type OutboxStore interface {
+	// RelayOutboxBatch leases up to limit due messages for the given duration, only the oldest undelivered message
+	// of each aggregate, and passes each one to deliver. Leased messages aren't claimed again until they're delivered
+	// or the lease expires. Failed deliveries are retried after backoff(attempts).
+	// It returns the number of messages claimed.
+	RelayOutboxBatch(
+		limit int,
+		lease time.Duration,
+		deliver func(message OutboxMessage) error,
+		backoff func(attempts int) time.Duration,
+	) (int, error)
+	// DeleteDeliveredOutboxMessages deletes messages delivered before the given time.
+	DeleteDeliveredOutboxMessages(before time.Time) (int64, error)
+}
+
+// claimOutboxMessagesSQL leases the due messages that are next in line for their aggregate. Leased messages are
+// skipped until their lease expires, so several relays can share a table without delivering a message twice at the
+// same time, and messages whose relay died are claimed again once the lease is up. A leased message still holds back
+// the later messages of its aggregate.
+const claimOutboxMessagesSQL = `
+UPDATE outbox_messages
+SET locked_until = ?
+WHERE id IN (
+  SELECT m.id FROM outbox_messages m
+  WHERE m.delivered_at IS NULL
+    AND m.next_attempt_at <= ?
+    AND (m.locked_until IS NULL OR m.locked_until <= ?)
+    AND NOT EXISTS (
+      SELECT 1 FROM outbox_messages earlier
+      WHERE earlier.aggregate_type = m.aggregate_type
+        AND earlier.aggregate_id = m.aggregate_id
+        AND earlier.delivered_at IS NULL
+        AND earlier.id < m.id
+    )
+  ORDER BY m.id
+  LIMIT ?
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+func (s *PostgresStore) RelayOutboxBatch(
+	limit int,
+	lease time.Duration,
+	deliver func(message OutboxMessage) error,
+	backoff func(attempts int) time.Duration,
+) (int, error) {
+	now := time.Now()
+	// Postgres keeps microseconds, the lease is compared with what's stored when each message is delivered.
+	lockedUntil := now.Add(lease).Truncate(time.Microsecond)
+	var messages []OutboxMessage
+	err := s.Postgres.Transaction(func(tx *gorm.DB) error {
+		return tx.Raw(claimOutboxMessagesSQL, lockedUntil, now, now, limit).Scan(&messages).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+
+	for i, message := range messages {
+		if !time.Now().Before(lockedUntil) {
+			// The rest of the batch is claimed again once the lease is up.
+			return len(messages), fmt.Errorf("the outbox lease expired with %d messages undelivered", len(messages)-i)
+		}
+		deliverErr := deliver(message)
+		now = time.Now()
+		update := map[string]interface{}{
+			"delivered_at": now,
+			"locked_until": nil,
+		}
+		if deliverErr != nil {
+			update = map[string]interface{}{
+				"attempts":        message.Attempts + 1,
+				"next_attempt_at": now.Add(backoff(message.Attempts + 1)),
+				"last_error":      deliverErr.Error(),
+				"locked_until":    nil,
+			}
+		}
+		result := s.Postgres.Where("id = ? AND locked_until = ?", message.ID, lockedUntil).Model(&OutboxMessage{}).Updates(update)
+		if result.Error != nil {
+			return len(messages), result.Error
+		}
+		if result.RowsAffected == 0 {
+			return len(messages), fmt.Errorf("outbox message %d's lease expired before it was delivered", message.ID)
+		}
+	}
+	return len(messages), nil
+}
+
+func (s *PostgresStore) DeleteDeliveredOutboxMessages(before time.Time) (int64, error) {
+	result := s.Postgres.Where("delivered_at < ?", before).Delete(&OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
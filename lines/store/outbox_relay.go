@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"lines/lines/events"
+	"lines/lines/logging"
+	"lines/lines/utils"
+	"time"
+)
+
+// OutboxRelayConfig is the configuration for an OutboxRelay.
+type OutboxRelayConfig struct {
+	AppName string
+	Logger  logging.Logger
+	// PollInterval is how often the outbox is checked for due messages.
+	PollInterval time.Duration
+	// BatchSize is the most messages claimed at once.
+	BatchSize int
+	// Lease is how long a claimed batch is held for delivery, messages left undelivered when it expires, e.g. because
+	// the relay died, are claimed again.
+	Lease time.Duration
+	// RetryBackoff is the delay before a failed message is first retried, it doubles with each attempt.
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the delay between retries.
+	MaxRetryBackoff time.Duration
+	// Retention is how long delivered messages are kept before they're cleaned up.
+	Retention time.Duration
+}
+
+// NewOutboxRelayConfig creates a new OutboxRelayConfig, reading from environment variables.
+func NewOutboxRelayConfig(appName string, logger logging.Logger) OutboxRelayConfig {
+	return OutboxRelayConfig{
+		AppName:         appName,
+		Logger:          logger,
+		PollInterval:    time.Duration(utils.GetEnvOrDefault("OUTBOX_POLL_INTERVAL_MS", "1000", "int").(int)) * time.Millisecond,
+		BatchSize:       utils.GetEnvOrDefault("OUTBOX_BATCH_SIZE", "100", "int").(int),
+		Lease:           time.Duration(utils.GetEnvOrDefault("OUTBOX_LEASE_MS", "300000", "int").(int)) * time.Millisecond,
+		RetryBackoff:    time.Duration(utils.GetEnvOrDefault("OUTBOX_RETRY_BACKOFF_MS", "1000", "int").(int)) * time.Millisecond,
+		MaxRetryBackoff: time.Duration(utils.GetEnvOrDefault("OUTBOX_MAX_RETRY_BACKOFF_MS", "300000", "int").(int)) * time.Millisecond,
+		Retention:       time.Duration(utils.GetEnvOrDefault("OUTBOX_RETENTION_HOURS", "24", "int").(int)) * time.Hour,
+	}
+}
+
+// OutboxRelay dispatches outbox messages to a publisher in the background. Messages of the same aggregate are
+// published in the order they were written, a failing message holds back the later messages of its aggregate.
+// A message is only marked delivered once every handler has handled it, so it survives a crash or a closed bus
+// mid-delivery. It's published again if any handler fails, so delivery is at-least-once and handlers must be
+// idempotent, e.g. by recording the events they've processed.
+type OutboxRelay struct {
+	config    OutboxRelayConfig
+	store     OutboxStore
+	publisher events.AcknowledgingPublisher
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewOutboxRelay creates a new OutboxRelay, call Start to begin relaying.
+func NewOutboxRelay(config OutboxRelayConfig, store OutboxStore, publisher events.AcknowledgingPublisher) *OutboxRelay {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OutboxRelay{
+		config:    config,
+		store:     store,
+		publisher: publisher,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start starts relaying on a new goroutine.
+func (r *OutboxRelay) Start() {
+	go r.run()
+}
+
+// Stop stops the relay and waits for the current batch to finish, or for ctx to expire.
+// Messages that weren't delivered stay in the outbox for the next run.
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *OutboxRelay) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	lastCleanup := time.Time{}
+	for {
+		err := r.RelayPending()
+		if err != nil {
+			r.config.Logger.Error(r.config.AppName, "OutboxRelay.run", fmt.Sprintf("Failed to relay outbox: %s", err.Error()))
+		}
+		if time.Since(lastCleanup) > time.Hour {
+			err = r.Cleanup()
+			if err != nil {
+				r.config.Logger.Error(r.config.AppName, "OutboxRelay.run", fmt.Sprintf("Failed to clean up outbox: %s", err.Error()))
+			}
+			lastCleanup = time.Now()
+		}
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+// RelayPending relays batches of due messages until the outbox has no more, or the relay is stopped.
+func (r *OutboxRelay) RelayPending() error {
+	for r.ctx.Err() == nil {
+		claimed, err := r.relayBatch()
+		if err != nil {
+			return err
+		}
+		if claimed == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Cleanup deletes delivered messages that are older than the retention period.
+func (r *OutboxRelay) Cleanup() error {
+	deleted, err := r.store.DeleteDeliveredOutboxMessages(time.Now().Add(-r.config.Retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		r.config.Logger.Debug(r.config.AppName, "OutboxRelay.Cleanup", fmt.Sprintf("Deleted %d delivered outbox messages", deleted))
+	}
+	return nil
+}
+
+// relayBatch relays one batch, its deliveries are cancelled when the batch's lease expires.
+func (r *OutboxRelay) relayBatch() (int, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, r.config.Lease)
+	defer cancel()
+	return r.store.RelayOutboxBatch(r.config.BatchSize, r.config.Lease, func(message OutboxMessage) error {
+		return r.deliver(ctx, message)
+	}, r.backoff)
+}
+
+func (r *OutboxRelay) deliver(ctx context.Context, message OutboxMessage) error {
+	err := r.publisher.PublishAndWait(ctx, message.Event())
+	if err != nil {
+		r.config.Logger.Warn(
+			r.config.AppName,
+			"OutboxRelay.deliver",
+			fmt.Sprintf("Failed to publish %s for %s %s: %s", message.EventName, message.AggregateType, message.AggregateID, err.Error()),
+		)
+	}
+	return err
+}
+
+// backoff returns how long to wait before retrying a message that has failed the given number of times.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	backoff := r.config.RetryBackoff
+	for i := 1; i < attempts && backoff < r.config.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.config.MaxRetryBackoff {
+		return r.config.MaxRetryBackoff
+	}
+	return backoff
+}
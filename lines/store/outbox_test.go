@@ -0,0 +1,336 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/events"
+	"lines/lines/logging"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	ID int `json:"id"`
+}
+
+func (e testEvent) EventName() string { return "test.event" }
+
+func TestNewOutboxMessage(t *testing.T) {
+	message, err := NewOutboxMessage("user", "1", testEvent{ID: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "user", message.AggregateType)
+	assert.Equal(t, "1", message.AggregateID)
+	assert.Equal(t, "test.event", message.EventName)
+	assert.JSONEq(t, `{"id":1}`, string(message.Payload))
+	assert.False(t, message.NextAttemptAt.IsZero())
+	assert.Empty(t, message.Validate())
+}
+
+func TestOutboxMessage_Validate(t *testing.T) {
+	errors := OutboxMessage{}.Validate()
+	assert.Equal(t, []ModelValidationError{
+		{Field: "AggregateType", Message: "AggregateType is required"},
+		{Field: "AggregateID", Message: "AggregateID is required"},
+		{Field: "EventName", Message: "EventName is required"},
+	}, errors)
+}
+
+func TestOutboxMessage_Event(t *testing.T) {
+	message := OutboxMessage{EventName: "test.event", Payload: []byte(`{"id":1}`)}
+	assert.Equal(t, events.RawEvent{Name: "test.event", Payload: []byte(`{"id":1}`)}, message.Event())
+}
+
+func TestNewOutboxRelayConfig(t *testing.T) {
+	t.Setenv("OUTBOX_BATCH_SIZE", "10")
+	logger := logging.NewLogrusHandler("info")
+	config := NewOutboxRelayConfig("USER", logger)
+	assert.Equal(t, "USER", config.AppName)
+	assert.Equal(t, logger, config.Logger)
+	assert.Equal(t, time.Second, config.PollInterval)
+	assert.Equal(t, 10, config.BatchSize)
+	assert.Equal(t, 5*time.Minute, config.Lease)
+	assert.Equal(t, time.Second, config.RetryBackoff)
+	assert.Equal(t, 5*time.Minute, config.MaxRetryBackoff)
+	assert.Equal(t, 24*time.Hour, config.Retention)
+}
+
+// fakeOutboxStore keeps messages in memory, claiming them the same way PostgresStore does.
+type fakeOutboxStore struct {
+	mu       sync.Mutex
+	messages []*OutboxMessage
+	deleted  []time.Time
+	leases   []time.Duration
+}
+
+func (f *fakeOutboxStore) RelayOutboxBatch(
+	limit int,
+	lease time.Duration,
+	deliver func(message OutboxMessage) error,
+	backoff func(attempts int) time.Duration,
+) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.leases = append(f.leases, lease)
+	blocked := map[string]bool{}
+	claimed := 0
+	for _, message := range f.messages {
+		key := message.AggregateType + message.AggregateID
+		if message.DeliveredAt != nil || blocked[key] {
+			continue
+		}
+		blocked[key] = true
+		if message.NextAttemptAt.After(time.Now()) || claimed == limit {
+			continue
+		}
+		claimed++
+		err := deliver(*message)
+		if err != nil {
+			message.Attempts++
+			message.NextAttemptAt = time.Now().Add(backoff(message.Attempts))
+			message.LastError = err.Error()
+			continue
+		}
+		now := time.Now()
+		message.DeliveredAt = &now
+	}
+	return claimed, nil
+}
+
+func (f *fakeOutboxStore) DeleteDeliveredOutboxMessages(before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted = append(f.deleted, before)
+	return 0, nil
+}
+
+type errorOutboxStore struct {
+	fakeOutboxStore
+}
+
+func (e *errorOutboxStore) RelayOutboxBatch(
+	int,
+	time.Duration,
+	func(OutboxMessage) error,
+	func(int) time.Duration,
+) (int, error) {
+	return 0, assert.AnError
+}
+
+func (e *errorOutboxStore) DeleteDeliveredOutboxMessages(time.Time) (int64, error) {
+	return 0, assert.AnError
+}
+
+type mockPublisher struct {
+	mu        sync.Mutex
+	published []events.Event
+	failures  int
+}
+
+func (m *mockPublisher) Publish(ctx context.Context, event events.Event) error {
+	return m.PublishAndWait(ctx, event)
+}
+
+func (m *mockPublisher) PublishAndWait(ctx context.Context, event events.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return assert.AnError
+	}
+	m.published = append(m.published, event)
+	return nil
+}
+
+type mockLogger struct {
+	logging.Logger
+	mu         sync.Mutex
+	ErrorCalls int
+	WarnCalls  int
+}
+
+func (m *mockLogger) Error(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ErrorCalls++
+}
+
+func (m *mockLogger) Warn(appName string, caller string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.WarnCalls++
+}
+
+func (m *mockLogger) Debug(appName string, caller string, message string) {}
+
+func newTestRelayConfig(logger logging.Logger) OutboxRelayConfig {
+	return OutboxRelayConfig{
+		AppName:         "TEST",
+		Logger:          logger,
+		PollInterval:    time.Millisecond,
+		BatchSize:       2,
+		Lease:           time.Minute,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 4 * time.Millisecond,
+		Retention:       time.Hour,
+	}
+}
+
+func newMessage(t *testing.T, aggregateID string, id int) *OutboxMessage {
+	message, err := NewOutboxMessage("test", aggregateID, testEvent{ID: id})
+	assert.Nil(t, err)
+	return message
+}
+
+func TestOutboxRelay_RelayPending_PublishesEveryMessageInOrder(t *testing.T) {
+	store := &fakeOutboxStore{messages: []*OutboxMessage{
+		newMessage(t, "1", 1),
+		newMessage(t, "1", 2),
+		newMessage(t, "2", 3),
+		newMessage(t, "1", 4),
+		newMessage(t, "3", 5),
+	}}
+	publisher := &mockPublisher{}
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), store, publisher)
+
+	assert.Nil(t, relay.RelayPending())
+
+	var aggregateOne []int
+	for _, event := range publisher.published {
+		var decoded testEvent
+		assert.Nil(t, json.Unmarshal(event.(events.RawEvent).Payload, &decoded))
+		if decoded.ID == 1 || decoded.ID == 2 || decoded.ID == 4 {
+			aggregateOne = append(aggregateOne, decoded.ID)
+		}
+	}
+	assert.Len(t, publisher.published, 5)
+	assert.Equal(t, []int{1, 2, 4}, aggregateOne)
+}
+
+func TestOutboxRelay_RelayPending_FailureHoldsBackAggregate(t *testing.T) {
+	store := &fakeOutboxStore{messages: []*OutboxMessage{
+		newMessage(t, "1", 1),
+		newMessage(t, "1", 2),
+	}}
+	publisher := &mockPublisher{failures: 1}
+	logger := &mockLogger{}
+	relay := NewOutboxRelay(newTestRelayConfig(logger), store, publisher)
+
+	assert.Nil(t, relay.RelayPending())
+
+	assert.Empty(t, publisher.published)
+	assert.Equal(t, 1, store.messages[0].Attempts)
+	assert.Equal(t, assert.AnError.Error(), store.messages[0].LastError)
+	assert.Equal(t, 1, logger.WarnCalls)
+
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, relay.RelayPending())
+	assert.Len(t, publisher.published, 2)
+}
+
+func TestOutboxRelay_RelayPending_KeepsMessageUntilHandled(t *testing.T) {
+	store := &fakeOutboxStore{messages: []*OutboxMessage{newMessage(t, "1", 1)}}
+	bus := events.NewInMemoryBus(events.BusConfig{Workers: 1, QueueSize: 1, MaxAttempts: 1, Logger: &mockLogger{}})
+	var handled []testEvent
+	failing := true
+	events.Subscribe(bus, func(ctx context.Context, event testEvent) error {
+		if failing {
+			return assert.AnError
+		}
+		handled = append(handled, event)
+		return nil
+	})
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), store, bus)
+
+	assert.Nil(t, relay.RelayPending())
+	// The handler failed, so the message is retried rather than lost.
+	assert.Nil(t, store.messages[0].DeliveredAt)
+	assert.Equal(t, 1, store.messages[0].Attempts)
+
+	failing = false
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, relay.RelayPending())
+	assert.NotNil(t, store.messages[0].DeliveredAt)
+	assert.Equal(t, []testEvent{{ID: 1}}, handled)
+	assert.Nil(t, bus.Close(context.Background()))
+}
+
+// blockingPublisher blocks until the publish is cancelled.
+type blockingPublisher struct {
+	mockPublisher
+}
+
+func (b *blockingPublisher) PublishAndWait(ctx context.Context, event events.Event) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestOutboxRelay_RelayPending_CancelsDeliveryWhenLeaseExpires(t *testing.T) {
+	store := &fakeOutboxStore{messages: []*OutboxMessage{newMessage(t, "1", 1)}}
+	config := newTestRelayConfig(&mockLogger{})
+	config.Lease = 10 * time.Millisecond
+	relay := NewOutboxRelay(config, store, &blockingPublisher{})
+
+	assert.Nil(t, relay.RelayPending())
+
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}, store.leases)
+	assert.Nil(t, store.messages[0].DeliveredAt)
+	assert.Equal(t, 1, store.messages[0].Attempts)
+	assert.Equal(t, context.DeadlineExceeded.Error(), store.messages[0].LastError)
+}
+
+func TestOutboxRelay_RelayPending_StoreError(t *testing.T) {
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), &errorOutboxStore{}, &mockPublisher{})
+	assert.Equal(t, assert.AnError, relay.RelayPending())
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), &fakeOutboxStore{}, &mockPublisher{})
+	assert.Equal(t, time.Millisecond, relay.backoff(1))
+	assert.Equal(t, 2*time.Millisecond, relay.backoff(2))
+	assert.Equal(t, 4*time.Millisecond, relay.backoff(3))
+	assert.Equal(t, 4*time.Millisecond, relay.backoff(10))
+}
+
+func TestOutboxRelay_Cleanup(t *testing.T) {
+	store := &fakeOutboxStore{}
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), store, &mockPublisher{})
+
+	assert.Nil(t, relay.Cleanup())
+
+	assert.Len(t, store.deleted, 1)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), store.deleted[0], time.Second)
+}
+
+func TestOutboxRelay_Cleanup_Error(t *testing.T) {
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), &errorOutboxStore{}, &mockPublisher{})
+	assert.Equal(t, assert.AnError, relay.Cleanup())
+}
+
+func TestOutboxRelay_StartStop(t *testing.T) {
+	store := &fakeOutboxStore{messages: []*OutboxMessage{newMessage(t, "1", 1)}}
+	publisher := &mockPublisher{}
+	relay := NewOutboxRelay(newTestRelayConfig(&mockLogger{}), store, publisher)
+
+	relay.Start()
+	assert.Eventually(t, func() bool {
+		publisher.mu.Lock()
+		defer publisher.mu.Unlock()
+		return len(publisher.published) == 1
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, relay.Stop(context.Background()))
+}
+
+func TestOutboxRelay_Run_LogsErrors(t *testing.T) {
+	logger := &mockLogger{}
+	relay := NewOutboxRelay(newTestRelayConfig(logger), &errorOutboxStore{}, &mockPublisher{})
+
+	relay.Start()
+	assert.Eventually(t, func() bool {
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		return logger.ErrorCalls >= 2
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, relay.Stop(context.Background()))
+}
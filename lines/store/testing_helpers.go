@@ -24,3 +24,13 @@ func IsolatedIntegrationTest(t *testing.T, stores []IntegrationTestStore, testFu
 	}()
 	testFunc(t)
 }
+
+// SkipWithoutTestDB skips an integration test if the app's database isn't configured, rather than letting it
+// connect and stop the whole test binary. appName is the prefix of the app's POSTGRES_URL variables.
+func SkipWithoutTestDB(t *testing.T, appName string) {
+	t.Helper()
+	config := CreatePostgresDBConfig(appName)
+	if config.ConnectionString == "NODEFAULT" {
+		t.Skipf("%s_POSTGRES_URL_TEST isn't set", appName)
+	}
+}
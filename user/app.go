@@ -1,27 +1,77 @@
 package user
 
 import (
+	"context"
+	"lines/lines/app"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
 	linesHttp "lines/lines/http"
+	"lines/lines/jobs"
+	"lines/user/domain"
+	"lines/user/ingress/grpc"
 	"lines/user/ingress/http"
 )
 
 type UserApp struct {
-	http http.UserHttpIngressInterface
+	http   http.UserHttpIngressInterface
+	grpc   grpc.UserGrpcIngressInterface
+	domain domain.UserDomainInterface
 }
 
 func NewUserApp() UserApp {
-	ingress := http.NewUserHttpIngress(nil)
+	userDomain := domain.NewUserDomain()
+	httpIngress := http.NewUserHttpIngress(userDomain)
+	grpcIngress := grpc.NewUserGrpcIngress(userDomain)
 	return UserApp{
-		http: &ingress,
+		http:   &httpIngress,
+		grpc:   &grpcIngress,
+		domain: userDomain,
 	}
 }
 
-func (a *UserApp) Initialise() error { return nil }
+func (a *UserApp) Name() string { return "user" }
+
+// Initialise provides the user app's public use cases.
+func (a *UserApp) Initialise(useCases *app.UseCaseRegistry) error {
+	return provideUserResolver(useCases, &userResolver{domain: a.domain})
+}
 
 func (a *UserApp) RegisterHTTPRoutes(engine linesHttp.HttpEngine) {
 	a.http.RegisterRoutes(engine)
 }
 
-func (a *UserApp) RegisterGRPCServices() error {
+func (a *UserApp) RegisterGRPCServices(server linesGrpc.GrpcServer) error {
+	a.grpc.RegisterServices(server)
+	return nil
+}
+
+// RegisterEventHandlers starts relaying the domain's outbox to the bus, the user app doesn't subscribe to
+// anything yet.
+func (a *UserApp) RegisterEventHandlers(bus events.Bus) error {
+	a.domain.RelayEvents(bus)
 	return nil
 }
+
+// Migrate migrates the user app's stores.
+func (a *UserApp) Migrate() error {
+	return a.domain.Migrate()
+}
+
+// CheckConfig checks the user domain's config.
+func (a *UserApp) CheckConfig() error {
+	return a.domain.CheckConfig()
+}
+
+// RegisterJobs is a no-op until the user app has background jobs.
+func (a *UserApp) RegisterJobs(registry jobs.Registry) error {
+	return nil
+}
+
+// Shutdown stops the user app's outbox relay and closes its store connections.
+func (a *UserApp) Shutdown(ctx context.Context) error {
+	err := a.http.Close()
+	if err != nil {
+		return err
+	}
+	return a.domain.Close(ctx)
+}
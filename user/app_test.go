@@ -1,22 +1,49 @@
 package user
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	linesApp "lines/lines/app"
+	"lines/lines/events"
+	linesGrpc "lines/lines/grpc"
 	linesHttp "lines/lines/http"
+	"lines/lines/store"
+	"lines/user/domain"
+	"lines/user/public"
 	"testing"
 )
 
 func TestNewUserApp(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	app := NewUserApp()
 	if app.http == nil {
 		t.Errorf("Expected app.http to not be nil")
 	}
+	if app.grpc == nil {
+		t.Errorf("Expected app.grpc to not be nil")
+	}
+	if app.domain == nil {
+		t.Errorf("Expected app.domain to not be nil")
+	}
 }
 
-func TestUserApp_Initialise(t *testing.T) {
-	app := NewUserApp()
-	assert.Nil(t, app.Initialise())
+func TestUserApp_Name(t *testing.T) {
+	app := UserApp{}
+	assert.Equal(t, "user", app.Name())
+}
+
+func TestUserApp_Initialise_ProvidesUseCases(t *testing.T) {
+	app := UserApp{domain: &mockUserDomain{}}
+	useCases := linesApp.NewUseCaseRegistry()
+
+	assert.Nil(t, app.Initialise(useCases))
+
+	resolver, err := linesApp.Resolve(useCases, public.ResolveUserV2)
+	assert.Nil(t, err)
+	assert.IsType(t, &userResolver{}, resolver)
+	_, err = linesApp.Resolve(useCases, public.ResolveUserV1)
+	assert.Nil(t, err)
 }
 
 type MockUserApp struct {
@@ -39,7 +66,89 @@ func TestUserApp_RegisterHTTPRoutes(t *testing.T) {
 	assert.Equal(t, &engine, app.RegisterHTTPRoutesArgs[0])
 }
 
+type mockUserGrpcIngress struct {
+	RegisterServicesArgs []linesGrpc.GrpcServer
+}
+
+func (m *mockUserGrpcIngress) RegisterServices(s linesGrpc.GrpcServer) {
+	m.RegisterServicesArgs = append(m.RegisterServicesArgs, s)
+}
+
 func TestUserApp_RegisterGRPCServices(t *testing.T) {
-	app := NewUserApp()
-	assert.Nil(t, app.RegisterGRPCServices())
+	ingress := &mockUserGrpcIngress{}
+	app := UserApp{grpc: ingress}
+	server := &linesGrpc.Server{}
+
+	assert.Nil(t, app.RegisterGRPCServices(server))
+	assert.Equal(t, []linesGrpc.GrpcServer{server}, ingress.RegisterServicesArgs)
+}
+
+type mockUserDomain struct {
+	domain.UserDomainInterface
+	CloseCalls   int
+	MigrateCalls int
+	Publisher    events.Publisher
+}
+
+func (m *mockUserDomain) Migrate() error {
+	m.MigrateCalls++
+	return nil
+}
+
+func (m *mockUserDomain) CheckConfig() error {
+	return assert.AnError
+}
+
+func (m *mockUserDomain) RelayEvents(publisher events.AcknowledgingPublisher) {
+	m.Publisher = publisher
+}
+
+func (m *mockUserDomain) Close(ctx context.Context) error {
+	m.CloseCalls++
+	return nil
+}
+
+func TestUserApp_RegisterEventHandlers(t *testing.T) {
+	userDomain := &mockUserDomain{}
+	app := UserApp{domain: userDomain}
+	bus := events.NewInMemoryBus(events.BusConfig{})
+	assert.Nil(t, app.RegisterEventHandlers(bus))
+	assert.Equal(t, bus, userDomain.Publisher)
+}
+
+type mockUserHttpIngress struct {
+	CloseCalls int
+}
+
+func (m *mockUserHttpIngress) RegisterRoutes(linesHttp.HttpEngine) {}
+
+func (m *mockUserHttpIngress) Close() error {
+	m.CloseCalls++
+	return nil
+}
+
+func TestUserApp_Shutdown(t *testing.T) {
+	httpIngress := &mockUserHttpIngress{}
+	userDomain := &mockUserDomain{}
+	app := UserApp{http: httpIngress, domain: userDomain}
+	assert.Nil(t, app.Shutdown(context.Background()))
+	assert.Equal(t, 1, httpIngress.CloseCalls)
+	assert.Equal(t, 1, userDomain.CloseCalls)
+}
+
+func TestUserApp_Migrate(t *testing.T) {
+	userDomain := &mockUserDomain{}
+	app := UserApp{domain: userDomain}
+	assert.Nil(t, app.Migrate())
+	assert.Equal(t, 1, userDomain.MigrateCalls)
+}
+
+func TestUserApp_CheckConfig(t *testing.T) {
+	app := UserApp{domain: &mockUserDomain{}}
+	assert.ErrorIs(t, app.CheckConfig(), assert.AnError)
+}
+
+func TestUserApp_RegisterJobs(t *testing.T) {
+	app := UserApp{}
+	assert.Nil(t, app.RegisterJobs(nil))
 }
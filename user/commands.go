@@ -0,0 +1,53 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"lines/lines/app"
+	"lines/user/domain"
+	"strings"
+)
+
+// Commands adds the user admin commands to the main binary.
+func (a *UserApp) Commands() []app.Command {
+	return []app.Command{
+		{
+			Name:  "create-user",
+			Usage: "Create a user, e.g. create-user -name Jake -email jake@example.com -password ...",
+			Run:   a.createUserCommand,
+		},
+	}
+}
+
+func (a *UserApp) createUserCommand(ctx context.Context, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("create-user", flag.ContinueOnError)
+	flags.SetOutput(out)
+	name := flags.String("name", "", "The user's name.")
+	email := flags.String("email", "", "The user's email.")
+	password := flags.String("password", "", "The user's password.")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	validationErrors, user, err := a.domain.CreateUser(domain.UserForCreate{
+		Name:     *name,
+		Email:    *email,
+		Password: *password,
+	})
+	if err != nil {
+		return err
+	}
+	if len(validationErrors) > 0 {
+		var messages []string
+		for _, validationError := range validationErrors {
+			messages = append(messages, fmt.Sprintf("%s: %s", validationError.Field, strings.Join(validationError.Errors, " ")))
+		}
+		return errors.New(strings.Join(messages, "\n"))
+	}
+	_, err = fmt.Fprintf(out, "Created user %d <%s>\n", user.ID, user.Email)
+	return err
+}
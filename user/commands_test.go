@@ -0,0 +1,81 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	linesDomain "lines/lines/domain"
+	"lines/user/domain"
+	"testing"
+)
+
+type mockCreateUserDomain struct {
+	domain.UserDomainInterface
+	CreateUserArgs   []domain.UserForCreate
+	validationErrors []linesDomain.DomainValidationErrors
+	err              error
+}
+
+func (m *mockCreateUserDomain) CreateUser(user domain.UserForCreate) ([]linesDomain.DomainValidationErrors, *domain.UserData, error) {
+	m.CreateUserArgs = append(m.CreateUserArgs, user)
+	if m.err != nil || len(m.validationErrors) > 0 {
+		return m.validationErrors, nil, m.err
+	}
+	return nil, &domain.UserData{ID: 1, Name: user.Name, Email: user.Email}, nil
+}
+
+func runCommand(t *testing.T, app *UserApp, name string, args ...string) (string, error) {
+	for _, command := range app.Commands() {
+		if command.Name == name {
+			out := &bytes.Buffer{}
+			err := command.Run(context.Background(), args, out)
+			return out.String(), err
+		}
+	}
+	t.Fatalf("Command %s not found", name)
+	return "", nil
+}
+
+func TestUserApp_CreateUserCommand(t *testing.T) {
+	userDomain := &mockCreateUserDomain{}
+	app := &UserApp{domain: userDomain}
+
+	out, err := runCommand(t, app, "create-user", "-name", "Jake", "-email", "jake@example.com", "-password", "password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Created user 1 <jake@example.com>\n", out)
+	assert.Equal(t, []domain.UserForCreate{
+		{Name: "Jake", Email: "jake@example.com", Password: "password"},
+	}, userDomain.CreateUserArgs)
+}
+
+func TestUserApp_CreateUserCommand_ValidationErrors(t *testing.T) {
+	userDomain := &mockCreateUserDomain{validationErrors: []linesDomain.DomainValidationErrors{
+		{Field: "email", Errors: []string{"Email is already in use."}},
+		{Field: "password", Errors: []string{"Password is required."}},
+	}}
+	app := &UserApp{domain: userDomain}
+
+	_, err := runCommand(t, app, "create-user", "-email", "jake@example.com")
+
+	assert.EqualError(t, err, "email: Email is already in use.\npassword: Password is required.")
+}
+
+func TestUserApp_CreateUserCommand_DomainError(t *testing.T) {
+	app := &UserApp{domain: &mockCreateUserDomain{err: assert.AnError}}
+
+	_, err := runCommand(t, app, "create-user")
+
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestUserApp_CreateUserCommand_BadFlag(t *testing.T) {
+	userDomain := &mockCreateUserDomain{}
+	app := &UserApp{domain: userDomain}
+
+	out, err := runCommand(t, app, "create-user", "-unknown")
+
+	assert.NotNil(t, err)
+	assert.Contains(t, out, "-email")
+	assert.Empty(t, userDomain.CreateUserArgs)
+}
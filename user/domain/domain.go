@@ -1,26 +1,49 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	linesEvents "lines/lines/events"
+	"lines/lines/logging"
+	"lines/lines/store"
 	"lines/lines/utils"
 	"lines/user/stores"
+	"time"
 )
 
 // UserDomainConfig is a struct that contains the configuration for a UserDomain.
 type UserDomainConfig struct {
 	SecretKey                  []byte
 	TokenExpirationTimeMinutes int
+	// DeletedUserRetentionDays is how long deleted users are kept before they're purged.
+	DeletedUserRetentionDays int
 }
 
 func NewUserDomainConfig() UserDomainConfig {
 	return UserDomainConfig{
 		SecretKey:                  []byte(utils.GetEnvOrDefault("SECRET_KEY", "secret_key", "string").(string)),
 		TokenExpirationTimeMinutes: utils.GetEnvOrDefault("TOKEN_EXPIRATION_TIME_MINUTES", "15", "int").(int),
+		DeletedUserRetentionDays:   utils.GetEnvOrDefault("USER_DELETED_RETENTION_DAYS", "30", "int").(int),
 	}
 }
 
+// Validate returns an error describing everything wrong with the config.
+func (c UserDomainConfig) Validate() error {
+	var errs []error
+	if len(c.SecretKey) == 0 {
+		errs = append(errs, errors.New("SECRET_KEY must be set"))
+	}
+	if c.TokenExpirationTimeMinutes <= 0 {
+		errs = append(errs, errors.New("TOKEN_EXPIRATION_TIME_MINUTES must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
 type UserDomain struct {
 	store  stores.UserStoreInterface
 	Config UserDomainConfig
+	Logger logging.Logger
+	relay  *store.OutboxRelay
 }
 
 func (d *UserDomain) BeginTransaction() error {
@@ -31,10 +54,43 @@ func (d *UserDomain) RollbackTransaction() error {
 	return d.store.RollbackTransaction()
 }
 
+// Migrate brings the user app's tables up to date.
+func (d *UserDomain) Migrate() error {
+	return d.store.Migrate()
+}
+
+// CheckConfig returns an error describing everything wrong with the domain's config.
+func (d *UserDomain) CheckConfig() error {
+	return d.Config.Validate()
+}
+
+// PurgeDeletedUsers permanently deletes users that were deleted longer ago than the retention period.
+func (d *UserDomain) PurgeDeletedUsers() (int64, error) {
+	return d.store.PurgeDeletedUsers(time.Now().AddDate(0, 0, -d.Config.DeletedUserRetentionDays))
+}
+
+// RelayEvents starts relaying the events the domain writes to its outbox to the publisher.
+func (d *UserDomain) RelayEvents(publisher linesEvents.AcknowledgingPublisher) {
+	d.relay = store.NewOutboxRelay(store.NewOutboxRelayConfig("USER", d.Logger), d.store, publisher)
+	d.relay.Start()
+}
+
+// Close stops relaying events and releases the domain's store connections.
+func (d *UserDomain) Close(ctx context.Context) error {
+	if d.relay != nil {
+		err := d.relay.Stop(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return d.store.Close()
+}
+
 // NewUserDomain is a function that returns a new UserDomain instance.
 func NewUserDomain() *UserDomain {
 	return &UserDomain{
 		store:  stores.NewUserStore(),
 		Config: NewUserDomainConfig(),
+		Logger: logging.NewLogrusHandler(utils.GetEnvOrDefault("LOG_LEVEL", "info", "string").(string)),
 	}
 }
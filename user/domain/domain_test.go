@@ -1,19 +1,24 @@
 package domain
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	linesEvents "lines/lines/events"
 	"lines/lines/store"
 	"lines/user/stores"
 	"testing"
+	"time"
 )
 
 func TestNewUserDomainConfig(t *testing.T) {
 	config := NewUserDomainConfig()
 	assert.NotNil(t, config)
 	assert.NotEmpty(t, config.SecretKey)
+	assert.Equal(t, 30, config.DeletedUserRetentionDays)
 }
 
 func TestNewUserDomain(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := NewUserDomain()
 	assert.NotNil(t, domain)
 	assert.NotNil(t, domain.store)
@@ -21,6 +26,7 @@ func TestNewUserDomain(t *testing.T) {
 }
 
 func TestUserDomain_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := NewUserDomain()
 	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{domain.store}, func(t *testing.T) {
 		validationErrors, user, err := domain.CreateUser(
@@ -36,6 +42,9 @@ type MockUserStore struct {
 	stores.UserStoreInterface
 	BeingTransactionCalls    int
 	RollbackTransactionCalls int
+	CloseCalls               int
+	MigrateCalls             int
+	PurgeDeletedUsersArgs    []time.Time
 }
 
 func (m *MockUserStore) BeginTransaction() error {
@@ -48,6 +57,34 @@ func (m *MockUserStore) RollbackTransaction() error {
 	return nil
 }
 
+func (m *MockUserStore) Close() error {
+	m.CloseCalls++
+	return nil
+}
+
+func (m *MockUserStore) Migrate() error {
+	m.MigrateCalls++
+	return nil
+}
+
+func (m *MockUserStore) RelayOutboxBatch(
+	int,
+	time.Duration,
+	func(store.OutboxMessage) error,
+	func(int) time.Duration,
+) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUserStore) DeleteDeliveredOutboxMessages(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *MockUserStore) PurgeDeletedUsers(before time.Time) (int64, error) {
+	m.PurgeDeletedUsersArgs = append(m.PurgeDeletedUsersArgs, before)
+	return 2, nil
+}
+
 func TestUserDomain_BeginTransaction(t *testing.T) {
 	domain := UserDomain{
 		store: &MockUserStore{},
@@ -65,3 +102,61 @@ func TestUserDomain_RollbackTransaction(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, domain.store.(*MockUserStore).RollbackTransactionCalls)
 }
+
+func TestUserDomain_Close(t *testing.T) {
+	domain := UserDomain{
+		store: &MockUserStore{},
+	}
+	err := domain.Close(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, domain.store.(*MockUserStore).CloseCalls)
+}
+
+func TestUserDomain_Migrate(t *testing.T) {
+	domain := UserDomain{
+		store: &MockUserStore{},
+	}
+	err := domain.Migrate()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, domain.store.(*MockUserStore).MigrateCalls)
+}
+
+func TestUserDomain_PurgeDeletedUsers(t *testing.T) {
+	mockStore := &MockUserStore{}
+	domain := UserDomain{
+		store:  mockStore,
+		Config: UserDomainConfig{DeletedUserRetentionDays: 30},
+	}
+	purged, err := domain.PurgeDeletedUsers()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.Len(t, mockStore.PurgeDeletedUsersArgs, 1)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), mockStore.PurgeDeletedUsersArgs[0], time.Minute)
+}
+
+func TestUserDomain_CheckConfig(t *testing.T) {
+	domain := UserDomain{
+		Config: UserDomainConfig{SecretKey: []byte("secret"), TokenExpirationTimeMinutes: 15},
+	}
+	assert.Nil(t, domain.CheckConfig())
+}
+
+func TestUserDomainConfig_Validate(t *testing.T) {
+	err := UserDomainConfig{}.Validate()
+	assert.EqualError(t, err, "SECRET_KEY must be set\nTOKEN_EXPIRATION_TIME_MINUTES must be positive")
+}
+
+type mockPublisher struct {
+	linesEvents.AcknowledgingPublisher
+}
+
+func TestUserDomain_RelayEvents(t *testing.T) {
+	domain := UserDomain{
+		store: &MockUserStore{},
+	}
+	domain.RelayEvents(&mockPublisher{})
+	assert.NotNil(t, domain.relay)
+	err := domain.Close(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, domain.store.(*MockUserStore).CloseCalls)
+}
@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"context"
 	"github.com/golang-jwt/jwt/v5"
 	"lines/lines/domain"
+	linesEvents "lines/lines/events"
 	linesHttp "lines/lines/http"
 	"lines/user/stores"
 	"net/http"
@@ -15,22 +17,25 @@ type UserDomainInterface interface {
 	DeleteUser(id uint) error
 	CheckPassword(userID uint, password string) bool
 	GenerateJWT(userEmail string) (*JWTClaimsOut, error)
-	ValidateRequestAuth(r http.Request) (*linesHttp.HttpError, *JWTClaimsOut)
+	ValidateJWT(token string) (*linesHttp.Problem, *JWTClaimsOut)
+	ValidateRequestAuth(r http.Request) (*linesHttp.Problem, *JWTClaimsOut)
 	BeginTransaction() error
 	RollbackTransaction() error
+	Migrate() error
+	CheckConfig() error
+	PurgeDeletedUsers() (int64, error)
+	RelayEvents(publisher linesEvents.AcknowledgingPublisher)
+	Close(ctx context.Context) error
 }
 
 type UserForCreate struct {
-	Name     string
-	Email    string
-	Password string
+	Name     string `validate:"required"`
+	Email    string `validate:"required,email"`
+	Password string `validate:"required"`
 }
 
 func (u UserForCreate) Validate(store stores.UserStoreInterface) ([]domain.DomainValidationErrors, error) {
-	var validationErrors []domain.DomainValidationErrors
-	validationErrors = domain.EmptyStringValidator(u.Name, "name", validationErrors)
-	validationErrors = domain.EmailValidator(u.Email, "email", validationErrors)
-	validationErrors = domain.EmptyStringValidator(u.Password, "password", validationErrors)
+	validationErrors := domain.Validate(u)
 
 	// Check if the email is already in use.
 	user, err := store.GetUserByEmail(u.Email)
@@ -40,7 +40,7 @@ func TestUserForCreate_Validate_EmptyName(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotEmpty(t, validation)
 	assert.Equal(t, "name", validation[0].Field)
-	assert.Equal(t, "name is required", validation[0].Errors[0])
+	assert.Equal(t, "Name is required.", validation[0].Errors[0])
 }
 
 func TestUserForCreate_Validate_EmptyEmail(t *testing.T) {
@@ -53,7 +53,7 @@ func TestUserForCreate_Validate_EmptyEmail(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotEmpty(t, validation)
 	assert.Equal(t, "email", validation[0].Field)
-	assert.Equal(t, "email is required", validation[0].Errors[0])
+	assert.Equal(t, "Email is required.", validation[0].Errors[0])
 }
 
 func TestUserForCreate_Validate_InvalidEmail(t *testing.T) {
@@ -66,7 +66,7 @@ func TestUserForCreate_Validate_InvalidEmail(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotEmpty(t, validation)
 	assert.Equal(t, "email", validation[0].Field)
-	assert.Equal(t, "Invalid email", validation[0].Errors[0])
+	assert.Equal(t, "Email must be a valid email.", validation[0].Errors[0])
 }
 
 func TestUserForCreate_Validate_EmptyPassword(t *testing.T) {
@@ -79,7 +79,7 @@ func TestUserForCreate_Validate_EmptyPassword(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotEmpty(t, validation)
 	assert.Equal(t, "password", validation[0].Field)
-	assert.Equal(t, "password is required", validation[0].Errors[0])
+	assert.Equal(t, "Password is required.", validation[0].Errors[0])
 }
 
 func TestUserForCreate_Validate_EmailExists(t *testing.T) {
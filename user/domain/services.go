@@ -5,7 +5,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"lines/lines/domain"
+	linesEvents "lines/lines/events"
 	linesHttp "lines/lines/http"
+	"lines/user/events"
 	"lines/user/stores"
 	"net/http"
 	"strings"
@@ -54,7 +56,11 @@ func (u *UserDomain) CreateUser(user UserForCreate) ([]domain.DomainValidationEr
 		Name:     user.Name,
 		Password: hashed,
 	}
-	modelErrors, err := u.store.CreateUser(&storeUser)
+	modelErrors, err := u.store.CreateUser(&storeUser, func(created *stores.User) []linesEvents.Event {
+		return []linesEvents.Event{
+			events.UserCreated{ID: created.ID, Name: created.Name, Email: created.Email},
+		}
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -136,7 +142,7 @@ func (u *UserDomain) GenerateJWT(userEmail string) (*JWTClaimsOut, error) {
 
 func (u *UserDomain) GetJWTFromRequest(r http.Request) (string, error) {
 	// Cookie
-	tokenString, err := r.Cookie("Bearer")
+	tokenString, err := r.Cookie(linesHttp.AuthCookieName)
 	if err == nil {
 		return tokenString.Value, nil
 	}
@@ -148,35 +154,27 @@ func (u *UserDomain) GetJWTFromRequest(r http.Request) (string, error) {
 	return "", errors.New("no token found")
 }
 
-func (u *UserDomain) ValidateJWT(token string) (*linesHttp.HttpError, *JWTClaimsOut) {
+func (u *UserDomain) ValidateJWT(token string) (*linesHttp.Problem, *JWTClaimsOut) {
 	claims := &JWTClaimsOut{}
 	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
 		return u.Config.SecretKey, nil
 	})
 	if err != nil {
 		if err == jwt.ErrSignatureInvalid {
-			return &linesHttp.HttpError{
-				Message: []string{"Unauthorised"},
-			}, nil
+			return linesHttp.Unauthorised("Unauthorised"), nil
 		}
-		return &linesHttp.HttpError{
-			Message: []string{"Bearer token invalid"},
-		}, nil
+		return linesHttp.Unauthorised("Bearer token invalid"), nil
 	}
 	if !parsedToken.Valid {
-		return &linesHttp.HttpError{
-			Message: []string{"Unauthorised"},
-		}, nil
+		return linesHttp.Unauthorised("Unauthorised"), nil
 	}
 	return nil, claims
 }
 
-func (u *UserDomain) ValidateRequestAuth(r http.Request) (*linesHttp.HttpError, *JWTClaimsOut) {
+func (u *UserDomain) ValidateRequestAuth(r http.Request) (*linesHttp.Problem, *JWTClaimsOut) {
 	tokenString, err := u.GetJWTFromRequest(r)
 	if err != nil {
-		return &linesHttp.HttpError{
-			Message: []string{"Unauthorised"},
-		}, nil
+		return linesHttp.Unauthorised("Unauthorised"), nil
 	}
 	return u.ValidateJWT(tokenString)
 }
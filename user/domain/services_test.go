@@ -3,7 +3,9 @@ package domain
 import (
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/crypto/bcrypt"
+	linesEvents "lines/lines/events"
 	"lines/lines/store"
+	"lines/user/events"
 	"lines/user/stores"
 	"net/http"
 	"testing"
@@ -30,7 +32,7 @@ type mockUserStore struct {
 	CreateUserCalls int
 }
 
-func (m *mockUserStore) CreateUser(user *stores.User) ([]store.ModelValidationError, error) {
+func (m *mockUserStore) CreateUser(user *stores.User, newEvents func(user *stores.User) []linesEvents.Event) ([]store.ModelValidationError, error) {
 	m.CreateUserCalls++
 	return nil, nil
 }
@@ -110,7 +112,7 @@ type mockUserStoreWithError struct {
 	stores.UserStoreInterface
 }
 
-func (m *mockUserStoreWithError) CreateUser(user *stores.User) ([]store.ModelValidationError, error) {
+func (m *mockUserStoreWithError) CreateUser(user *stores.User, newEvents func(user *stores.User) []linesEvents.Event) ([]store.ModelValidationError, error) {
 	return nil, assert.AnError
 }
 
@@ -140,7 +142,7 @@ type mockUserStoreSuccess struct {
 	stores.UserStoreInterface
 }
 
-func (m *mockUserStoreSuccess) CreateUser(user *stores.User) ([]store.ModelValidationError, error) {
+func (m *mockUserStoreSuccess) CreateUser(user *stores.User, newEvents func(user *stores.User) []linesEvents.Event) ([]store.ModelValidationError, error) {
 	user.ID = 1
 	return nil, nil
 }
@@ -165,7 +167,36 @@ func TestUserDomain_CreateUser_Success(t *testing.T) {
 	assert.Equal(t, userData.ID, uint(1))
 }
 
+type mockUserStoreRecordsEvents struct {
+	mockUserStoreSuccess
+	Events []linesEvents.Event
+}
+
+func (m *mockUserStoreRecordsEvents) CreateUser(
+	user *stores.User,
+	newEvents func(user *stores.User) []linesEvents.Event,
+) ([]store.ModelValidationError, error) {
+	user.ID = 1
+	m.Events = newEvents(user)
+	return nil, nil
+}
+
+func TestUserDomain_CreateUser_WritesUserCreated(t *testing.T) {
+	userStore := &mockUserStoreRecordsEvents{}
+	domain := UserDomain{
+		store: userStore,
+	}
+	_, _, err := domain.CreateUser(UserForCreate{
+		Name:     "name",
+		Email:    "some@email.com",
+		Password: "password",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []linesEvents.Event{events.UserCreated{ID: 1, Name: "name", Email: "some@email.com"}}, userStore.Events)
+}
+
 func TestUserDomain_CreateUser_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := UserDomain{
 		store: stores.NewUserStore(),
 	}
@@ -217,6 +248,7 @@ func TestUserDomain_GetUserByEmail_Success(t *testing.T) {
 }
 
 func TestUserDomain_GetUserByEmail_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := UserDomain{
 		store: stores.NewUserStore(),
 	}
@@ -226,7 +258,7 @@ func TestUserDomain_GetUserByEmail_Integration(t *testing.T) {
 			Email:    "test@email.com",
 			Password: "password",
 		}
-		validationErrors, err := domain.store.CreateUser(&user)
+		validationErrors, err := domain.store.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -270,6 +302,7 @@ func TestUserDomain_GetUserByID_Success(t *testing.T) {
 }
 
 func TestUserDomain_GetUserByID_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := UserDomain{
 		store: stores.NewUserStore(),
 	}
@@ -279,7 +312,7 @@ func TestUserDomain_GetUserByID_Integration(t *testing.T) {
 			Email:    "auser@thing.com",
 			Password: "password",
 		}
-		validationErrors, err := domain.store.CreateUser(&user)
+		validationErrors, err := domain.store.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -317,6 +350,7 @@ func TestUserDomain_DeleteUser_Success(t *testing.T) {
 }
 
 func TestUserDomain_DeleteUser_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := UserDomain{
 		store: stores.NewUserStore(),
 	}
@@ -326,7 +360,7 @@ func TestUserDomain_DeleteUser_Integration(t *testing.T) {
 			Email:    "some@user.com",
 			Password: "password",
 		}
-		validationErrors, err := domain.store.CreateUser(&user)
+		validationErrors, err := domain.store.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -392,7 +426,7 @@ func TestUserDomain_ValidateJWT_Invalid(t *testing.T) {
 	}
 	jwtString := "invalidstring"
 	errors, claims := domain.ValidateJWT(jwtString)
-	assert.Contains(t, errors.Message, "Bearer token invalid")
+	assert.Equal(t, "Bearer token invalid", errors.Detail)
 	assert.Nil(t, claims)
 }
 
@@ -407,7 +441,7 @@ func TestUserDomain_ValidateJWT_Expired(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, jwt)
 	errors, claims := domain.ValidateJWT(jwt.TokenString)
-	assert.Contains(t, errors.Message, "Bearer token invalid")
+	assert.Equal(t, "Bearer token invalid", errors.Detail)
 	assert.Nil(t, claims)
 }
 
@@ -467,7 +501,7 @@ func TestUserDomain_ValidateRequestAuth_InvalidToken(t *testing.T) {
 		},
 	}
 	errors, claims := domain.ValidateRequestAuth(req)
-	assert.Contains(t, errors.Message, "Bearer token invalid")
+	assert.Equal(t, "Bearer token invalid", errors.Detail)
 	assert.Nil(t, claims)
 }
 
@@ -616,6 +650,7 @@ func TestUserDomain_ChangeUserPassword_ValidationErrors(t *testing.T) {
 }
 
 func TestUserDomain_ChangeUserPassword_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	domain := UserDomain{
 		store: stores.NewUserStore(),
 	}
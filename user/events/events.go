@@ -0,0 +1,10 @@
+package events
+
+// UserCreated is published when a new user signs up.
+type UserCreated struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (e UserCreated) EventName() string { return "user.created" }
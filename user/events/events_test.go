@@ -0,0 +1,10 @@
+package events
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestUserCreated_EventName(t *testing.T) {
+	assert.Equal(t, "user.created", UserCreated{}.EventName())
+}
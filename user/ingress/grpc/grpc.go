@@ -0,0 +1,32 @@
+package grpc
+
+import (
+	linesGrpc "lines/lines/grpc"
+	user_domain "lines/user/domain"
+	"lines/user/ingress/grpc/userpb"
+)
+
+type UserGrpcIngressInterface interface {
+	RegisterServices(s linesGrpc.GrpcServer)
+}
+
+type UserGrpcIngress struct {
+	userpb.UnimplementedUserServiceServer
+	domain user_domain.UserDomainInterface
+}
+
+// RegisterServices registers the UserService. ValidateToken is public, callers use it to find out who a token
+// belongs to.
+func (i *UserGrpcIngress) RegisterServices(s linesGrpc.GrpcServer) {
+	userpb.RegisterUserServiceServer(s, i)
+	s.AllowUnauthenticated(userpb.UserService_ValidateToken_FullMethodName)
+}
+
+func NewUserGrpcIngress(domain user_domain.UserDomainInterface) UserGrpcIngress {
+	if domain == nil {
+		domain = user_domain.NewUserDomain()
+	}
+	return UserGrpcIngress{
+		domain: domain,
+	}
+}
@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"lines/user/domain"
+	"lines/user/ingress/grpc/userpb"
+	"strings"
+)
+
+func toUserMessage(user *domain.UserData) *userpb.User {
+	return &userpb.User{
+		Id:    uint64(user.ID),
+		Name:  user.Name,
+		Email: user.Email,
+	}
+}
+
+func (i *UserGrpcIngress) GetUserByID(ctx context.Context, req *userpb.GetUserByIDRequest) (*userpb.GetUserByIDResponse, error) {
+	if req.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ID is required.")
+	}
+	user, err := i.domain.GetUserByID(uint(req.Id))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Could not fetch user.")
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "User not found.")
+	}
+	return &userpb.GetUserByIDResponse{User: toUserMessage(user)}, nil
+}
+
+func (i *UserGrpcIngress) GetUserByEmail(ctx context.Context, req *userpb.GetUserByEmailRequest) (*userpb.GetUserByEmailResponse, error) {
+	if req.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "Email is required.")
+	}
+	user, err := i.domain.GetUserByEmail(req.Email)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Could not fetch user.")
+	}
+	if user == nil {
+		return nil, status.Error(codes.NotFound, "User not found.")
+	}
+	return &userpb.GetUserByEmailResponse{User: toUserMessage(user)}, nil
+}
+
+// ValidateToken accepts the token with or without its "Bearer " prefix, as it would appear in an Authorization
+// header.
+func (i *UserGrpcIngress) ValidateToken(ctx context.Context, req *userpb.ValidateTokenRequest) (*userpb.ValidateTokenResponse, error) {
+	token := strings.TrimPrefix(req.Token, "Bearer ")
+	if token == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required.")
+	}
+	authError, claims := i.domain.ValidateJWT(token)
+	if authError != nil {
+		return nil, status.Error(codes.Unauthenticated, authError.Detail)
+	}
+	user, err := i.domain.GetUserByEmail(claims.Email)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Could not fetch user.")
+	}
+	// The user may have been deleted since the token was issued.
+	if user == nil {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorised")
+	}
+	response := &userpb.ValidateTokenResponse{User: toUserMessage(user)}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = timestamppb.New(claims.ExpiresAt.Time)
+	}
+	return response, nil
+}
@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"lines/internal"
+	linesGrpc "lines/lines/grpc"
+	linesHttp "lines/lines/http"
+	"lines/lines/logging"
+	"lines/user/domain"
+	"lines/user/ingress/grpc/userpb"
+	"net"
+	"testing"
+	"time"
+)
+
+const testServiceToken = "service-token"
+
+// newTestClient serves the ingress over an in-memory connection, behind the same server the monolith runs. The
+// client sends testServiceToken, which the server accepts unless it's given another authenticator.
+func newTestClient(t *testing.T, userDomain domain.UserDomainInterface, authenticator linesGrpc.Authenticator) userpb.UserServiceClient {
+	server := linesGrpc.CreateServer(&internal.MainConfig{Logger: logging.NewLogrusHandler("fatal")})
+	if authenticator == nil {
+		authenticator = linesGrpc.NewServiceTokenAuthenticator(testServiceToken)
+	}
+	server.UseAuthenticator(authenticator)
+	ingress := NewUserGrpcIngress(userDomain)
+	ingress.RegisterServices(server)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown(context.Background())
+	})
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(linesGrpc.NewServiceTokenCredentials(testServiceToken, false)),
+	)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return userpb.NewUserServiceClient(conn)
+}
+
+type mockUserDomain struct {
+	domain.UserDomainInterface
+	users map[string]*domain.UserData
+	err   error
+}
+
+func (m *mockUserDomain) GetUserByID(id uint) (*domain.UserData, error) {
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, m.err
+		}
+	}
+	return nil, m.err
+}
+
+func (m *mockUserDomain) GetUserByEmail(email string) (*domain.UserData, error) {
+	return m.users[email], m.err
+}
+
+var expiresAt = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (m *mockUserDomain) ValidateJWT(token string) (*linesHttp.Problem, *domain.JWTClaimsOut) {
+	if token != "valid" {
+		return linesHttp.Unauthorised("Bearer token invalid"), nil
+	}
+	return nil, &domain.JWTClaimsOut{
+		Email:            "test@test.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+	}
+}
+
+func newMockUserDomain() *mockUserDomain {
+	return &mockUserDomain{
+		users: map[string]*domain.UserData{
+			"test@test.com": {ID: 1, Name: "test", Email: "test@test.com"},
+		},
+	}
+}
+
+type rejectingAuthenticator struct{}
+
+func (r *rejectingAuthenticator) Authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	return nil, status.Error(codes.Unauthenticated, "Unauthorised")
+}
+
+func TestUserGrpcIngress_GetUserByID(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	response, err := client.GetUserByID(context.Background(), &userpb.GetUserByIDRequest{Id: 1})
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), response.User.Id)
+	assert.Equal(t, "test", response.User.Name)
+	assert.Equal(t, "test@test.com", response.User.Email)
+}
+
+func TestUserGrpcIngress_GetUserByID_NotFound(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.GetUserByID(context.Background(), &userpb.GetUserByIDRequest{Id: 2})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUserGrpcIngress_GetUserByID_MissingID(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.GetUserByID(context.Background(), &userpb.GetUserByIDRequest{})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserGrpcIngress_GetUserByID_DomainError(t *testing.T) {
+	userDomain := newMockUserDomain()
+	userDomain.err = assert.AnError
+	client := newTestClient(t, userDomain, nil)
+
+	_, err := client.GetUserByID(context.Background(), &userpb.GetUserByIDRequest{Id: 1})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestUserGrpcIngress_GetUserByEmail(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	response, err := client.GetUserByEmail(context.Background(), &userpb.GetUserByEmailRequest{Email: "test@test.com"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), response.User.Id)
+}
+
+func TestUserGrpcIngress_GetUserByEmail_NotFound(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.GetUserByEmail(context.Background(), &userpb.GetUserByEmailRequest{Email: "other@test.com"})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUserGrpcIngress_GetUserByEmail_MissingEmail(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.GetUserByEmail(context.Background(), &userpb.GetUserByEmailRequest{})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserGrpcIngress_GetUserByEmail_RequiresAuthentication(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), &rejectingAuthenticator{})
+
+	_, err := client.GetUserByEmail(context.Background(), &userpb.GetUserByEmailRequest{Email: "test@test.com"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestUserGrpcIngress_ValidateToken(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	response, err := client.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: "Bearer valid"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "test@test.com", response.User.Email)
+	assert.Equal(t, expiresAt, response.ExpiresAt.AsTime())
+}
+
+func TestUserGrpcIngress_ValidateToken_InvalidToken(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: "invalid"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, "Bearer token invalid", status.Convert(err).Message())
+}
+
+func TestUserGrpcIngress_ValidateToken_MissingToken(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), nil)
+
+	_, err := client.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUserGrpcIngress_ValidateToken_DeletedUser(t *testing.T) {
+	userDomain := newMockUserDomain()
+	userDomain.users = map[string]*domain.UserData{}
+	client := newTestClient(t, userDomain, nil)
+
+	_, err := client.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: "valid"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestUserGrpcIngress_ValidateToken_IsPublic(t *testing.T) {
+	client := newTestClient(t, newMockUserDomain(), &rejectingAuthenticator{})
+
+	response, err := client.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: "valid"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "test@test.com", response.User.Email)
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	linesHttp "lines/lines/http"
+	"lines/user/domain"
+	"net/http"
+	"strconv"
+)
+
+// UserAuthentication is what requests are authenticated as, the claims of their JWT and the user it was issued to.
+type UserAuthentication = linesHttp.Authentication[*domain.JWTClaimsOut, *domain.UserData]
+
+// JWTAuthenticator authenticates requests with the JWT in their Bearer cookie or Authorization header.
+type JWTAuthenticator struct {
+	domain domain.UserDomainInterface
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*linesHttp.Problem, *UserAuthentication, error) {
+	problem, claims := a.domain.ValidateRequestAuth(*r)
+	if problem != nil {
+		return problem, nil, nil
+	}
+	user, err := a.domain.GetUserByEmail(claims.Email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return linesHttp.Unauthorised("Unable to find user."), nil, nil
+	}
+	return nil, &UserAuthentication{Claims: claims, User: user, UserID: strconv.FormatUint(uint64(user.ID), 10)}, nil
+}
+
+func NewJWTAuthenticator(domain domain.UserDomainInterface) *JWTAuthenticator {
+	return &JWTAuthenticator{domain: domain}
+}
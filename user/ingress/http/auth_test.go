@@ -0,0 +1,73 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	linesHttp "lines/lines/http"
+	"lines/user/domain"
+	"net/http"
+	"testing"
+)
+
+type mockAuthUserDomain struct {
+	domain.UserDomainInterface
+	authError *linesHttp.Problem
+	user      *domain.UserData
+	err       error
+}
+
+func (m *mockAuthUserDomain) ValidateRequestAuth(r http.Request) (*linesHttp.Problem, *domain.JWTClaimsOut) {
+	if m.authError != nil {
+		return m.authError, nil
+	}
+	return nil, &domain.JWTClaimsOut{Email: "email"}
+}
+
+func (m *mockAuthUserDomain) GetUserByEmail(email string) (*domain.UserData, error) {
+	return m.user, m.err
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	user := &domain.UserData{ID: 1, Name: "name", Email: "email"}
+	tests := []struct {
+		name           string
+		domain         *mockAuthUserDomain
+		authError      *linesHttp.Problem
+		authentication *UserAuthentication
+		err            error
+	}{
+		{
+			"authenticated",
+			&mockAuthUserDomain{user: user},
+			nil,
+			&UserAuthentication{Claims: &domain.JWTClaimsOut{Email: "email"}, User: user, UserID: "1"},
+			nil,
+		},
+		{
+			"invalid token",
+			&mockAuthUserDomain{authError: linesHttp.Unauthorised("Bearer token invalid")},
+			linesHttp.Unauthorised("Bearer token invalid"),
+			nil,
+			nil,
+		},
+		{
+			"no user",
+			&mockAuthUserDomain{},
+			linesHttp.Unauthorised("Unable to find user."),
+			nil,
+			nil,
+		},
+		{"lookup error", &mockAuthUserDomain{err: assert.AnError}, nil, nil, assert.AnError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/me", nil)
+			assert.Nil(t, err)
+
+			authError, authentication, err := NewJWTAuthenticator(test.domain).Authenticate(req)
+
+			assert.Equal(t, test.authError, authError)
+			assert.Equal(t, test.authentication, authentication)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
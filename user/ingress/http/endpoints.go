@@ -12,95 +12,75 @@ func (i *UserHttpIngress) V1SignIn(c *gin.Context) {
 	var credentials UserLogin
 	err := c.BindJSON(&credentials)
 	if err != nil {
-		httpErr := linesHttp.HttpError{Message: []string{err.Error()}}
-		c.JSON(http.StatusBadRequest, httpErr)
+		linesHttp.AbortWithProblem(c, linesHttp.BindProblem(err))
 		return
 	}
-	if httpErr := credentials.Validate(); len(httpErr.Message) > 0 {
-		c.JSON(http.StatusBadRequest, httpErr)
+	if problem := credentials.Validate(); problem != nil {
+		linesHttp.AbortWithProblem(c, problem)
 		return
 	}
 
 	user, err := i.domain.GetUserByEmail(credentials.Email)
 	if err != nil || user == nil {
-		c.JSON(http.StatusUnauthorized, linesHttp.HttpError{Message: []string{"Credentials not recognised."}})
+		linesHttp.AbortWithProblem(c, linesHttp.Unauthorised("Credentials not recognised."))
 		return
 	}
 
 	passwordCorrect := i.domain.CheckPassword(user.ID, credentials.Password)
 	if !passwordCorrect {
-		c.JSON(http.StatusUnauthorized, linesHttp.HttpError{Message: []string{"Credentials not recognised."}})
+		linesHttp.AbortWithProblem(c, linesHttp.Unauthorised("Credentials not recognised."))
 		return
 	}
 
 	jwt, err := i.domain.GenerateJWT(user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, linesHttp.HttpError{Message: []string{"Could not generate JWT."}})
+		linesHttp.AbortWithProblem(c, linesHttp.Internal("Could not generate JWT."))
+		return
 	}
 
-	expiresAt := int(jwt.ExpiresAt.Sub(time.Now()).Seconds())
-	c.SetCookie(
-		"Bearer",
-		jwt.TokenString,
-		expiresAt,
-		"/",
-		i.config.SiteDomain,
-		i.config.UseSSL,
-		true,
-	)
+	i.setBearerCookie(c, jwt.TokenString, int(jwt.ExpiresAt.Sub(time.Now()).Seconds()))
 
 	c.JSON(http.StatusOK, jwt)
 }
 
 // UserSignOutAPI is the handler for user sign out, it clears the JWT cookie.
 func (i *UserHttpIngress) V1SignOut(c *gin.Context) {
-	c.SetCookie(
-		"Bearer",
-		"",
-		0,
-		"/",
-		i.config.SiteDomain,
-		i.config.UseSSL,
-		true,
-	)
+	i.setBearerCookie(c, "", 0)
 }
 
-// UserRefreshTokenAPI is the handler for refreshing a JWT token.
+// UserRefreshTokenAPI is the handler for refreshing a JWT token, it's mounted behind RequireAuth.
 func (i *UserHttpIngress) V1RefreshToken(c *gin.Context) {
-	authError, claims := i.domain.ValidateRequestAuth(*c.Request)
-	if authError != nil {
-		c.JSON(http.StatusUnauthorized, authError)
+	claims, ok := linesHttp.CurrentClaims[*domain.JWTClaimsOut](c)
+	if !ok {
+		linesHttp.AbortWithProblem(c, linesHttp.Unauthorised("Unauthorised"))
 		return
 	}
 	newClaims, err := i.domain.GenerateJWT(claims.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, linesHttp.HttpError{Message: []string{"Could not generate JWT."}})
+		linesHttp.AbortWithProblem(c, linesHttp.Internal("Could not generate JWT."))
 		return
 	}
 
-	c.SetCookie(
-		"Bearer",
-		newClaims.TokenString,
-		int(newClaims.ExpiresAt.Sub(time.Now()).Seconds()),
-		"/",
-		i.config.SiteDomain,
-		i.config.UseSSL,
-		true,
-	)
+	i.setBearerCookie(c, newClaims.TokenString, int(newClaims.ExpiresAt.Sub(time.Now()).Seconds()))
 
 	c.JSON(http.StatusOK, newClaims)
 }
 
+// setBearerCookie sets the HttpOnly cookie requests are authenticated with, for maxAge seconds.
+func (i *UserHttpIngress) setBearerCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(i.config.SameSite)
+	c.SetCookie(linesHttp.AuthCookieName, token, maxAge, "/", i.config.SiteDomain, i.config.UseSSL, true)
+}
+
 func (i *UserHttpIngress) V1SignUp(c *gin.Context) {
 	var credentials UserSignUp
 	err := c.BindJSON(&credentials)
 	if err != nil {
-		httpErr := linesHttp.HttpError{Message: []string{err.Error()}}
-		c.JSON(http.StatusBadRequest, httpErr)
+		linesHttp.AbortWithProblem(c, linesHttp.BindProblem(err))
 		return
 	}
-	if httpErr := credentials.Validate(); len(httpErr.Message) > 0 {
-		c.JSON(http.StatusBadRequest, httpErr)
+	if problem := credentials.Validate(); problem != nil {
+		linesHttp.AbortWithProblem(c, problem)
 		return
 	}
 
@@ -112,11 +92,11 @@ func (i *UserHttpIngress) V1SignUp(c *gin.Context) {
 
 	validationErrors, user, err := i.domain.CreateUser(userForCreate)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, linesHttp.HttpError{Message: []string{"Could not create user."}})
+		linesHttp.AbortWithProblem(c, linesHttp.Internal("Could not create user."))
 		return
 	}
 	if len(validationErrors) > 0 {
-		c.JSON(http.StatusBadRequest, validationErrors)
+		linesHttp.AbortWithProblem(c, linesHttp.ValidationProblem(validationErrors))
 		return
 	}
 
@@ -129,16 +109,11 @@ func (i *UserHttpIngress) V1SignUp(c *gin.Context) {
 	c.JSON(http.StatusCreated, userReadDTO)
 }
 
+// V1GetUser is the handler for fetching the signed in user, it's mounted behind RequireAuth.
 func (i *UserHttpIngress) V1GetUser(c *gin.Context) {
-	authError, claims := i.domain.ValidateRequestAuth(*c.Request)
-	if authError != nil {
-		c.JSON(http.StatusUnauthorized, authError)
-		return
-	}
-
-	user, err := i.domain.GetUserByEmail(claims.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, linesHttp.HttpError{Message: []string{"Unable to find user."}})
+	user, ok := linesHttp.CurrentUser[*domain.UserData](c)
+	if !ok {
+		linesHttp.AbortWithProblem(c, linesHttp.Unauthorised("Unauthorised"))
 		return
 	}
 
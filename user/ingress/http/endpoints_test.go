@@ -48,13 +48,13 @@ func TestUserHttpIngress_V1SignIn_ValidationErrors(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, errors.Message, "Email is required.")
-	assert.Contains(t, errors.Message, "Password is required.")
+	assert.Contains(t, errors.Errors, domain2.DomainValidationErrors{Field: "email", Errors: []string{"Email is required."}})
+	assert.Contains(t, errors.Errors, domain2.DomainValidationErrors{Field: "password", Errors: []string{"Password is required."}})
 }
 
 type mockUserDomain struct {
@@ -87,12 +87,12 @@ func TestUserHttpIngress_V1SignIn_UserNotFound(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, errors.Message, "Credentials not recognised.")
+	assert.Equal(t, "Credentials not recognised.", errors.Detail)
 }
 
 type mockUserDomainPasswordDoesNotMatch struct {
@@ -131,12 +131,12 @@ func TestUserHttpIngress_V1SignIn_PasswordDoesNotMatch(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, errors.Message, "Credentials not recognised.")
+	assert.Equal(t, "Credentials not recognised.", errors.Detail)
 }
 
 type mockUserDomainGenerateJWTError struct {
@@ -179,12 +179,12 @@ func TestUserHttpIngress_V1SignIn_GenerateJWTError(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, errors.Message, "Could not generate JWT.")
+	assert.Equal(t, "Could not generate JWT.", errors.Detail)
 }
 
 type mockUserDomainSuccess struct {
@@ -237,6 +237,7 @@ func TestUserHttpIngress_V1SignIn_SetsCookie(t *testing.T) {
 		config: UserHttpConfig{
 			SiteDomain: "example.com",
 			UseSSL:     true,
+			SameSite:   http.SameSiteStrictMode,
 		},
 	}
 	bodyCreds, err := json.Marshal(UserLogin{
@@ -257,11 +258,11 @@ func TestUserHttpIngress_V1SignIn_SetsCookie(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	assert.Equal(t, "Bearer=token; Path=/; Domain=example.com; Max-Age=0; HttpOnly; Secure", rr.Header().Get("Set-Cookie"))
+	assert.Equal(t, "Bearer=token; Path=/; Domain=example.com; Max-Age=0; HttpOnly; Secure; SameSite=Strict", rr.Header().Get("Set-Cookie"))
 }
 
 func TestUserHttpIngress_V1SignIn_Integration(t *testing.T) {
-
+	store.SkipWithoutTestDB(t, "USER")
 	ingress := NewUserHttpIngress(nil)
 	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{ingress.domain}, func(t *testing.T) {
 		user := domain.UserForCreate{
@@ -342,7 +343,7 @@ func TestUserHttpIngress_V1RefreshToken_Success(t *testing.T) {
 
 	rr := httptest.NewRecorder()
 	router := gin.Default()
-	router.GET("/refresh-token", ingress.V1RefreshToken)
+	router.GET("/refresh-token", linesHttp.RequireAuth(&stubAuthenticator{&UserAuthentication{Claims: token}}), ingress.V1RefreshToken)
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
@@ -381,12 +382,12 @@ func TestUserHttpIngress_V1SignUp_HttpValidationErrors(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	assert.Nil(t, err)
-	assert.Contains(t, errors.Message, "Name is required.")
-	assert.Contains(t, errors.Message, "Email is required.")
-	assert.Contains(t, errors.Message, "Password is required.")
+	assert.Contains(t, errors.Errors, domain2.DomainValidationErrors{Field: "name", Errors: []string{"Name is required."}})
+	assert.Contains(t, errors.Errors, domain2.DomainValidationErrors{Field: "email", Errors: []string{"Email is required."}})
+	assert.Contains(t, errors.Errors, domain2.DomainValidationErrors{Field: "password", Errors: []string{"Password is required."}})
 }
 
 type mockUserDomainUserCreateError struct {
@@ -416,10 +417,10 @@ func TestUserHttpIngress_V1SignUp_UserCreateError(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
-	errors := linesHttp.HttpError{}
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	assert.Nil(t, err)
-	assert.Contains(t, errors.Message, "Could not create user.")
+	assert.Equal(t, "Could not create user.", errors.Detail)
 }
 
 type mockUserDomainUserCreateValidationErrors struct {
@@ -427,7 +428,7 @@ type mockUserDomainUserCreateValidationErrors struct {
 }
 
 func (m *mockUserDomainUserCreateValidationErrors) CreateUser(user domain.UserForCreate) ([]domain2.DomainValidationErrors, *domain.UserData, error) {
-	return []domain2.DomainValidationErrors{{"name", []string{"error"}}}, nil, nil
+	return []domain2.DomainValidationErrors{{Field: "name", Errors: []string{"error"}}}, nil, nil
 }
 
 func TestUserHttpIngress_V1SignUp_DomainValidationErrors(t *testing.T) {
@@ -449,10 +450,12 @@ func TestUserHttpIngress_V1SignUp_DomainValidationErrors(t *testing.T) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-	var errors []domain2.DomainValidationErrors
+	assert.Equal(t, linesHttp.ProblemContentType, rr.Header().Get("Content-Type"))
+	errors := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &errors)
 	assert.Nil(t, err)
-	assert.Contains(t, errors[0].Errors, "error")
+	assert.Equal(t, linesHttp.CodeValidationFailed, errors.Code)
+	assert.Equal(t, []domain2.DomainValidationErrors{{Field: "name", Errors: []string{"error"}}}, errors.Errors)
 }
 
 type mockUserDomainUserCreateSuccess struct {
@@ -495,6 +498,7 @@ func TestUserHttpIngress_V1SignUp_Success(t *testing.T) {
 }
 
 func TestUserHttpIngress_V1SignUp_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	ingress := NewUserHttpIngress(nil)
 	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{ingress.domain}, func(t *testing.T) {
 		body, err := json.Marshal(UserSignUp{
@@ -528,67 +532,18 @@ func TestUserHttpIngress_V1GetUser_Unauthenticated(t *testing.T) {
 	EndpointIsAuthenticatedTest(t, ingress.V1GetUser)
 }
 
-type mockUserDomainNoUser struct {
-	domain.UserDomainInterface
-}
-
-func (m *mockUserDomainNoUser) GetUserByEmail(email string) (*domain.UserData, error) {
-	return nil, assert.AnError
-}
-
-func (m *mockUserDomainNoUser) ValidateRequestAuth(r http.Request) (*linesHttp.HttpError, *domain.JWTClaimsOut) {
-	return nil, &domain.JWTClaimsOut{
-		Email: "some@email.com",
-	}
-}
-
-func TestUserHttpIngress_V1GetUser_NoUser(t *testing.T) {
-	ingress := UserHttpIngress{
-		domain: &mockUserDomainNoUser{},
-	}
-	req, err := http.NewRequest("GET", "/user", nil)
-	assert.Nil(t, err)
-
-	rr := httptest.NewRecorder()
-	router := gin.Default()
-	router.GET("/user", ingress.V1GetUser)
-	router.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusUnauthorized, rr.Code)
-	errors := linesHttp.HttpError{}
-	err = json.Unmarshal(rr.Body.Bytes(), &errors)
-	assert.Nil(t, err)
-	assert.Contains(t, errors.Message, "Unable to find user.")
-}
-
-type mockUserDomainSuccessGetUser struct {
-	domain.UserDomainInterface
-}
-
-func (m *mockUserDomainSuccessGetUser) GetUserByEmail(email string) (*domain.UserData, error) {
-	return &domain.UserData{
-		ID:    1,
-		Name:  "name",
-		Email: "email",
-	}, nil
-}
-
-func (m *mockUserDomainSuccessGetUser) ValidateRequestAuth(r http.Request) (*linesHttp.HttpError, *domain.JWTClaimsOut) {
-	return nil, &domain.JWTClaimsOut{
-		Email: "email",
-	}
-}
-
 func TestUserHttpIngress_V1GetUser_Success(t *testing.T) {
-	ingress := UserHttpIngress{
-		domain: &mockUserDomainSuccessGetUser{},
-	}
+	ingress := UserHttpIngress{}
+	authenticator := &stubAuthenticator{&UserAuthentication{
+		Claims: &domain.JWTClaimsOut{Email: "email"},
+		User:   &domain.UserData{ID: 1, Name: "name", Email: "email"},
+	}}
 	req, err := http.NewRequest("GET", "/user", nil)
 	assert.Nil(t, err)
 
 	rr := httptest.NewRecorder()
 	router := gin.Default()
-	router.GET("/user", ingress.V1GetUser)
+	router.GET("/user", linesHttp.RequireAuth(authenticator), ingress.V1GetUser)
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
@@ -601,6 +556,7 @@ func TestUserHttpIngress_V1GetUser_Success(t *testing.T) {
 }
 
 func TestUserHttpIngress_V1GetUser_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	ingress := NewUserHttpIngress(nil)
 	store.IsolatedIntegrationTest(t, []store.IntegrationTestStore{ingress.domain}, func(t *testing.T) {
 		user := domain.UserForCreate{
@@ -619,7 +575,7 @@ func TestUserHttpIngress_V1GetUser_Integration(t *testing.T) {
 
 		rr := httptest.NewRecorder()
 		router := gin.Default()
-		router.GET("/user", ingress.V1GetUser)
+		router.GET("/user", linesHttp.RequireAuth(ingress.authenticator), ingress.V1GetUser)
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
@@ -1,46 +1,124 @@
 package http
 
 import (
+	"fmt"
 	"lines/lines/http"
+	"lines/lines/logging"
 	"lines/lines/utils"
 	user_domain "lines/user/domain"
+	nethttp "net/http"
+	"time"
 )
 
 type UserHttpIngressInterface interface {
 	RegisterRoutes(e http.HttpEngine)
+	Close() error
 }
 
 type UserHttpConfig struct {
 	SiteDomain string
 	UseSSL     bool
+	// SameSite is the Bearer cookie's SameSite attribute.
+	SameSite nethttp.SameSite
+	// CredentialsRateLimit limits sign in and sign up attempts, to slow down brute forcing and sign up spam.
+	CredentialsRateLimit http.RateLimitPolicy
 }
 
 func NewUserHttpConfig() UserHttpConfig {
 	return UserHttpConfig{
 		SiteDomain: utils.GetEnvOrDefault("SITE_DOMAIN", "localhost", "string").(string),
 		UseSSL:     !utils.GetEnvOrDefault("LOCAL_DEV", "true", "bool").(bool) && !utils.GetEnvOrDefault("ALLOW_HTTP", "false", "bool").(bool),
+		SameSite:   http.SameSite(utils.GetEnvOrDefault("COOKIE_SAME_SITE", "lax", "string").(string)),
+		CredentialsRateLimit: http.NewRateLimitPolicy(http.RateLimitPolicy{
+			Name:      "user_credentials",
+			Limit:     10,
+			Window:    time.Minute,
+			Algorithm: http.SlidingWindow{},
+			Key:       http.KeyByIP,
+		}),
 	}
 }
 
 type UserHttpIngress struct {
-	config UserHttpConfig
-	domain user_domain.UserDomainInterface
+	config        UserHttpConfig
+	domain        user_domain.UserDomainInterface
+	authenticator http.Authenticator[*user_domain.JWTClaimsOut, *user_domain.UserData]
+	rateLimits    http.RateLimitStore
+	logger        logging.Logger
 }
 
+// JWTSecurityScheme is the name the user app's JWT authentication is documented under.
+const JWTSecurityScheme = "jwt"
+
+// RegisterRoutes mounts the v1 endpoints under /v1/users. They're mounted under /users too, where they were before
+// the API was versioned, so existing clients keep working.
 func (i *UserHttpIngress) RegisterRoutes(e http.HttpEngine) {
-	e.POST("/users/sign-in", i.V1SignIn)
-	e.POST("/users/sign-out", i.V1SignOut)
-	e.GET("/users/refresh-token", i.V1RefreshToken)
-	e.POST("/users/sign-up", i.V1SignUp)
-	e.GET("/users/me", i.V1GetUser)
+	http.RegisterSecurityScheme(JWTSecurityScheme, http.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "The JWT from signing in, in the Authorization header or the Bearer cookie.",
+	})
+	i.registerV1Routes(e.Group("/v1/users"))
+	i.registerV1Routes(e.Group("/users"))
+}
+
+func (i *UserHttpIngress) registerV1Routes(users http.HttpRouter) {
+	tags := []string{"users"}
+	http.Handle(users, nethttp.MethodPost, "/sign-out", http.Operation{
+		Summary:   "Sign out, clearing the Bearer cookie.",
+		Tags:      tags,
+		Responses: map[int]any{nethttp.StatusOK: nil},
+	}, i.V1SignOut)
+
+	credentials := users.Group("", http.RateLimit(i.rateLimits, i.config.CredentialsRateLimit, i.logger))
+	http.Handle(credentials, nethttp.MethodPost, "/sign-in", http.Operation{
+		Summary:   "Sign in, setting the Bearer cookie to a JWT.",
+		Tags:      tags,
+		Request:   UserLogin{},
+		Responses: map[int]any{nethttp.StatusOK: user_domain.JWTClaimsOut{}},
+	}, i.V1SignIn)
+	http.Handle(credentials, nethttp.MethodPost, "/sign-up", http.Operation{
+		Summary:   "Sign up a new user.",
+		Tags:      tags,
+		Request:   UserSignUp{},
+		Responses: map[int]any{nethttp.StatusCreated: UserReadDTO{}},
+	}, i.V1SignUp)
+
+	authenticated := users.Group("", http.RequireAuth(i.authenticator))
+	http.Handle(authenticated, nethttp.MethodGet, "/refresh-token", http.Operation{
+		Summary:   "Swap the request's JWT for a new one, setting the Bearer cookie to it.",
+		Tags:      tags,
+		Responses: map[int]any{nethttp.StatusOK: user_domain.JWTClaimsOut{}},
+		Auth:      JWTSecurityScheme,
+	}, i.V1RefreshToken)
+	http.Handle(authenticated, nethttp.MethodGet, "/me", http.Operation{
+		Summary:   "Get the signed in user.",
+		Tags:      tags,
+		Responses: map[int]any{nethttp.StatusOK: UserReadDTO{}},
+		Auth:      JWTSecurityScheme,
+	}, i.V1GetUser)
 }
 
 func NewUserHttpIngress(domain user_domain.UserDomainInterface) UserHttpIngress {
 	if domain == nil {
 		domain = user_domain.NewUserDomain()
 	}
+	logger := logging.NewLogrusHandler(utils.GetEnvOrDefault("LOG_LEVEL", "info", "string").(string))
+	rateLimits, err := http.NewRateLimitStore()
+	if err != nil {
+		logger.Fatal("user", "NewUserHttpIngress", fmt.Sprintf("Failed to create the rate limit store: %v", err))
+	}
 	return UserHttpIngress{
-		config: NewUserHttpConfig(),
-		domain: domain,
+		config:        NewUserHttpConfig(),
+		domain:        domain,
+		authenticator: NewJWTAuthenticator(domain),
+		rateLimits:    rateLimits,
+		logger:        logger,
 	}
 }
+
+// Close releases the rate limit store.
+func (i *UserHttpIngress) Close() error {
+	return i.rateLimits.Close()
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	linesHttp "lines/lines/http"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserHttpIngress_RegisterRoutes(t *testing.T) {
+	ingress := UserHttpIngress{}
+	engine := gin.New()
+
+	ingress.RegisterRoutes(engine)
+
+	var routes []string
+	for _, route := range engine.Routes() {
+		routes = append(routes, route.Method+" "+route.Path)
+	}
+	for _, prefix := range []string{"/v1/users", "/users"} {
+		assert.Contains(t, routes, "POST "+prefix+"/sign-in")
+		assert.Contains(t, routes, "POST "+prefix+"/sign-out")
+		assert.Contains(t, routes, "GET "+prefix+"/refresh-token")
+		assert.Contains(t, routes, "POST "+prefix+"/sign-up")
+		assert.Contains(t, routes, "GET "+prefix+"/me")
+	}
+	assert.Len(t, routes, 10)
+	assert.Empty(t, linesHttp.UndocumentedRoutes(engine))
+}
+
+func TestUserHttpIngress_RegisterRoutes_OpenAPI(t *testing.T) {
+	ingress := UserHttpIngress{}
+	engine := gin.New()
+	ingress.RegisterRoutes(engine)
+
+	document := linesHttp.NewOpenAPIDocument(engine, linesHttp.OpenAPIInfo{Title: "Test", Version: "1"})
+
+	signIn := document.Paths["/v1/users/sign-in"]["post"]
+	assert.Equal(t, "#/components/schemas/UserLogin", signIn.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/JWTClaimsOut", signIn.Responses["200"].Content["application/json"].Schema.Ref)
+	signUp := document.Paths["/v1/users/sign-up"]["post"]
+	assert.Equal(t, "#/components/schemas/UserSignUp", signUp.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/UserReadDTO", signUp.Responses["201"].Content["application/json"].Schema.Ref)
+	me := document.Paths["/v1/users/me"]["get"]
+	assert.Equal(t, []map[string][]string{{JWTSecurityScheme: {}}}, me.Security)
+	assert.Contains(t, document.Components.SecuritySchemes, JWTSecurityScheme)
+	assert.Contains(t, document.Components.Schemas["JWTClaimsOut"].Properties, "token_string")
+	// The registered claims are promoted from the embedded jwt.RegisteredClaims.
+	assert.Contains(t, document.Components.Schemas["JWTClaimsOut"].Properties, "exp")
+}
+
+func TestUserHttpIngress_RegisterRoutes_RateLimitsCredentials(t *testing.T) {
+	rateLimits := linesHttp.NewMemoryRateLimitStore(time.Minute)
+	defer rateLimits.Close()
+	ingress := UserHttpIngress{
+		config: UserHttpConfig{
+			CredentialsRateLimit: linesHttp.RateLimitPolicy{Name: "user_credentials", Limit: 2, Window: time.Minute},
+		},
+		rateLimits: rateLimits,
+	}
+	engine := gin.New()
+	ingress.RegisterRoutes(engine)
+
+	var codes []int
+	// Sign in and sign up share the limit, across the versioned and unversioned paths.
+	for _, path := range []string{"/v1/users/sign-in", "/users/sign-up", "/v1/users/sign-up"} {
+		rr := httptest.NewRecorder()
+		engine.ServeHTTP(rr, httptest.NewRequest("POST", path, strings.NewReader("{")))
+		codes = append(codes, rr.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+}
+
+func TestNewUserHttpConfig_CredentialsRateLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT_USER_CREDENTIALS_LIMIT", "3")
+	config := NewUserHttpConfig()
+	assert.Equal(t, "user_credentials", config.CredentialsRateLimit.Name)
+	assert.Equal(t, 3, config.CredentialsRateLimit.Limit)
+	assert.Equal(t, time.Minute, config.CredentialsRateLimit.Window)
+}
@@ -1,43 +1,27 @@
 package http
 
 import (
+	"lines/lines/domain"
 	linesHttp "lines/lines/http"
 )
 
 type UserLogin struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
-func (u *UserLogin) Validate() linesHttp.HttpError {
-	httpErr := linesHttp.HttpError{}
-	if u.Email == "" {
-		httpErr.Message = append(httpErr.Message, "Email is required.")
-	}
-	if u.Password == "" {
-		httpErr.Message = append(httpErr.Message, "Password is required.")
-	}
-	return httpErr
+func (u *UserLogin) Validate() *linesHttp.Problem {
+	return linesHttp.ValidationProblem(domain.Validate(u))
 }
 
 type UserSignUp struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
-func (u *UserSignUp) Validate() linesHttp.HttpError {
-	httpErr := linesHttp.HttpError{}
-	if u.Email == "" {
-		httpErr.Message = append(httpErr.Message, "Email is required.")
-	}
-	if u.Password == "" {
-		httpErr.Message = append(httpErr.Message, "Password is required.")
-	}
-	if u.Name == "" {
-		httpErr.Message = append(httpErr.Message, "Name is required.")
-	}
-	return httpErr
+func (u *UserSignUp) Validate() *linesHttp.Problem {
+	return linesHttp.ValidationProblem(domain.Validate(u))
 }
 
 type UserReadDTO struct {
@@ -10,7 +10,7 @@ func TestUserLogin_Validate_NoEmail(t *testing.T) {
 		Password: "password",
 	}
 	err := u.Validate()
-	assert.Equal(t, "Email is required.", err.Message[0])
+	assert.Equal(t, "Email is required.", err.Errors[0].Errors[0])
 }
 
 func TestUserLogin_Validate_NoPassword(t *testing.T) {
@@ -18,7 +18,7 @@ func TestUserLogin_Validate_NoPassword(t *testing.T) {
 		Email: "email",
 	}
 	err := u.Validate()
-	assert.Equal(t, "Password is required.", err.Message[0])
+	assert.Equal(t, "Password is required.", err.Errors[0].Errors[0])
 }
 
 func TestUserSignUp_Validate(t *testing.T) {
@@ -28,7 +28,7 @@ func TestUserSignUp_Validate(t *testing.T) {
 		Password: "password",
 	}
 	err := u.Validate()
-	assert.Equal(t, 0, len(err.Message))
+	assert.Nil(t, err)
 }
 
 func TestUserSignUp_Validate_NoEmail(t *testing.T) {
@@ -37,7 +37,7 @@ func TestUserSignUp_Validate_NoEmail(t *testing.T) {
 		Password: "password",
 	}
 	err := u.Validate()
-	assert.Equal(t, "Email is required.", err.Message[0])
+	assert.Equal(t, "Email is required.", err.Errors[0].Errors[0])
 }
 
 func TestUserSignUp_Validate_NoPassword(t *testing.T) {
@@ -46,7 +46,7 @@ func TestUserSignUp_Validate_NoPassword(t *testing.T) {
 		Email: "email",
 	}
 	err := u.Validate()
-	assert.Equal(t, "Password is required.", err.Message[0])
+	assert.Equal(t, "Password is required.", err.Errors[0].Errors[0])
 }
 
 func TestUserSignUp_Validate_NoName(t *testing.T) {
@@ -55,5 +55,5 @@ func TestUserSignUp_Validate_NoName(t *testing.T) {
 		Password: "password",
 	}
 	err := u.Validate()
-	assert.Equal(t, "Name is required.", err.Message[0])
+	assert.Equal(t, "Name is required.", err.Errors[0].Errors[0])
 }
@@ -21,10 +21,10 @@ func EndpointIsAuthenticatedTest(t *testing.T, endpoint func(c *gin.Context)) {
 	router.ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
-	response := linesHttp.HttpError{}
+	response := linesHttp.Problem{}
 	err = json.Unmarshal(rr.Body.Bytes(), &response)
 	assert.Nil(t, err)
-	assert.Contains(t, response.Message, "Unauthorised")
+	assert.Equal(t, linesHttp.CodeUnauthorised, response.Code)
 }
 
 func AuthenticateRequest(req *http.Request, email string) error {
@@ -40,3 +40,15 @@ func AuthenticateRequest(req *http.Request, email string) error {
 	req.AddCookie(&cookie)
 	return nil
 }
+
+// stubAuthenticator authenticates every request as its authentication, or none if it's nil.
+type stubAuthenticator struct {
+	authentication *UserAuthentication
+}
+
+func (s *stubAuthenticator) Authenticate(r *http.Request) (*linesHttp.Problem, *UserAuthentication, error) {
+	if s.authentication == nil {
+		return linesHttp.Unauthorised("Unauthorised"), nil, nil
+	}
+	return nil, s.authentication, nil
+}
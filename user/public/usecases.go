@@ -0,0 +1,33 @@
+// Package public holds the use cases the user app offers to other apps. Other apps may import this package, but
+// never the user app's domain or stores.
+package public
+
+import (
+	"context"
+	"lines/lines/app"
+)
+
+// User is what other apps get to know about a user.
+type User struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+// UserResolverV1 resolves users for other apps.
+//
+// Deprecated: use UserResolverV2, which passes the caller's context, and its request ID, on to the user app.
+type UserResolverV1 interface {
+	// ResolveUserByID returns the user with the given ID, or nil if there isn't one.
+	ResolveUserByID(id uint) (*User, error)
+}
+
+var ResolveUserV1 = app.UseCase[UserResolverV1]{App: "user", Name: "ResolveUser", Version: 1}
+
+// UserResolverV2 resolves users for other apps, in the context of the caller's request.
+type UserResolverV2 interface {
+	// ResolveUserByID returns the user with the given ID, or nil if there isn't one.
+	ResolveUserByID(ctx context.Context, id uint) (*User, error)
+}
+
+var ResolveUserV2 = app.UseCase[UserResolverV2]{App: "user", Name: "ResolveUser", Version: 2}
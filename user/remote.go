@@ -0,0 +1,120 @@
+package user
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"lines/lines/app"
+	linesGrpc "lines/lines/grpc"
+	"lines/lines/utils"
+	"lines/user/ingress/grpc/userpb"
+	"lines/user/public"
+	"time"
+)
+
+// RemoteUserConfig is how a process without the user app reaches the deployment that runs it.
+type RemoteUserConfig struct {
+	// GRPCAddress is the user deployment's gRPC server, e.g. "user:9090".
+	GRPCAddress string
+	UseTLS      bool
+	Timeout     time.Duration
+	// ServiceToken is sent with every call to pass the user deployment's authenticator, it's the GRPC_SERVICE_TOKEN
+	// both deployments share.
+	ServiceToken string
+}
+
+func NewRemoteUserConfig() RemoteUserConfig {
+	return RemoteUserConfig{
+		GRPCAddress:  utils.GetEnvOrDefault("USER_REMOTE_GRPC_ADDRESS", "", "string").(string),
+		UseTLS:       utils.GetEnvOrDefault("USER_REMOTE_GRPC_TLS", "false", "bool").(bool),
+		Timeout:      time.Duration(utils.GetEnvOrDefault("USER_REMOTE_TIMEOUT_MS", "5000", "int").(int)) * time.Millisecond,
+		ServiceToken: utils.GetEnvOrDefault("GRPC_SERVICE_TOKEN", "", "string").(string),
+	}
+}
+
+// RemoteUserApp stands in for the user app when it's disabled, it provides the user app's public use cases over
+// gRPC.
+type RemoteUserApp struct {
+	app.RemoteApp
+	config RemoteUserConfig
+	conn   *grpc.ClientConn
+}
+
+func NewRemoteUserApp() *RemoteUserApp {
+	return &RemoteUserApp{config: NewRemoteUserConfig()}
+}
+
+func (a *RemoteUserApp) Name() string { return "user" }
+
+// Initialise provides the user app's public use cases, backed by a client of the user deployment. The connection
+// is made lazily, so a user deployment that's down doesn't stop this one starting.
+func (a *RemoteUserApp) Initialise(useCases *app.UseCaseRegistry) error {
+	err := a.CheckConfig()
+	if err != nil {
+		return err
+	}
+	transport := insecure.NewCredentials()
+	if a.config.UseTLS {
+		transport = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	a.conn, err = grpc.NewClient(
+		a.config.GRPCAddress,
+		grpc.WithTransportCredentials(transport),
+		grpc.WithPerRPCCredentials(linesGrpc.NewServiceTokenCredentials(a.config.ServiceToken, a.config.UseTLS)),
+		grpc.WithUnaryInterceptor(linesGrpc.RequestIDClientInterceptor()),
+	)
+	if err != nil {
+		return err
+	}
+	resolver := &remoteUserResolver{client: userpb.NewUserServiceClient(a.conn), timeout: a.config.Timeout}
+	return provideUserResolver(useCases, resolver)
+}
+
+// CheckConfig checks the user deployment can be found and called.
+func (a *RemoteUserApp) CheckConfig() error {
+	var errs []error
+	if a.config.GRPCAddress == "" {
+		errs = append(errs, errors.New("USER_REMOTE_GRPC_ADDRESS must be set when the user app is disabled"))
+	}
+	if a.config.ServiceToken == "" {
+		errs = append(errs, errors.New("GRPC_SERVICE_TOKEN must be set when the user app is disabled"))
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown closes the connection to the user deployment.
+func (a *RemoteUserApp) Shutdown(ctx context.Context) error {
+	if a.conn == nil {
+		return nil
+	}
+	return a.conn.Close()
+}
+
+// remoteUserResolver implements public.UserResolverV2 with calls to the user deployment's UserService, made in the
+// caller's context so they carry its request ID.
+type remoteUserResolver struct {
+	client  userpb.UserServiceClient
+	timeout time.Duration
+}
+
+func (r *remoteUserResolver) ResolveUserByID(ctx context.Context, id uint) (*public.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	resp, err := r.client.GetUserByID(ctx, &userpb.GetUserByIDRequest{Id: uint64(id)})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &public.User{
+		ID:    uint(resp.User.GetId()),
+		Name:  resp.User.GetName(),
+		Email: resp.User.GetEmail(),
+	}, nil
+}
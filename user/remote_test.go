@@ -0,0 +1,106 @@
+package user
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	linesApp "lines/lines/app"
+	"lines/lines/logging"
+	"lines/user/ingress/grpc/userpb"
+	"lines/user/public"
+	"testing"
+	"time"
+)
+
+func TestNewRemoteUserConfig(t *testing.T) {
+	t.Setenv("USER_REMOTE_GRPC_ADDRESS", "user:9090")
+	t.Setenv("GRPC_SERVICE_TOKEN", "secret")
+	config := NewRemoteUserConfig()
+	assert.Equal(t, "user:9090", config.GRPCAddress)
+	assert.Equal(t, "secret", config.ServiceToken)
+	assert.False(t, config.UseTLS)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+}
+
+func TestRemoteUserApp_Initialise_ProvidesUseCases(t *testing.T) {
+	app := &RemoteUserApp{config: RemoteUserConfig{GRPCAddress: "localhost:9090", Timeout: time.Second, ServiceToken: "secret"}}
+	useCases := linesApp.NewUseCaseRegistry()
+
+	assert.Nil(t, app.Initialise(useCases))
+
+	resolver, err := linesApp.Resolve(useCases, public.ResolveUserV2)
+	assert.Nil(t, err)
+	assert.IsType(t, &remoteUserResolver{}, resolver)
+	_, err = linesApp.Resolve(useCases, public.ResolveUserV1)
+	assert.Nil(t, err)
+	assert.Nil(t, app.Shutdown(context.Background()))
+}
+
+func TestRemoteUserApp_CheckConfig(t *testing.T) {
+	app := &RemoteUserApp{}
+	assert.EqualError(
+		t,
+		app.CheckConfig(),
+		"USER_REMOTE_GRPC_ADDRESS must be set when the user app is disabled\n"+
+			"GRPC_SERVICE_TOKEN must be set when the user app is disabled",
+	)
+	assert.Equal(t, app.CheckConfig(), app.Initialise(linesApp.NewUseCaseRegistry()))
+	assert.Nil(t, app.Shutdown(context.Background()))
+}
+
+type mockUserServiceClient struct {
+	userpb.UserServiceClient
+	GetUserByIDArgs []*userpb.GetUserByIDRequest
+	requestIDs      []string
+	user            *userpb.User
+	err             error
+}
+
+func (m *mockUserServiceClient) GetUserByID(
+	ctx context.Context,
+	req *userpb.GetUserByIDRequest,
+	opts ...grpc.CallOption,
+) (*userpb.GetUserByIDResponse, error) {
+	m.GetUserByIDArgs = append(m.GetUserByIDArgs, req)
+	m.requestIDs = append(m.requestIDs, logging.RequestID(ctx))
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &userpb.GetUserByIDResponse{User: m.user}, nil
+}
+
+func TestRemoteUserResolver_ResolveUserByID(t *testing.T) {
+	client := &mockUserServiceClient{user: &userpb.User{Id: 1, Name: "Jake", Email: "jake@example.com"}}
+	resolver := &remoteUserResolver{client: client, timeout: time.Second}
+
+	user, err := resolver.ResolveUserByID(logging.WithRequestID(context.Background(), "abc"), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &public.User{ID: 1, Name: "Jake", Email: "jake@example.com"}, user)
+	assert.Equal(t, uint64(1), client.GetUserByIDArgs[0].Id)
+	assert.Equal(t, []string{"abc"}, client.requestIDs)
+}
+
+func TestRemoteUserResolver_ResolveUserByID_NotFound(t *testing.T) {
+	resolver := &remoteUserResolver{
+		client:  &mockUserServiceClient{err: status.Error(codes.NotFound, "User not found.")},
+		timeout: time.Second,
+	}
+
+	user, err := resolver.ResolveUserByID(context.Background(), 1)
+
+	assert.Nil(t, err)
+	assert.Nil(t, user)
+}
+
+func TestRemoteUserResolver_ResolveUserByID_Error(t *testing.T) {
+	callErr := status.Error(codes.Unavailable, "connection refused")
+	resolver := &remoteUserResolver{client: &mockUserServiceClient{err: callErr}, timeout: time.Second}
+
+	user, err := resolver.ResolveUserByID(context.Background(), 1)
+
+	assert.Equal(t, callErr, err)
+	assert.Nil(t, user)
+}
@@ -0,0 +1,21 @@
+package user
+
+import (
+	"context"
+	"lines/lines/schedule"
+)
+
+// RegisterSchedules registers the user app's housekeeping tasks.
+func (a *UserApp) RegisterSchedules(registry schedule.Registry) error {
+	return registry.Register(schedule.Task{
+		Name:       "user.purge_deleted_users",
+		Schedule:   schedule.MustCron("0 3 * * *"),
+		Run:        a.purgeDeletedUsers,
+		MissedRuns: schedule.RunMissedOnce,
+	})
+}
+
+func (a *UserApp) purgeDeletedUsers(ctx context.Context) error {
+	_, err := a.domain.PurgeDeletedUsers()
+	return err
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"lines/lines/schedule"
+	"lines/user/domain"
+	"testing"
+)
+
+type mockScheduleRegistry struct {
+	Tasks []schedule.Task
+}
+
+func (m *mockScheduleRegistry) Register(task schedule.Task) error {
+	m.Tasks = append(m.Tasks, task)
+	return nil
+}
+
+type mockPurgeDomain struct {
+	domain.UserDomainInterface
+	PurgeDeletedUsersCalls int
+}
+
+func (m *mockPurgeDomain) PurgeDeletedUsers() (int64, error) {
+	m.PurgeDeletedUsersCalls++
+	return 0, assert.AnError
+}
+
+func TestUserApp_RegisterSchedules(t *testing.T) {
+	userDomain := &mockPurgeDomain{}
+	app := UserApp{domain: userDomain}
+	registry := &mockScheduleRegistry{}
+
+	assert.Nil(t, app.RegisterSchedules(registry))
+
+	assert.Len(t, registry.Tasks, 1)
+	task := registry.Tasks[0]
+	assert.Equal(t, "user.purge_deleted_users", task.Name)
+	assert.Equal(t, schedule.RunMissedOnce, task.MissedRuns)
+	assert.Equal(t, assert.AnError, task.Run(context.Background()))
+	assert.Equal(t, 1, userDomain.PurgeDeletedUsersCalls)
+}
@@ -13,8 +13,8 @@ func TestUser_Validate_NameIsEmpty(t *testing.T) {
 	}
 	errors := user.Validate()
 	assert.Equal(t, 1, len(errors))
-	assert.Equal(t, "Name", errors[0].Field)
-	assert.Equal(t, "Name is required", errors[0].Message)
+	assert.Equal(t, "name", errors[0].Field)
+	assert.Equal(t, "Name is required.", errors[0].Message)
 }
 
 func TestUser_Validate_EmailIsEmpty(t *testing.T) {
@@ -25,8 +25,8 @@ func TestUser_Validate_EmailIsEmpty(t *testing.T) {
 	}
 	errors := user.Validate()
 	assert.Equal(t, 1, len(errors))
-	assert.Equal(t, "Email", errors[0].Field)
-	assert.Equal(t, "Email is required", errors[0].Message)
+	assert.Equal(t, "email", errors[0].Field)
+	assert.Equal(t, "Email is required.", errors[0].Message)
 }
 
 func TestUser_Validate_PasswordIsEmpty(t *testing.T) {
@@ -37,6 +37,6 @@ func TestUser_Validate_PasswordIsEmpty(t *testing.T) {
 	}
 	errors := user.Validate()
 	assert.Equal(t, 1, len(errors))
-	assert.Equal(t, "Password", errors[0].Field)
-	assert.Equal(t, "Password is required", errors[0].Message)
+	assert.Equal(t, "password", errors[0].Field)
+	assert.Equal(t, "Password is required.", errors[0].Message)
 }
@@ -1,19 +1,28 @@
 package stores
 
 import (
+	linesEvents "lines/lines/events"
 	"lines/lines/logging"
 	"lines/lines/store"
+	"time"
 )
 
 // TUserPostgresStore is an interface for a UserPostgresStore.
 type UserPostgresStoreInterface interface {
-	CreateUser(user *User) ([]store.ModelValidationError, error)
+	store.OutboxStore
+	// CreateUser saves the user, along with the events built by newEvents in the same transaction.
+	CreateUser(user *User, newEvents func(user *User) []linesEvents.Event) ([]store.ModelValidationError, error)
 	GetUserByEmail(email string) (*User, error)
 	GetUserByID(id uint) (*User, error)
 	UpdateUser(user *User) ([]store.ModelValidationError, error)
 	DeleteUser(user *User) error
+	// PurgeDeletedUsers permanently deletes users that were soft deleted before the given time.
+	PurgeDeletedUsers(before time.Time) (int64, error)
 	BeginTransaction() error
 	RollbackTransaction() error
+	// Migrate brings the user tables up to date with the store's models.
+	Migrate() error
+	Close() error
 }
 
 // UserPostgresStore is a struct that contains an initialized PostgresStore instance.
@@ -25,9 +34,14 @@ type UserPostgresStore struct {
 func (s *UserPostgresStore) Models() []store.PostgresModel {
 	return []store.PostgresModel{
 		User{},
+		store.OutboxMessage{},
 	}
 }
 
+func (s *UserPostgresStore) Migrate() error {
+	return store.MigrateModels(s.Postgres, s.Models())
+}
+
 // NewUserPostgresStore is a function that returns a new UserPostgresStore instance.
 func NewUserPostgresStore() *UserPostgresStore {
 	appName := "USER"
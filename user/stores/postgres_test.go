@@ -14,8 +14,16 @@ func (m MockModel) Validate() []store.ModelValidationError {
 }
 
 func TestNewUserPostgresStore_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	assert.Equal(t, pgStore.PostgresStore.Config.AppName, "USER")
 	assert.NotNil(t, pgStore.Logger)
 	assert.NotNil(t, pgStore.PostgresStore.Postgres)
 }
+
+func TestUserPostgresStore_Migrate_Integration(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
+	pgStore := NewUserPostgresStore()
+	assert.Nil(t, pgStore.Migrate())
+	assert.Nil(t, pgStore.Close())
+}
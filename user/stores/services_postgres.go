@@ -1,13 +1,28 @@
 package stores
 
-import "lines/lines/store"
+import (
+	"gorm.io/gorm"
+	linesEvents "lines/lines/events"
+	"lines/lines/store"
+	"strconv"
+	"time"
+)
 
-func (s *UserPostgresStore) CreateUser(user *User) ([]store.ModelValidationError, error) {
+func (s *UserPostgresStore) CreateUser(
+	user *User,
+	newEvents func(user *User) []linesEvents.Event,
+) ([]store.ModelValidationError, error) {
 	validationErrors := user.Validate()
 	if len(validationErrors) > 0 {
 		return validationErrors, nil
 	}
-	return []store.ModelValidationError{}, s.Postgres.Create(user).Error
+	return []store.ModelValidationError{}, s.Postgres.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(user).Error
+		if err != nil || newEvents == nil {
+			return err
+		}
+		return store.WriteOutbox(tx, "user", strconv.FormatUint(uint64(user.ID), 10), newEvents(user)...)
+	})
 }
 
 func (s *UserPostgresStore) GetUserByEmail(email string) (*User, error) {
@@ -44,3 +59,8 @@ func (s *UserPostgresStore) UpdateUser(user *User) ([]store.ModelValidationError
 func (s *UserPostgresStore) DeleteUser(user *User) error {
 	return s.Postgres.Delete(user).Error
 }
+
+func (s *UserPostgresStore) PurgeDeletedUsers(before time.Time) (int64, error) {
+	result := s.Postgres.Unscoped().Where("deleted_at < ?", before).Delete(&User{})
+	return result.RowsAffected, result.Error
+}
@@ -2,11 +2,16 @@ package stores
 
 import (
 	"github.com/stretchr/testify/assert"
+	linesEvents "lines/lines/events"
 	"lines/lines/store"
+	"lines/user/events"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestUserPostgresStore_CreateUser(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -15,7 +20,7 @@ func TestUserPostgresStore_CreateUser(t *testing.T) {
 			Email:    "some@email.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -28,7 +33,117 @@ func TestUserPostgresStore_CreateUser(t *testing.T) {
 	})
 }
 
+func TestUserPostgresStore_CreateUser_WritesOutbox(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
+	pgStore := NewUserPostgresStore()
+	stores := []store.IntegrationTestStore{pgStore}
+	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
+		user := User{
+			Name:     "Test User",
+			Email:    "some@email.com",
+			Password: "password",
+		}
+		validationErrors, err := pgStore.CreateUser(&user, func(user *User) []linesEvents.Event {
+			return []linesEvents.Event{events.UserCreated{ID: user.ID, Name: user.Name, Email: user.Email}}
+		})
+		assert.Nil(t, err)
+		assert.Empty(t, validationErrors)
+
+		var delivered []linesEvents.Event
+		claimed, err := pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+			assert.Equal(t, "user", message.AggregateType)
+			assert.Equal(t, strconv.FormatUint(uint64(user.ID), 10), message.AggregateID)
+			delivered = append(delivered, message.Event())
+			return nil
+		}, func(int) time.Duration { return time.Second })
+		assert.Nil(t, err)
+		assert.Equal(t, 1, claimed)
+		assert.Len(t, delivered, 1)
+		assert.Equal(t, "user.created", delivered[0].EventName())
+
+		claimed, err = pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+			t.Error("Delivered message was relayed again")
+			return nil
+		}, func(int) time.Duration { return time.Second })
+		assert.Nil(t, err)
+		assert.Equal(t, 0, claimed)
+	})
+}
+
+func TestUserPostgresStore_CreateUser_FailedRelayIsRetried(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
+	pgStore := NewUserPostgresStore()
+	stores := []store.IntegrationTestStore{pgStore}
+	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
+		user := User{
+			Name:     "Test User",
+			Email:    "some@email.com",
+			Password: "password",
+		}
+		_, err := pgStore.CreateUser(&user, func(user *User) []linesEvents.Event {
+			return []linesEvents.Event{
+				events.UserCreated{ID: user.ID, Name: user.Name, Email: user.Email},
+				events.UserCreated{ID: user.ID, Name: user.Name, Email: user.Email},
+			}
+		})
+		assert.Nil(t, err)
+
+		claimed, err := pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+			return assert.AnError
+		}, func(int) time.Duration { return -time.Second })
+		assert.Nil(t, err)
+		assert.Equal(t, 1, claimed, "Only the first message of the aggregate is claimed")
+
+		var attempts []int
+		claimed, err = pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+			attempts = append(attempts, message.Attempts)
+			return nil
+		}, func(int) time.Duration { return time.Second })
+		assert.Nil(t, err)
+		assert.Equal(t, 1, claimed)
+		assert.Equal(t, []int{1}, attempts)
+	})
+}
+
+func TestUserPostgresStore_CreateUser_ExpiredLeaseIsRelayedAgain(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
+	pgStore := NewUserPostgresStore()
+	stores := []store.IntegrationTestStore{pgStore}
+	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
+		user := User{
+			Name:     "Test User",
+			Email:    "some@email.com",
+			Password: "password",
+		}
+		_, err := pgStore.CreateUser(&user, func(user *User) []linesEvents.Event {
+			return []linesEvents.Event{events.UserCreated{ID: user.ID, Name: user.Name, Email: user.Email}}
+		})
+		assert.Nil(t, err)
+
+		var relayedAgain int
+		claimed, err := pgStore.RelayOutboxBatch(10, 10*time.Millisecond, func(message store.OutboxMessage) error {
+			claimed, err := pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+				t.Error("Leased message was relayed twice at once")
+				return nil
+			}, func(int) time.Duration { return time.Second })
+			assert.Nil(t, err)
+			assert.Equal(t, 0, claimed)
+
+			time.Sleep(20 * time.Millisecond)
+			relayedAgain, err = pgStore.RelayOutboxBatch(10, time.Minute, func(message store.OutboxMessage) error {
+				return nil
+			}, func(int) time.Duration { return time.Second })
+			assert.Nil(t, err)
+			return nil
+		}, func(int) time.Duration { return time.Second })
+		assert.NotNil(t, err, "The first relay lost its lease")
+		assert.Equal(t, 1, claimed)
+		assert.Equal(t, 1, relayedAgain, "The message is claimed again once its lease is up")
+	})
+}
+
 func TestUserPostgresStore_CreateUser_ValidationErrors(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -36,13 +151,14 @@ func TestUserPostgresStore_CreateUser_ValidationErrors(t *testing.T) {
 			Email:    "someemail.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.NotEmpty(t, validationErrors)
 	})
 }
 
 func TestUserPostgresStore_GetUserByEmail(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -51,7 +167,7 @@ func TestUserPostgresStore_GetUserByEmail(t *testing.T) {
 			Email:    "some@email.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -65,6 +181,7 @@ func TestUserPostgresStore_GetUserByEmail(t *testing.T) {
 }
 
 func TestUserPostgresStore_GetUserByEmail_NoUser(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -75,6 +192,7 @@ func TestUserPostgresStore_GetUserByEmail_NoUser(t *testing.T) {
 }
 
 func TestUserPostgresStore_GetUserByID(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -83,7 +201,7 @@ func TestUserPostgresStore_GetUserByID(t *testing.T) {
 			Email:    "some@email.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -97,6 +215,7 @@ func TestUserPostgresStore_GetUserByID(t *testing.T) {
 }
 
 func TestUserPostgresStore_GetUserByID_NoUser(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -107,6 +226,7 @@ func TestUserPostgresStore_GetUserByID_NoUser(t *testing.T) {
 }
 
 func TestUserPostgresStore_UpdateUser(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -115,7 +235,7 @@ func TestUserPostgresStore_UpdateUser(t *testing.T) {
 			Email:    "some@email.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -133,6 +253,7 @@ func TestUserPostgresStore_UpdateUser(t *testing.T) {
 }
 
 func TestUserPostgresStore_UpdateUser_ValidationErrors(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -141,7 +262,7 @@ func TestUserPostgresStore_UpdateUser_ValidationErrors(t *testing.T) {
 			Email:    "alala",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -157,6 +278,7 @@ func TestUserPostgresStore_UpdateUser_ValidationErrors(t *testing.T) {
 }
 
 func TestUserPostgresStore_DeleteUser(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
 	pgStore := NewUserPostgresStore()
 	stores := []store.IntegrationTestStore{pgStore}
 	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
@@ -165,7 +287,7 @@ func TestUserPostgresStore_DeleteUser(t *testing.T) {
 			Email:    "some@user.com",
 			Password: "password",
 		}
-		validationErrors, err := pgStore.CreateUser(&user)
+		validationErrors, err := pgStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
@@ -176,3 +298,29 @@ func TestUserPostgresStore_DeleteUser(t *testing.T) {
 		assert.Nil(t, dbUser)
 	})
 }
+
+func TestUserPostgresStore_PurgeDeletedUsers(t *testing.T) {
+	store.SkipWithoutTestDB(t, "USER")
+	pgStore := NewUserPostgresStore()
+	stores := []store.IntegrationTestStore{pgStore}
+	store.IsolatedIntegrationTest(t, stores, func(t *testing.T) {
+		deleted := User{Name: "Deleted User", Email: "deleted@user.com", Password: "password"}
+		kept := User{Name: "Kept User", Email: "kept@user.com", Password: "password"}
+		for _, user := range []*User{&deleted, &kept} {
+			validationErrors, err := pgStore.CreateUser(user, nil)
+			assert.Nil(t, err)
+			assert.Empty(t, validationErrors)
+		}
+		assert.Nil(t, pgStore.DeleteUser(&deleted))
+
+		purged, err := pgStore.PurgeDeletedUsers(time.Now().Add(-time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), purged)
+		purged, err = pgStore.PurgeDeletedUsers(time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), purged)
+		dbUser, err := pgStore.GetUserByID(kept.ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, dbUser)
+	})
+}
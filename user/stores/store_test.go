@@ -7,6 +7,7 @@ import (
 )
 
 func TestNewUserStore(t *testing.T) {
+	store2.SkipWithoutTestDB(t, "USER")
 	store := NewUserStore()
 	assert.NotNil(t, store)
 	assert.NotNil(t, store.UserPostgresStore)
@@ -17,7 +18,7 @@ func TestNewUserStore(t *testing.T) {
 			Email:    "Barry",
 			Password: "password",
 		}
-		validationErrors, err := store.UserPostgresStore.CreateUser(&user)
+		validationErrors, err := store.UserPostgresStore.CreateUser(&user, nil)
 		assert.Nil(t, err)
 		assert.Empty(t, validationErrors)
 		assert.NotEqual(t, uint(0), user.ID)
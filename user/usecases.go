@@ -0,0 +1,48 @@
+package user
+
+import (
+	"context"
+	"lines/lines/app"
+	"lines/user/domain"
+	"lines/user/public"
+)
+
+// provideUserResolver provides every version of the user resolver use case, backed by the latest.
+func provideUserResolver(useCases *app.UseCaseRegistry, resolver public.UserResolverV2) error {
+	err := app.Provide[public.UserResolverV2](useCases, public.ResolveUserV2, resolver)
+	if err != nil {
+		return err
+	}
+	return app.Provide[public.UserResolverV1](useCases, public.ResolveUserV1, &userResolverV1{resolver: resolver})
+}
+
+// userResolverV1 implements public.UserResolverV1 with a public.UserResolverV2, without a caller's context.
+type userResolverV1 struct {
+	resolver public.UserResolverV2
+}
+
+func (r *userResolverV1) ResolveUserByID(id uint) (*public.User, error) {
+	return r.resolver.ResolveUserByID(context.Background(), id)
+}
+
+// userResolver implements public.UserResolverV2 on top of the user domain.
+type userResolver struct {
+	domain domain.UserDomainInterface
+}
+
+// ResolveUserByID reads the user from the domain, which doesn't take a context, so ctx only stops the lookup if the
+// caller has already given up on it. It's the remote resolver that carries ctx's deadline and request ID.
+func (r *userResolver) ResolveUserByID(ctx context.Context, id uint) (*public.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	user, err := r.domain.GetUserByID(id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &public.User{
+		ID:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	linesApp "lines/lines/app"
+	"lines/user/domain"
+	"lines/user/public"
+	"testing"
+)
+
+type mockResolverDomain struct {
+	domain.UserDomainInterface
+	user *domain.UserData
+	err  error
+}
+
+func (m *mockResolverDomain) GetUserByID(id uint) (*domain.UserData, error) {
+	return m.user, m.err
+}
+
+func TestUserResolver_ResolveUserByID(t *testing.T) {
+	resolver := userResolver{domain: &mockResolverDomain{
+		user: &domain.UserData{ID: 1, Name: "test", Email: "test@test.com"},
+	}}
+
+	user, err := resolver.ResolveUserByID(context.Background(), 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &public.User{ID: 1, Name: "test", Email: "test@test.com"}, user)
+}
+
+func TestUserResolver_ResolveUserByID_NotFound(t *testing.T) {
+	resolver := userResolver{domain: &mockResolverDomain{}}
+
+	user, err := resolver.ResolveUserByID(context.Background(), 1)
+
+	assert.Nil(t, err)
+	assert.Nil(t, user)
+}
+
+func TestUserResolver_ResolveUserByID_Error(t *testing.T) {
+	resolver := userResolver{domain: &mockResolverDomain{err: assert.AnError}}
+
+	user, err := resolver.ResolveUserByID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, user)
+}
+
+func TestUserResolver_ResolveUserByID_CallerGaveUp(t *testing.T) {
+	resolver := userResolver{domain: &mockResolverDomain{user: &domain.UserData{ID: 1}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	user, err := resolver.ResolveUserByID(ctx, 1)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, user)
+}
+
+func TestProvideUserResolver_V1UsesV2(t *testing.T) {
+	useCases := linesApp.NewUseCaseRegistry()
+	resolver := &userResolver{domain: &mockResolverDomain{user: &domain.UserData{ID: 1, Name: "test"}}}
+	assert.Nil(t, provideUserResolver(useCases, resolver))
+
+	v2, err := linesApp.Resolve(useCases, public.ResolveUserV2)
+	assert.Nil(t, err)
+	assert.Equal(t, resolver, v2)
+	v1, err := linesApp.Resolve(useCases, public.ResolveUserV1)
+	assert.Nil(t, err)
+	user, err := v1.ResolveUserByID(1)
+	assert.Nil(t, err)
+	assert.Equal(t, &public.User{ID: 1, Name: "test"}, user)
+}